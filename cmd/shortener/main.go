@@ -5,17 +5,27 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/augustjourney/urlshrt/internal/analytics"
 	"github.com/augustjourney/urlshrt/internal/app"
+	"github.com/augustjourney/urlshrt/internal/auth"
 	"github.com/augustjourney/urlshrt/internal/config"
 	"github.com/augustjourney/urlshrt/internal/controller"
+	"github.com/augustjourney/urlshrt/internal/deleter"
+	"github.com/augustjourney/urlshrt/internal/health"
 	"github.com/augustjourney/urlshrt/internal/infra"
+	"github.com/augustjourney/urlshrt/internal/jobs"
 	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/metrics"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
+	"github.com/augustjourney/urlshrt/internal/screening"
 	"github.com/augustjourney/urlshrt/internal/service"
-	"github.com/augustjourney/urlshrt/internal/storage"
-	"github.com/augustjourney/urlshrt/internal/storage/infile"
-	"github.com/augustjourney/urlshrt/internal/storage/postgres"
+	"github.com/augustjourney/urlshrt/internal/shortcode"
+	"github.com/augustjourney/urlshrt/internal/storage/cache"
+	"github.com/augustjourney/urlshrt/internal/storage/instrumented"
+	"github.com/augustjourney/urlshrt/internal/tracing"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -25,74 +35,174 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
 
-	config := config.New()
+	// конфиг пишет в лог ошибки чтения файла — логгер нужен раньше него
 	logger.New()
+	config := config.New()
 
-	logger.Log.Printf("Build version: %v\n", buildVersion)
-	logger.Log.Printf("Build date: %v\n", buildDate)
-	logger.Log.Printf("Build commit: %v\n", buildCommit)
+	err := logger.Setup(config.LogLevel, config.LogFormat)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Could not set up logger")
+	}
 
-	db, err := infra.InitPostgres(config)
+	err = config.Validate()
 	if err != nil {
-		panic(err)
+		logger.Log.WithError(err).Fatal("Invalid config")
 	}
 
-	defer db.Close()
+	logger.Log.WithFields(logrus.Fields{
+		"version": buildVersion,
+		"date":    buildDate,
+		"commit":  buildCommit,
+	}).Info("Build info")
 
-	var repo storage.IRepo
+	store, err := infra.NewStorage(context.Background(), config)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Could not init storage")
+	}
+
+	logger.Log.WithField("backend", store.Backend).Info("Using storage")
 
-	repo, err = postgres.New(context.Background(), db)
+	exporter, err := tracing.NewExporter(config.TracingExporter, os.Stdout)
 	if err != nil {
-		logger.Log.Error("Could not connect to postgres, using in-file storage")
-		repo = infile.New(config)
+		logger.Log.WithError(err).Fatal("Could not init tracing")
 	}
+	tracerProvider := tracing.Setup(exporter)
 
-	urlService := service.New(repo, config)
+	appMetrics := metrics.New()
 
-	httpController := controller.NewHTTPController(&urlService)
-	grpcController := controller.NewGrpcController(&urlService)
+	// замеряется само хранилище — попадания в кэш в задержки хранилища не входят
+	repo := store.Repo
+	repo = instrumented.New(repo, appMetrics)
 
-	httpServer := app.NewHTTPServer(httpController, db)
-	grpcServer := app.NewGrpcServer(grpcController)
-
-	go func() {
-		if config.EnableHTTPS {
-			err = app.RunHTTPS(httpServer, config)
-			// Если происходит ошибка — просто логируем ее
-			// И запускаем на http
-			if err != nil {
-				logger.Log.Fatal(err)
-			}
-		}
+	if config.CacheSize > 0 {
+		repo = cache.New(repo, config.CacheSize, config.CacheTTL.Duration, config.CacheNegativeTTL.Duration)
+	}
 
-		err = app.RunHTTP(httpServer, config)
+	generator, err := shortcode.New(config.ShortCodeStrategy, config.ShortCodeLength, store.Counter)
+	if err != nil {
+		panic(err)
+	}
+
+	tracker := analytics.NewTracker(store.Clicks, config.AnalyticsBufferSize, config.AnalyticsFlushInterval.Duration)
+	tracker.Start()
+
+	urlDeleter := deleter.New(repo, config.DeleteWorkers, config.DeleteQueueSize, config.DeleteBatchSize, config.DeleteFlushInterval.Duration)
+	urlDeleter.Start()
+
+	lifecycle := app.NewLifecycle(config.ShutdownTimeout.Duration)
+
+	accountsService := accounts.New(store.Accounts)
+
+	serviceOpts := []service.Option{
+		service.WithAnalytics(tracker),
+		service.WithGenerator(generator),
+		service.WithDeleter(urlDeleter),
+		service.WithRedirectObserver(appMetrics),
+		service.WithUserDirectory(accountsService),
+	}
+
+	if config.BlocklistPath != "" {
+		screener, err := screening.New(config.BlocklistPath)
 		if err != nil {
-			panic(err)
+			logger.Log.WithError(err).Fatal("Could not load blocklist")
 		}
-	}()
+		lifecycle.Go("blocklist watcher", func(ctx context.Context) {
+			screener.Watch(ctx, config.BlocklistReloadInterval.Duration)
+		})
+		serviceOpts = append(serviceOpts, service.WithScreener(screener))
+	}
 
-	go func() {
-		err = app.RunGRPC(grpcServer, config)
+	urlService := service.New(repo, config, serviceOpts...)
+
+	lifecycle.Go("expired reaper", jobs.NewExpiredReaper(repo, config.ExpiredReapInterval.Duration).Run)
+
+	if config.DeletedRetention.Duration > 0 {
+		lifecycle.Go("deleted purger", jobs.NewDeletedPurger(repo, store.Clicks, config.DeletedRetention.Duration, config.DeletedPurgeInterval.Duration).Run)
+	}
+
+	if store.File != nil && config.FileCompactInterval.Duration > 0 {
+		lifecycle.Go("log compactor", jobs.NewLogCompactor(store.File, config.FileCompactInterval.Duration).Run)
+	}
+
+	authSecret := config.AuthSecret
+	if authSecret == "" {
+		// Без заданного ключа токены перестанут проходить проверку после перезапуска
+		logger.Log.Warn("Auth secret is not set, generating a random one")
+		authSecret, err = auth.GenerateSecret()
 		if err != nil {
 			panic(err)
 		}
-	}()
+	}
+
+	authManager, err := auth.New(authSecret, config.AuthPreviousSecrets, config.AuthTokenTTL.Duration)
+	if err != nil {
+		panic(err)
+	}
+
+	httpController := controller.NewHTTPController(&urlService, authManager, accountsService)
+	grpcController := controller.NewGrpcController(&urlService)
+
+	// лимиты общие для http и grpc
+	limits := ratelimit.Limits{
+		Create:   ratelimit.New(config.RateLimitCreateRPS, config.RateLimitCreateBurst),
+		Redirect: ratelimit.New(config.RateLimitRedirectRPS, config.RateLimitRedirectBurst),
+	}
+
+	checker := health.New(health.DefaultTimeout)
+	checker.Add("storage", repo.Ping)
+	if store.File != nil {
+		checker.Add("file", store.File.CheckWritable)
+	}
+	checker.Add("grpc", checker.GRPCCheck)
+	lifecycle.Go("health watcher", func(ctx context.Context) {
+		checker.Watch(ctx, config.HealthCheckInterval.Duration)
+	})
+
+	httpServer := app.NewHTTPServer(httpController, repo, limits, authManager, appMetrics, checker)
+	grpcServer := app.NewGrpcServer(grpcController, authManager, accountsService, limits, appMetrics, checker)
 
-	ch := make(chan os.Signal, 1)
+	if config.EnableHTTPS {
+		pem, key, err := config.GetCerts()
+		if err != nil {
+			logger.Log.WithError(err).Fatal("Could not get certs")
+		}
+		lifecycle.AddServer(app.FiberServer("https", httpServer, app.ListenTLS(config.ServerAddress, pem, key)))
+	} else {
+		lifecycle.AddServer(app.FiberServer("http", httpServer, app.ListenTCP(config.ServerAddress)))
+	}
 
-	signal.Notify(ch, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	lifecycle.AddServer(app.GRPCServer(grpcServer, config.GrpcServerAddress, checker))
 
-	<-ch
+	if config.MetricsAddress != "" {
+		lifecycle.AddServer(app.MetricsServer(app.NewMetricsServer(appMetrics, config.MetricsAddress)))
+	}
 
-	logger.Log.Info("Gracefully shutting down...")
+	// балансировщик перестает слать запросы, пока сервер еще отвечает
+	lifecycle.BeforeStop("readiness", func(ctx context.Context) error {
+		checker.Drain(ctx, config.ShutdownDrainDelay.Duration)
+		return nil
+	})
 
-	httpServer.ShutdownWithTimeout(10 * time.Second)
-	grpcServer.GracefulStop()
+	// буферы сбрасываются в хранилище, когда новых запросов уже не будет, и до его закрытия
+	lifecycle.AfterStop("url deleter", urlDeleter.Close)
+	lifecycle.AfterStop("click tracker", tracker.Close)
+	lifecycle.AfterStop("tracer provider", tracerProvider.Shutdown)
+	lifecycle.AfterStop("storage", func(ctx context.Context) error {
+		return store.Close()
+	})
 
-	logger.Log.Info("Closing connections...")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
 
-	db.Close()
+	err = lifecycle.Run(ctx)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Server stopped with error")
+	}
 
 	logger.Log.Info("Server was shutdown successfully")
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/infra"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/migrations"
+	"github.com/sirupsen/logrus"
+)
+
+const migrateUsage = `usage: shortener migrate up|down [steps]|status [flags]
+
+  up      apply all pending migrations
+  down    roll back the last applied migrations, 1 by default
+  status  show applied and pending migrations
+
+flags are the same as for the server, e.g. -d for database DSN`
+
+// выполняет подкоманду migrate: shortener migrate up|down [steps]|status [flags]
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	command := args[0]
+	args = args[1:]
+
+	steps := 1
+	if command == "down" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		steps = n
+		args = args[1:]
+	}
+
+	// Остальные аргументы — обычные флаги сервиса, их разбирает config
+	os.Args = append([]string{os.Args[0]}, args...)
+
+	config := config.New()
+	logger.New()
+
+	db, err := infra.InitPostgres(config)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Could not connect to database")
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Could not init migrator")
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		err = infra.Migrate(ctx, db)
+	case "down":
+		var rolledBack []migrations.Migration
+		rolledBack, err = migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			logger.Log.WithFields(logrus.Fields{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Info("Rolled back migration")
+		}
+	case "status":
+		var statuses []migrations.Status
+		statuses, err = migrator.Status(ctx)
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Could not run migrations")
+	}
+}
@@ -0,0 +1,177 @@
+// модуль accounts отвечает за аккаунты сервисных клиентов и их API-ключи.
+// ключ показывается только при создании, в хранилище попадает лишь его sha256-хэш.
+// ссылки, созданные по ключу, принадлежат аккаунту — его ID используется как ID пользователя.
+package accounts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Префикс API-ключа — по нему ключ отличается от токена пользователя
+const KeyPrefix = "urlshrt_"
+
+// количество символов ключа, которое хранится открыто для его опознания
+const displayPrefixLength = len(KeyPrefix) + 6
+
+// Ошибка если аккаунт или ключ не найден
+var ErrNotFound = errors.New("not found")
+
+// Ошибка если ключ неизвестен или отозван
+var ErrInvalidKey = errors.New("invalid api key")
+
+// Ошибка если не указано имя аккаунта
+var ErrEmptyName = errors.New("account name is required")
+
+// аккаунт сервисного клиента
+type Account struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// API-ключ аккаунта — без самого ключа
+type APIKey struct {
+	ID        string     `json:"id"`
+	AccountID string     `json:"account_id"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Интерфейс хранилища аккаунтов и ключей
+type IStore interface {
+	CreateAccount(ctx context.Context, account Account) error
+	GetAccount(ctx context.Context, id string) (Account, error)
+	CreateKey(ctx context.Context, key APIKey) error
+	GetKeyByHash(ctx context.Context, hash string) (APIKey, error)
+	RevokeKey(ctx context.Context, id string, revokedAt time.Time) error
+}
+
+// управляет аккаунтами и проверяет API-ключи
+type Service struct {
+	store IStore
+}
+
+// создает аккаунт
+func (s *Service) CreateAccount(ctx context.Context, name string) (Account, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Account{}, ErrEmptyName
+	}
+
+	account := Account{
+		ID:        uuid.NewString(),
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err := s.store.CreateAccount(ctx, account)
+	if err != nil {
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+// выпускает новый ключ для аккаунта, сам ключ возвращается только здесь
+func (s *Service) CreateKey(ctx context.Context, accountID string) (string, APIKey, error) {
+	_, err := s.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	secret, err := generateKey()
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	key := APIKey{
+		ID:        uuid.NewString(),
+		AccountID: accountID,
+		Prefix:    secret[:displayPrefixLength],
+		Hash:      hashKey(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = s.store.CreateKey(ctx, key)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+
+	return secret, key, nil
+}
+
+// отзывает ключ — после этого он перестает приниматься
+func (s *Service) RevokeKey(ctx context.Context, id string) error {
+	return s.store.RevokeKey(ctx, id, time.Now().UTC())
+}
+
+// проверяет, есть ли аккаунт с таким ID
+func (s *Service) Exists(ctx context.Context, id string) (bool, error) {
+	_, err := s.store.GetAccount(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// проверяет ключ и возвращает ID аккаунта, которому он принадлежит
+func (s *Service) Authenticate(ctx context.Context, secret string) (string, error) {
+	if !IsAPIKey(secret) {
+		return "", ErrInvalidKey
+	}
+
+	key, err := s.store.GetKeyByHash(ctx, hashKey(secret))
+	if errors.Is(err, ErrNotFound) {
+		return "", ErrInvalidKey
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if key.RevokedAt != nil {
+		return "", ErrInvalidKey
+	}
+
+	return key.AccountID, nil
+}
+
+// проверяет, похожа ли строка на API-ключ
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, KeyPrefix)
+}
+
+func hashKey(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func generateKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// создает новый экземпляр сервиса аккаунтов
+func New(store IStore) *Service {
+	return &Service{
+		store: store,
+	}
+}
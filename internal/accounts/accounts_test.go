@@ -0,0 +1,150 @@
+package accounts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// хранилище в памяти для тестов сервиса
+type testStore struct {
+	accounts map[string]Account
+	keys     map[string]APIKey
+}
+
+func newTestStore() *testStore {
+	return &testStore{
+		accounts: make(map[string]Account),
+		keys:     make(map[string]APIKey),
+	}
+}
+
+func (s *testStore) CreateAccount(ctx context.Context, account Account) error {
+	s.accounts[account.ID] = account
+	return nil
+}
+
+func (s *testStore) GetAccount(ctx context.Context, id string) (Account, error) {
+	account, ok := s.accounts[id]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+func (s *testStore) CreateKey(ctx context.Context, key APIKey) error {
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *testStore) GetKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	for _, key := range s.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return APIKey{}, ErrNotFound
+}
+
+func (s *testStore) RevokeKey(ctx context.Context, id string, revokedAt time.Time) error {
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.RevokedAt = &revokedAt
+	s.keys[id] = key
+	return nil
+}
+
+func TestService_CreateAccount(t *testing.T) {
+	service := New(newTestStore())
+	ctx := context.Background()
+
+	account, err := service.CreateAccount(ctx, "  backend-jobs ")
+	require.NoError(t, err)
+	assert.NotEmpty(t, account.ID)
+	assert.Equal(t, "backend-jobs", account.Name)
+
+	exists, err := service.Exists(ctx, account.ID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = service.Exists(ctx, "unknown")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = service.CreateAccount(ctx, "   ")
+	assert.ErrorIs(t, err, ErrEmptyName)
+}
+
+func TestService_CreateKey(t *testing.T) {
+	store := newTestStore()
+	service := New(store)
+	ctx := context.Background()
+
+	account, err := service.CreateAccount(ctx, "backend-jobs")
+	require.NoError(t, err)
+
+	secret, key, err := service.CreateKey(ctx, account.ID)
+	require.NoError(t, err)
+	assert.True(t, IsAPIKey(secret))
+	assert.Equal(t, account.ID, key.AccountID)
+	assert.Equal(t, secret[:displayPrefixLength], key.Prefix)
+
+	// хранится только хэш ключа
+	hash := sha256.Sum256([]byte(secret))
+	require.Contains(t, store.keys, key.ID)
+	assert.Equal(t, hex.EncodeToString(hash[:]), store.keys[key.ID].Hash)
+	assert.NotContains(t, store.keys[key.ID].Hash, secret)
+
+	// каждый ключ уникален
+	other, _, err := service.CreateKey(ctx, account.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	_, _, err = service.CreateKey(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_Authenticate(t *testing.T) {
+	service := New(newTestStore())
+	ctx := context.Background()
+
+	account, err := service.CreateAccount(ctx, "backend-jobs")
+	require.NoError(t, err)
+
+	secret, key, err := service.CreateKey(ctx, account.ID)
+	require.NoError(t, err)
+
+	accountID, err := service.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, accountID)
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "not an api key", secret: "some-user-token"},
+		{name: "unknown key", secret: KeyPrefix + "unknown"},
+		{name: "prefix only", secret: key.Prefix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Authenticate(ctx, tt.secret)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+		})
+	}
+
+	// отозванный ключ больше не принимается
+	require.NoError(t, service.RevokeKey(ctx, key.ID))
+	_, err = service.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	assert.ErrorIs(t, service.RevokeKey(ctx, "unknown"), ErrNotFound)
+}
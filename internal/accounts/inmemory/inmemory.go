@@ -0,0 +1,90 @@
+// модуль отвечает за хранение аккаунтов и API-ключей в оперативной памяти.
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+)
+
+// хранилище аккаунтов в памяти
+type Store struct {
+	mu       sync.RWMutex
+	accounts map[string]accounts.Account
+	keys     map[string]accounts.APIKey
+}
+
+// сохраняет аккаунт
+func (s *Store) CreateAccount(ctx context.Context, account accounts.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts[account.ID] = account
+
+	return nil
+}
+
+// получает аккаунт по ID
+func (s *Store) GetAccount(ctx context.Context, id string) (accounts.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return accounts.Account{}, accounts.ErrNotFound
+	}
+
+	return account, nil
+}
+
+// сохраняет ключ
+func (s *Store) CreateKey(ctx context.Context, key accounts.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = key
+
+	return nil
+}
+
+// получает ключ по хэшу
+func (s *Store) GetKeyByHash(ctx context.Context, hash string) (accounts.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return accounts.APIKey{}, accounts.ErrNotFound
+}
+
+// помечает ключ отозванным
+func (s *Store) RevokeKey(ctx context.Context, id string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return accounts.ErrNotFound
+	}
+
+	if key.RevokedAt == nil {
+		key.RevokedAt = &revokedAt
+		s.keys[id] = key
+	}
+
+	return nil
+}
+
+// создает новый экземпляр inmemory-хранилища аккаунтов
+func New() *Store {
+	return &Store{
+		accounts: make(map[string]accounts.Account),
+		keys:     make(map[string]accounts.APIKey),
+	}
+}
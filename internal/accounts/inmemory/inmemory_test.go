@@ -0,0 +1,57 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Account(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	account := accounts.Account{ID: "account-1", Name: "backend-jobs", CreatedAt: time.Now().UTC()}
+	require.NoError(t, store.CreateAccount(ctx, account))
+
+	got, err := store.GetAccount(ctx, account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, account, got)
+
+	_, err = store.GetAccount(ctx, "unknown")
+	assert.ErrorIs(t, err, accounts.ErrNotFound)
+}
+
+func TestStore_Key(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	key := accounts.APIKey{ID: "key-1", AccountID: "account-1", Prefix: "urlshrt_abcdef", Hash: "hash-1", CreatedAt: time.Now().UTC()}
+	require.NoError(t, store.CreateKey(ctx, key))
+
+	got, err := store.GetKeyByHash(ctx, key.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	_, err = store.GetKeyByHash(ctx, "unknown")
+	assert.ErrorIs(t, err, accounts.ErrNotFound)
+
+	revokedAt := time.Now().UTC()
+	require.NoError(t, store.RevokeKey(ctx, key.ID, revokedAt))
+
+	got, err = store.GetKeyByHash(ctx, key.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	assert.Equal(t, revokedAt, *got.RevokedAt)
+
+	// повторный отзыв не меняет время отзыва
+	require.NoError(t, store.RevokeKey(ctx, key.ID, revokedAt.Add(time.Hour)))
+	got, err = store.GetKeyByHash(ctx, key.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, revokedAt, *got.RevokedAt)
+
+	assert.ErrorIs(t, store.RevokeKey(ctx, "unknown", revokedAt), accounts.ErrNotFound)
+}
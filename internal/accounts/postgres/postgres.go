@@ -0,0 +1,109 @@
+// модуль отвечает за хранение аккаунтов и API-ключей в postgres
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+)
+
+// хранилище аккаунтов в postgres
+type Store struct {
+	db *sql.DB
+}
+
+// сохраняет аккаунт
+func (s *Store) CreateAccount(ctx context.Context, account accounts.Account) error {
+	_, err := s.db.ExecContext(ctx, `
+		insert into accounts (id, name, created_at)
+		values ($1, $2, $3)
+	`, account.ID, account.Name, account.CreatedAt)
+
+	return err
+}
+
+// получает аккаунт по ID
+func (s *Store) GetAccount(ctx context.Context, id string) (accounts.Account, error) {
+	var account accounts.Account
+
+	row := s.db.QueryRowContext(ctx, `
+		select id, name, created_at from accounts where id = $1
+	`, id)
+
+	err := row.Scan(&account.ID, &account.Name, &account.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return account, accounts.ErrNotFound
+	}
+
+	return account, err
+}
+
+// сохраняет ключ
+func (s *Store) CreateKey(ctx context.Context, key accounts.APIKey) error {
+	_, err := s.db.ExecContext(ctx, `
+		insert into api_keys (id, account_id, prefix, key_hash, created_at)
+		values ($1, $2, $3, $4, $5)
+	`, key.ID, key.AccountID, key.Prefix, key.Hash, key.CreatedAt)
+
+	return err
+}
+
+// получает ключ по хэшу
+func (s *Store) GetKeyByHash(ctx context.Context, hash string) (accounts.APIKey, error) {
+	var key accounts.APIKey
+	var revokedAt sql.NullTime
+
+	row := s.db.QueryRowContext(ctx, `
+		select id, account_id, prefix, key_hash, created_at, revoked_at
+		from api_keys
+		where key_hash = $1
+	`, hash)
+
+	err := row.Scan(&key.ID, &key.AccountID, &key.Prefix, &key.Hash, &key.CreatedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, accounts.ErrNotFound
+	}
+
+	if err != nil {
+		return key, err
+	}
+
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
+
+// помечает ключ отозванным, повторный отзыв не меняет время отзыва
+func (s *Store) RevokeKey(ctx context.Context, id string, revokedAt time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		update api_keys set revoked_at = coalesce(revoked_at, $2)
+		where id = $1
+	`, id, revokedAt)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return accounts.ErrNotFound
+	}
+
+	return nil
+}
+
+// создает новый экземпляр postgres-хранилища аккаунтов
+func New(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
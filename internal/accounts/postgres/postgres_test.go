@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/augustjourney/urlshrt/internal/migrations"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// подключается к тестовой базе из TEST_DATABASE_DSN, без нее тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	return db
+}
+
+// аккаунт, который удаляется вместе с ключами после теста
+func newTestAccount(t *testing.T, db *sql.DB, store *Store) accounts.Account {
+	account := accounts.Account{
+		ID:        uuid.NewString(),
+		Name:      "test-account",
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, store.CreateAccount(context.Background(), account))
+
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), `delete from api_keys where account_id = $1`, account.ID)
+		db.ExecContext(context.Background(), `delete from accounts where id = $1`, account.ID)
+	})
+
+	return account
+}
+
+func TestStore_Account(t *testing.T) {
+	db := openTestDB(t)
+	store := New(db)
+	ctx := context.Background()
+
+	account := newTestAccount(t, db, store)
+
+	got, err := store.GetAccount(ctx, account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, got.ID)
+	assert.Equal(t, account.Name, got.Name)
+	assert.True(t, account.CreatedAt.Equal(got.CreatedAt))
+
+	_, err = store.GetAccount(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, accounts.ErrNotFound)
+}
+
+func TestStore_Key(t *testing.T) {
+	db := openTestDB(t)
+	store := New(db)
+	ctx := context.Background()
+
+	account := newTestAccount(t, db, store)
+
+	key := accounts.APIKey{
+		ID:        uuid.NewString(),
+		AccountID: account.ID,
+		Prefix:    "urlshrt_abcdef",
+		Hash:      uuid.NewString(),
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, store.CreateKey(ctx, key))
+
+	got, err := store.GetKeyByHash(ctx, key.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	assert.Equal(t, account.ID, got.AccountID)
+	assert.Nil(t, got.RevokedAt)
+
+	_, err = store.GetKeyByHash(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, accounts.ErrNotFound)
+
+	revokedAt := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, store.RevokeKey(ctx, key.ID, revokedAt))
+
+	// повторный отзыв не меняет время отзыва
+	require.NoError(t, store.RevokeKey(ctx, key.ID, revokedAt.Add(time.Hour)))
+
+	got, err = store.GetKeyByHash(ctx, key.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	assert.True(t, revokedAt.Equal(*got.RevokedAt))
+
+	assert.ErrorIs(t, store.RevokeKey(ctx, uuid.NewString(), revokedAt), accounts.ErrNotFound)
+}
@@ -0,0 +1,43 @@
+// модуль analytics отвечает за сбор и хранение переходов по коротким ссылкам.
+// поддерживается хранение в памяти и в базе данных postgres.
+package analytics
+
+import (
+	"context"
+	"time"
+)
+
+// хранит информацию об одном переходе по короткой ссылке
+type Click struct {
+	Short     string    `json:"short_url"`
+	Timestamp time.Time `json:"timestamp"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+}
+
+// количество переходов с конкретного источника
+type RefererStats struct {
+	Referer string `json:"referer"`
+	Clicks  int    `json:"clicks"`
+}
+
+// статистика переходов по короткой ссылке
+type LinkStats struct {
+	Clicks         int            `json:"clicks"`
+	UniqueVisitors int            `json:"unique_visitors"`
+	LastClickAt    *time.Time     `json:"last_click_at,omitempty"`
+	Referers       []RefererStats `json:"referers"`
+}
+
+// сколько источников переходов попадает в статистику
+const TopReferersLimit = 10
+
+// описывает методы хранилища переходов
+type IStore interface {
+	SaveClicks(ctx context.Context, clicks []Click) error
+	GetLinkStats(ctx context.Context, short string) (LinkStats, error)
+	// удаляет переходы по окончательно удаленным ссылкам — чтобы они не достались ссылке,
+	// которая займет тот же адрес
+	DeleteClicks(ctx context.Context, shorts []string) error
+}
@@ -0,0 +1,94 @@
+// модуль отвечает за хранение переходов по ссылкам в оперативной памяти.
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/augustjourney/urlshrt/internal/analytics"
+)
+
+// хранилище переходов в памяти
+type Store struct {
+	mu     sync.RWMutex
+	clicks map[string][]analytics.Click
+}
+
+// сохраняет переходы
+func (s *Store) SaveClicks(ctx context.Context, clicks []analytics.Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, click := range clicks {
+		s.clicks[click.Short] = append(s.clicks[click.Short], click)
+	}
+
+	return nil
+}
+
+// удаляет переходы по ссылкам
+func (s *Store) DeleteClicks(ctx context.Context, shorts []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, short := range shorts {
+		delete(s.clicks, short)
+	}
+
+	return nil
+}
+
+// получает статистику переходов по короткой ссылке
+func (s *Store) GetLinkStats(ctx context.Context, short string) (analytics.LinkStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := analytics.LinkStats{
+		Referers: []analytics.RefererStats{},
+	}
+
+	visitors := make(map[string]bool)
+	referers := make(map[string]int)
+
+	for _, click := range s.clicks[short] {
+		stats.Clicks++
+		visitors[click.IP] = true
+		if click.Referer != "" {
+			referers[click.Referer]++
+		}
+		if stats.LastClickAt == nil || click.Timestamp.After(*stats.LastClickAt) {
+			timestamp := click.Timestamp
+			stats.LastClickAt = &timestamp
+		}
+	}
+
+	stats.UniqueVisitors = len(visitors)
+
+	for referer, clicks := range referers {
+		stats.Referers = append(stats.Referers, analytics.RefererStats{
+			Referer: referer,
+			Clicks:  clicks,
+		})
+	}
+
+	sort.Slice(stats.Referers, func(i, j int) bool {
+		if stats.Referers[i].Clicks == stats.Referers[j].Clicks {
+			return stats.Referers[i].Referer < stats.Referers[j].Referer
+		}
+		return stats.Referers[i].Clicks > stats.Referers[j].Clicks
+	})
+
+	if len(stats.Referers) > analytics.TopReferersLimit {
+		stats.Referers = stats.Referers[:analytics.TopReferersLimit]
+	}
+
+	return stats, nil
+}
+
+// создает новый экземпляр inmemory-хранилища переходов
+func New() *Store {
+	return &Store{
+		clicks: make(map[string][]analytics.Click),
+	}
+}
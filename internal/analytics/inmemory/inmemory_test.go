@@ -0,0 +1,81 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_GetLinkStats(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err := store.SaveClicks(ctx, []analytics.Click{
+		{Short: "short1", Timestamp: now, Referer: "http://ya.ru", IP: "10.0.0.1"},
+		{Short: "short1", Timestamp: now.Add(time.Minute), Referer: "http://ya.ru", IP: "10.0.0.2"},
+		{Short: "short1", Timestamp: now.Add(-time.Minute), Referer: "http://vk.com", IP: "10.0.0.1"},
+		{Short: "short1", Timestamp: now, IP: "10.0.0.3"},
+		{Short: "short2", Timestamp: now.Add(time.Hour), Referer: "http://ya.ru", IP: "10.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	stats, err := store.GetLinkStats(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Clicks)
+	assert.Equal(t, 3, stats.UniqueVisitors)
+	require.NotNil(t, stats.LastClickAt)
+	assert.Equal(t, now.Add(time.Minute), *stats.LastClickAt)
+	assert.Equal(t, []analytics.RefererStats{
+		{Referer: "http://ya.ru", Clicks: 2},
+		{Referer: "http://vk.com", Clicks: 1},
+	}, stats.Referers)
+
+	stats, err = store.GetLinkStats(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Clicks)
+	assert.Nil(t, stats.LastClickAt)
+	assert.Empty(t, stats.Referers)
+}
+
+func TestStore_TopReferers(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	clicks := make([]analytics.Click, 0, analytics.TopReferersLimit+5)
+	for i := 0; i < analytics.TopReferersLimit+5; i++ {
+		clicks = append(clicks, analytics.Click{Short: "short1", Referer: fmt.Sprintf("http://site%02d.ru", i)})
+	}
+	require.NoError(t, store.SaveClicks(ctx, clicks))
+
+	stats, err := store.GetLinkStats(ctx, "short1")
+	require.NoError(t, err)
+	require.Len(t, stats.Referers, analytics.TopReferersLimit)
+	// при равном числе переходов источники упорядочены по имени
+	assert.Equal(t, "http://site00.ru", stats.Referers[0].Referer)
+}
+
+func TestStore_DeleteClicks(t *testing.T) {
+	store := New()
+	ctx := context.Background()
+
+	require.NoError(t, store.SaveClicks(ctx, []analytics.Click{
+		{Short: "short1", IP: "10.0.0.1"},
+		{Short: "short2", IP: "10.0.0.1"},
+	}))
+
+	require.NoError(t, store.DeleteClicks(ctx, []string{"short1", "unknown"}))
+
+	stats, err := store.GetLinkStats(ctx, "short1")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Clicks)
+
+	stats, err = store.GetLinkStats(ctx, "short2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Clicks)
+}
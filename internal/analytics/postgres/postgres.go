@@ -0,0 +1,106 @@
+// модуль отвечает за хранение переходов по ссылкам в postgres
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/augustjourney/urlshrt/internal/analytics"
+)
+
+// хранилище переходов в postgres
+type Store struct {
+	db *sql.DB
+}
+
+// сохраняет переходы одним запросом
+func (s *Store) SaveClicks(ctx context.Context, clicks []analytics.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(clicks))
+	args := make([]any, 0, len(clicks)*5)
+
+	for i, click := range clicks {
+		n := i * 5
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5))
+		args = append(args, click.Short, click.Timestamp, click.Referer, click.UserAgent, click.IP)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		insert into clicks (short, created_at, referer, user_agent, ip)
+		values `+strings.Join(values, ", "), args...)
+
+	return err
+}
+
+// удаляет переходы по ссылкам одним запросом
+func (s *Store) DeleteClicks(ctx context.Context, shorts []string) error {
+	if len(shorts) == 0 {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `delete from clicks where short = any($1)`, shorts)
+
+	return err
+}
+
+// получает статистику переходов по короткой ссылке
+func (s *Store) GetLinkStats(ctx context.Context, short string) (analytics.LinkStats, error) {
+	stats := analytics.LinkStats{
+		Referers: []analytics.RefererStats{},
+	}
+
+	var lastClickAt sql.NullTime
+
+	row := s.db.QueryRowContext(ctx, `
+		select count(*), count(distinct ip), max(created_at)
+		from clicks
+		where short = $1
+	`, short)
+
+	err := row.Scan(&stats.Clicks, &stats.UniqueVisitors, &lastClickAt)
+	if err != nil {
+		return stats, err
+	}
+
+	if lastClickAt.Valid {
+		stats.LastClickAt = &lastClickAt.Time
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		select referer, count(*) as clicks
+		from clicks
+		where short = $1 and referer <> ''
+		group by referer
+		order by clicks desc, referer
+		limit $2
+	`, short, analytics.TopReferersLimit)
+
+	if err != nil {
+		return stats, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var referer analytics.RefererStats
+		err = rows.Scan(&referer.Referer, &referer.Clicks)
+		if err != nil {
+			return stats, err
+		}
+		stats.Referers = append(stats.Referers, referer)
+	}
+
+	return stats, rows.Err()
+}
+
+// создает новый экземпляр postgres-хранилища переходов
+func New(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
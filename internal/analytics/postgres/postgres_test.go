@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	"github.com/augustjourney/urlshrt/internal/migrations"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// подключается к тестовой базе из TEST_DATABASE_DSN, без нее тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestStore_GetLinkStats(t *testing.T) {
+	db := openTestDB(t)
+	store := New(db)
+	ctx := context.Background()
+
+	short := "clk" + uuid.NewString()[:8]
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), `delete from clicks where short = $1`, short)
+	})
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	require.NoError(t, store.SaveClicks(ctx, nil))
+	err := store.SaveClicks(ctx, []analytics.Click{
+		{Short: short, Timestamp: now, Referer: "http://ya.ru", IP: "10.0.0.1"},
+		{Short: short, Timestamp: now.Add(time.Minute), Referer: "http://ya.ru", IP: "10.0.0.2"},
+		{Short: short, Timestamp: now.Add(-time.Minute), Referer: "http://vk.com", IP: "10.0.0.1"},
+		{Short: short, Timestamp: now, IP: "10.0.0.3"},
+	})
+	require.NoError(t, err)
+
+	stats, err := store.GetLinkStats(ctx, short)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Clicks)
+	assert.Equal(t, 3, stats.UniqueVisitors)
+	require.NotNil(t, stats.LastClickAt)
+	assert.True(t, now.Add(time.Minute).Equal(*stats.LastClickAt))
+	assert.Equal(t, []analytics.RefererStats{
+		{Referer: "http://ya.ru", Clicks: 2},
+		{Referer: "http://vk.com", Clicks: 1},
+	}, stats.Referers)
+
+	stats, err = store.GetLinkStats(ctx, "unknown"+short)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Clicks)
+	assert.Nil(t, stats.LastClickAt)
+	assert.Empty(t, stats.Referers)
+}
+
+func TestStore_DeleteClicks(t *testing.T) {
+	db := openTestDB(t)
+	store := New(db)
+	ctx := context.Background()
+
+	short := "del" + uuid.NewString()[:8]
+	other := "keep" + uuid.NewString()[:8]
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), `delete from clicks where short = any($1)`, []string{short, other})
+	})
+
+	require.NoError(t, store.SaveClicks(ctx, []analytics.Click{
+		{Short: short, Timestamp: time.Now(), IP: "10.0.0.1"},
+		{Short: other, Timestamp: time.Now(), IP: "10.0.0.1"},
+	}))
+
+	require.NoError(t, store.DeleteClicks(ctx, nil))
+	require.NoError(t, store.DeleteClicks(ctx, []string{short}))
+
+	stats, err := store.GetLinkStats(ctx, short)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Clicks)
+
+	stats, err = store.GetLinkStats(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Clicks)
+}
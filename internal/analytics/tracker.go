@@ -0,0 +1,128 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+)
+
+// максимальное количество переходов, сохраняемых за один раз
+const maxFlushBatch = 500
+
+// асинхронно записывает переходы в хранилище:
+// переходы копятся в буфере и сохраняются пачками по размеру или по таймеру,
+// поэтому запись перехода не замедляет редирект
+type Tracker struct {
+	store         IStore
+	clicks        chan Click
+	flushInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// ставит переход в очередь на сохранение
+// если буфер переполнен — переход отбрасывается, чтобы не блокировать запрос
+func (t *Tracker) Record(click Click) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed {
+		return
+	}
+
+	select {
+	case t.clicks <- click:
+	default:
+		logger.Log.Warn("Analytics buffer is full, click is dropped")
+	}
+}
+
+// получает статистику переходов по короткой ссылке
+func (t *Tracker) Stats(ctx context.Context, short string) (LinkStats, error) {
+	return t.store.GetLinkStats(ctx, short)
+}
+
+// количество переходов, которые еще не сохранены
+func (t *Tracker) Pending() int {
+	return len(t.clicks)
+}
+
+// запускает фоновое сохранение переходов
+func (t *Tracker) Start() {
+	go t.run()
+}
+
+// прекращает прием переходов и дожидается сохранения уже накопленных
+func (t *Tracker) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.clicks)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracker) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Click, 0, maxFlushBatch)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := t.store.SaveClicks(context.Background(), batch)
+		if err != nil {
+			logger.Log.WithError(err).WithField("clicks", len(batch)).Error("Could not save clicks")
+		}
+		batch = make([]Click, 0, maxFlushBatch)
+	}
+
+	for {
+		select {
+		case click, ok := <-t.clicks:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= maxFlushBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// создает новый экземпляр трекера переходов.
+// bufferSize меньше 1 заменяется на 1, неположительный flushInterval — на секунду
+func NewTracker(store IStore, bufferSize int, flushInterval time.Duration) *Tracker {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	return &Tracker{
+		store:         store,
+		clicks:        make(chan Click, bufferSize),
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+}
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// хранилище, которое запоминает пачки сохраненных переходов
+type batchStore struct {
+	mu      sync.Mutex
+	batches [][]Click
+}
+
+func (s *batchStore) SaveClicks(ctx context.Context, clicks []Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, clicks)
+	return nil
+}
+
+func (s *batchStore) GetLinkStats(ctx context.Context, short string) (LinkStats, error) {
+	return LinkStats{}, nil
+}
+
+func (s *batchStore) DeleteClicks(ctx context.Context, shorts []string) error {
+	return nil
+}
+
+func (s *batchStore) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sizes := make([]int, 0, len(s.batches))
+	for _, batch := range s.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestTracker_DropsWhenBufferIsFull(t *testing.T) {
+	logger.New()
+	store := &batchStore{}
+	tracker := NewTracker(store, 2, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		tracker.Record(Click{Short: "short1"})
+	}
+	assert.Equal(t, 2, tracker.Pending())
+
+	tracker.Start()
+	require.NoError(t, tracker.Close(context.Background()))
+	assert.Equal(t, []int{2}, store.sizes())
+}
+
+func TestTracker_FlushesInBatches(t *testing.T) {
+	logger.New()
+	store := &batchStore{}
+	tracker := NewTracker(store, 2*maxFlushBatch+10, time.Hour)
+
+	for i := 0; i < 2*maxFlushBatch+10; i++ {
+		tracker.Record(Click{Short: "short1"})
+	}
+
+	// полные пачки сохраняются, не дожидаясь таймера
+	tracker.Start()
+	assert.Eventually(t, func() bool {
+		return len(store.sizes()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{maxFlushBatch, maxFlushBatch}, store.sizes())
+
+	require.NoError(t, tracker.Close(context.Background()))
+	assert.Equal(t, []int{maxFlushBatch, maxFlushBatch, 10}, store.sizes())
+}
+
+func TestTracker_FlushesByInterval(t *testing.T) {
+	logger.New()
+	store := &batchStore{}
+	tracker := NewTracker(store, 10, 10*time.Millisecond)
+	tracker.Start()
+	defer tracker.Close(context.Background())
+
+	tracker.Record(Click{Short: "short1"})
+
+	assert.Eventually(t, func() bool {
+		return len(store.sizes()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTracker_Close(t *testing.T) {
+	logger.New()
+	store := &batchStore{}
+	tracker := NewTracker(store, 10, time.Hour)
+	tracker.Start()
+
+	for i := 0; i < 3; i++ {
+		tracker.Record(Click{Short: "short1"})
+	}
+
+	// накопленные переходы сохраняются при остановке
+	require.NoError(t, tracker.Close(context.Background()))
+	assert.Equal(t, []int{3}, store.sizes())
+	assert.Equal(t, 0, tracker.Pending())
+
+	// после остановки переходы не принимаются, повторная остановка не ломается
+	tracker.Record(Click{Short: "short1"})
+	require.NoError(t, tracker.Close(context.Background()))
+	assert.Equal(t, []int{3}, store.sizes())
+}
+
+func TestTracker_CloseTimeout(t *testing.T) {
+	logger.New()
+	tracker := NewTracker(&batchStore{}, 10, time.Hour)
+
+	// без запуска сохранять переходы некому
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, tracker.Close(ctx), context.DeadlineExceeded)
+}
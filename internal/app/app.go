@@ -2,16 +2,21 @@ package app
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"github.com/augustjourney/urlshrt/internal/config"
+	"crypto/tls"
+	"errors"
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/augustjourney/urlshrt/internal/auth"
+	"github.com/augustjourney/urlshrt/internal/health"
 	"github.com/augustjourney/urlshrt/internal/interceptors"
-	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/metrics"
 	"github.com/augustjourney/urlshrt/internal/middleware"
 	pb "github.com/augustjourney/urlshrt/internal/proto"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
 	"github.com/gofiber/fiber/v2"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"net"
+	"net/http"
 )
 
 // Интерфейс — который описывает методы контроллера
@@ -24,6 +29,14 @@ type Controller interface {
 	GetUserURLs(ctx *fiber.Ctx) error
 	APIDeleteBatch(ctx *fiber.Ctx) error
 	GetStats(ctx *fiber.Ctx) error
+	GetURLStats(ctx *fiber.Ctx) error
+	GetURLChanges(ctx *fiber.Ctx) error
+	CreateAccount(ctx *fiber.Ctx) error
+	CreateAPIKey(ctx *fiber.Ctx) error
+	RevokeAPIKey(ctx *fiber.Ctx) error
+	DisableBlockedURLs(ctx *fiber.Ctx) error
+	APIUpdateURL(ctx *fiber.Ctx) error
+	APIRestoreBatch(ctx *fiber.Ctx) error
 }
 
 type GrpcController interface {
@@ -33,69 +46,201 @@ type GrpcController interface {
 	GetUserURLs(ctx context.Context, req *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error)
 	DeleteBatch(ctx context.Context, req *pb.DeleteBatchRequest) (*pb.DeleteBatchResponse, error)
 	GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error)
+	GetURLStats(ctx context.Context, req *pb.GetURLStatsRequest) (*pb.GetURLStatsResponse, error)
+	GetURLChanges(ctx context.Context, req *pb.GetURLChangesRequest) (*pb.GetURLChangesResponse, error)
+	Update(ctx context.Context, req *pb.UpdateRequest) (*pb.UpdateResponse, error)
+	RestoreBatch(ctx context.Context, req *pb.RestoreBatchRequest) (*pb.RestoreBatchResponse, error)
 	mustEmbedUnimplementedURLServiceServer()
 }
 
-// Создает новый экземпляр приложения
-func NewHTTPServer(c Controller, db *sql.DB) *fiber.App {
+// Хранилище, доступность которого проверяет /ping
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Создает новый экземпляр приложения, m == nil — запросы не учитываются в метриках,
+// checker == nil — без /healthz и /readyz
+func NewHTTPServer(c Controller, pinger Pinger, limits ratelimit.Limits, authManager *auth.Manager, m *metrics.Metrics, checker *health.Checker) *fiber.App {
 	app := fiber.New()
 
+	createLimit := middleware.RateLimit(limits.Create, authManager)
+	redirectLimit := middleware.RateLimit(limits.Redirect, authManager)
+
+	app.Use(middleware.RequestID)
+	app.Use(middleware.Tracing)
+	if m != nil {
+		app.Use(middleware.Metrics(m))
+	}
 	app.Use(middleware.RequestCompress)
 	app.Use(middleware.RequestLogger)
 
 	app.Get("/ping", func(ctx *fiber.Ctx) error {
-		err := db.Ping()
+		err := pinger.Ping(ctx.UserContext())
 		if err != nil {
 			return ctx.SendStatus(fiber.StatusInternalServerError)
 		}
 		return ctx.SendStatus(fiber.StatusOK)
 	})
 
-	app.Post("/", c.CreateURL)
-	app.Post("/api/shorten", c.APICreateURL)
-	app.Post("/api/shorten/batch", c.APICreateURLBatch)
-	app.Get("/:short", c.GetURL)
+	if checker != nil {
+		app.Get("/healthz", healthHandler(checker.Live))
+		app.Get("/readyz", healthHandler(checker.LastReady))
+	}
+
+	app.Post("/", createLimit, c.CreateURL)
+	app.Post("/api/shorten", createLimit, c.APICreateURL)
+	app.Post("/api/shorten/batch", createLimit, c.APICreateURLBatch)
+	app.Get("/:short", redirectLimit, c.GetURL)
 	app.Get("/api/user/urls", c.GetUserURLs)
+	app.Get("/api/user/urls/:short/stats", c.GetURLStats)
+	app.Get("/api/user/urls/:short/history", c.GetURLChanges)
 	app.Delete("/api/user/urls", c.APIDeleteBatch)
+	app.Post("/api/user/urls/restore", c.APIRestoreBatch)
+	app.Patch("/api/user/urls/:short", c.APIUpdateURL)
 	app.Get("/api/internal/stats", middleware.IPInTrustedSubnet, c.GetStats)
+	app.Post("/api/internal/accounts", middleware.IPInTrustedSubnet, c.CreateAccount)
+	app.Post("/api/internal/accounts/:id/keys", middleware.IPInTrustedSubnet, c.CreateAPIKey)
+	app.Delete("/api/internal/keys/:id", middleware.IPInTrustedSubnet, c.RevokeAPIKey)
+	app.Post("/api/internal/screening/disable", middleware.IPInTrustedSubnet, c.DisableBlockedURLs)
 	app.Use("/*", c.BadRequest)
 
 	return app
 }
 
-// Запускает приложение на HTTP
-func RunHTTP(app *fiber.App, config *config.Config) error {
-	logger.Log.Info(fmt.Sprintf("Launching on http — %s", config.ServerAddress))
-	return app.Listen(config.ServerAddress)
+// Занимает адрес для http
+func ListenTCP(address string) func() (net.Listener, error) {
+	return func() (net.Listener, error) {
+		return net.Listen("tcp", address)
+	}
+}
+
+// Занимает адрес для https — сертификаты читаются сразу, чтобы ошибка в них остановила запуск
+func ListenTLS(address string, certFile string, keyFile string) func() (net.Listener, error) {
+	return func() (net.Listener, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		ln, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		return tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}), nil
+	}
 }
 
-// Запускает приложение на HTTPS
-func RunHTTPS(app *fiber.App, config *config.Config) error {
-	pem, key, err := config.GetCerts()
-	if err != nil {
-		return err
+// Сервер приложения на fiber — при остановке дожидается запросов в обработке
+func FiberServer(name string, app *fiber.App, listen func() (net.Listener, error)) Server {
+	return Server{
+		Name:     name,
+		Listen:   listen,
+		Serve:    app.Listener,
+		Shutdown: app.ShutdownWithContext,
 	}
-	logger.Log.Info(fmt.Sprintf("Launching on https — %s", config.ServerAddress))
-	return app.ListenTLS(config.ServerAddress, pem, key)
 }
 
-func NewGrpcServer(controller pb.URLServiceServer) *grpc.Server {
-	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+// Создает grpc-сервер, m == nil — запросы не учитываются в метриках,
+// checker == nil — без сервиса grpc.health.v1.Health
+func NewGrpcServer(controller pb.URLServiceServer, authManager *auth.Manager, accounts *accounts.Service, limits ratelimit.Limits, m *metrics.Metrics, checker *health.Checker) *grpc.Server {
+	chain := []grpc.UnaryServerInterceptor{interceptors.RequestID, interceptors.Tracing}
+	if m != nil {
+		chain = append(chain, interceptors.Metrics(m))
+	}
+	chain = append(chain,
 		grpc.UnaryServerInterceptor(interceptors.LogRequests),
 		grpc.UnaryServerInterceptor(interceptors.IPInTrustedSubnet),
-	))
+		// лимит проверяется до выдачи токена — иначе каждый новый токен получал бы свой лимит
+		interceptors.RateLimit(limits, authManager),
+		// новым пользователям токен выдается только при создании ссылок
+		interceptors.Auth(authManager, accounts,
+			pb.URLService_Create_FullMethodName,
+			pb.URLService_CreateBatch_FullMethodName,
+		),
+	)
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(chain...))
 	pb.RegisterURLServiceServer(server, controller)
+	if checker != nil {
+		healthpb.RegisterHealthServer(server, checker.GRPCServer())
+	}
 	return server
 }
 
-// Запускает приложение в gRPC
-func RunGRPC(server *grpc.Server, config *config.Config) error {
-	listen, err := net.Listen("tcp", config.GrpcServerAddress)
-	if err != nil {
-		logger.Log.Error(err)
+// отдает статус сервиса в json: 200, если он в порядке, иначе 503
+func healthHandler(report func(ctx context.Context) health.Report) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		result := report(ctx.UserContext())
+
+		status := fiber.StatusOK
+		if result.Status != health.StatusUp {
+			status = fiber.StatusServiceUnavailable
+		}
+
+		return ctx.Status(status).JSON(result)
+	}
+}
+
+// grpc-сервер — пока он принимает запросы, checker отмечает это для готовности.
+// если запросы не дообработались за время остановки, соединения закрываются принудительно
+func GRPCServer(server *grpc.Server, address string, checker *health.Checker) Server {
+	return Server{
+		Name:   "grpc",
+		Listen: ListenTCP(address),
+		Serve: func(ln net.Listener) error {
+			if checker != nil {
+				checker.SetGRPCServing(true)
+				defer checker.SetGRPCServing(false)
+			}
+
+			err := server.Serve(ln)
+			if errors.Is(err, grpc.ErrServerStopped) {
+				return nil
+			}
+			return err
+		},
+		Shutdown: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				server.Stop()
+				return ctx.Err()
+			}
+		},
 	}
+}
 
-	logger.Log.Info(fmt.Sprintf("gRPC server started on %s", config.GrpcServerAddress))
+// Создает сервер, который отдает метрики на /metrics
+func NewMetricsServer(m *metrics.Metrics, address string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
 
-	return server.Serve(listen)
+	return &http.Server{
+		Addr:    address,
+		Handler: mux,
+	}
+}
+
+// Сервер метрик
+func MetricsServer(server *http.Server) Server {
+	return Server{
+		Name:   "metrics",
+		Listen: ListenTCP(server.Addr),
+		Serve: func(ln net.Listener) error {
+			err := server.Serve(ln)
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+		Shutdown: server.Shutdown,
+	}
 }
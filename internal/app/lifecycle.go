@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// Ошибка если сервер перестал принимать запросы, хотя его не останавливали
+var ErrServerStopped = errors.New("server stopped unexpectedly")
+
+// Сервер, которым управляет Lifecycle
+type Server struct {
+	Name string
+	// занимает адрес — до запуска остальных серверов, чтобы ошибка не осталась незамеченной
+	Listen func() (net.Listener, error)
+	// обслуживает запросы, пока сервер не остановят
+	Serve func(ln net.Listener) error
+	// перестает принимать запросы и дожидается тех, что уже в обработке
+	Shutdown func(ctx context.Context) error
+}
+
+// шаг остановки
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// фоновая задача, которая работает до отмены контекста
+type worker struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// Запускает серверы и фоновые задачи и останавливает их в заданном порядке:
+//  1. шаги BeforeStop — например, сервис перестает быть готовым;
+//  2. серверы — запросы в обработке дорабатывают;
+//  3. фоновые задачи — их контекст отменяется, остановка ждет их завершения;
+//  4. шаги AfterStop — сброс буферов и закрытие хранилищ.
+//
+// шаги 1–3 и шаги AfterStop получают по timeout каждые: зависшие запросы не съедают время на сброс буферов.
+// остановка начинается по отмене контекста Run или когда любой сервер падает
+type Lifecycle struct {
+	servers    []Server
+	workers    []worker
+	beforeStop []hook
+	afterStop  []hook
+	timeout    time.Duration
+}
+
+// добавляет сервер
+func (l *Lifecycle) AddServer(server Server) {
+	l.servers = append(l.servers, server)
+}
+
+// добавляет фоновую задачу — она запускается вместе с серверами
+func (l *Lifecycle) Go(name string, run func(ctx context.Context)) {
+	l.workers = append(l.workers, worker{name: name, run: run})
+}
+
+// добавляет шаг, который выполняется до остановки серверов, — шаги выполняются в порядке добавления
+func (l *Lifecycle) BeforeStop(name string, stop func(ctx context.Context) error) {
+	l.beforeStop = append(l.beforeStop, hook{name: name, stop: stop})
+}
+
+// добавляет шаг, который выполняется после остановки серверов и фоновых задач, — в порядке добавления
+func (l *Lifecycle) AfterStop(name string, stop func(ctx context.Context) error) {
+	l.afterStop = append(l.afterStop, hook{name: name, stop: stop})
+}
+
+// запускает серверы и фоновые задачи и блокируется до их остановки.
+// возвращает ошибку сервера, из-за которой началась остановка, или ошибки самой остановки.
+// шаги остановки выполняются и тогда, когда сервер не смог занять адрес
+func (l *Lifecycle) Run(ctx context.Context) error {
+	listeners := make([]net.Listener, 0, len(l.servers))
+
+	for _, server := range l.servers {
+		ln, err := server.Listen()
+		if err != nil {
+			err = fmt.Errorf("%s: %w", server.Name, err)
+			return errors.Join(err, l.stop(listeners, nil))
+		}
+		logger.Log.WithFields(logrus.Fields{
+			"server":  server.Name,
+			"address": ln.Addr().String(),
+		}).Info("Server listening")
+		listeners = append(listeners, ln)
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, w := range l.workers {
+		workers.Add(1)
+		go func(w worker) {
+			defer workers.Done()
+			w.run(workersCtx)
+		}(w)
+	}
+
+	waitWorkers := func(ctx context.Context) error {
+		stopWorkers()
+		return wait(ctx, &workers)
+	}
+
+	var stopping atomic.Bool
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	for i, server := range l.servers {
+		server, ln := server, listeners[i]
+		group.Go(func() error {
+			err := server.Serve(ln)
+			if stopping.Load() {
+				return nil
+			}
+			if err == nil {
+				err = ErrServerStopped
+			}
+			return fmt.Errorf("%s: %w", server.Name, err)
+		})
+	}
+
+	group.Go(func() error {
+		<-groupCtx.Done()
+		stopping.Store(true)
+		return l.stop(listeners, waitWorkers)
+	})
+
+	return group.Wait()
+}
+
+// останавливает серверы и фоновые задачи за l.timeout, затем выполняет шаги AfterStop — еще за l.timeout
+func (l *Lifecycle) stop(listeners []net.Listener, waitWorkers func(ctx context.Context) error) error {
+	logger.Log.Info("Gracefully shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	var errs []error
+	run := func(ctx context.Context, name string, stop func(ctx context.Context) error) {
+		logger.Log.WithField("step", name).Info("Stopping")
+		err := stop(ctx)
+		if err != nil {
+			logger.Log.WithError(err).WithField("step", name).Error("Could not stop")
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	for _, h := range l.beforeStop {
+		run(ctx, h.name, h.stop)
+	}
+
+	// серверы останавливаются одновременно — у каждого свои запросы в обработке
+	shutdownErrs := make([]error, len(listeners))
+
+	var servers sync.WaitGroup
+	for i, ln := range listeners {
+		servers.Add(1)
+		go func(i int, server Server, ln net.Listener) {
+			defer servers.Done()
+			logger.Log.WithField("server", server.Name).Info("Stopping server")
+			shutdownErrs[i] = server.Shutdown(ctx)
+			// сервер мог не успеть начать принимать запросы — тогда закрытый адрес не даст ему начать
+			ln.Close()
+		}(i, l.servers[i], ln)
+	}
+	servers.Wait()
+
+	for i, err := range shutdownErrs {
+		if err != nil {
+			logger.Log.WithError(err).WithField("server", l.servers[i].Name).Error("Could not stop server")
+			errs = append(errs, fmt.Errorf("%s: %w", l.servers[i].Name, err))
+		}
+	}
+
+	if waitWorkers != nil {
+		run(ctx, "background workers", waitWorkers)
+	}
+
+	// у сброса буферов свое время — даже если запросы дообрабатывались до последнего
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), l.timeout)
+	defer cancelFlush()
+
+	for _, h := range l.afterStop {
+		run(flushCtx, h.name, h.stop)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ждет группу горутин, но не дольше, чем живет контекст
+func wait(ctx context.Context, group *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// создает управление жизненным циклом, timeout — сколько может длиться каждый этап остановки
+func NewLifecycle(timeout time.Duration) *Lifecycle {
+	return &Lifecycle{timeout: timeout}
+}
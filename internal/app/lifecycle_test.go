@@ -0,0 +1,309 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// записывает события остановки в порядке их наступления
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// шаг остановки, который только записывает событие
+func (r *recorder) hook(event string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.add(event)
+		return nil
+	}
+}
+
+// сервер, который принимает соединения, пока адрес не закроют
+func fakeServer(name string, address string, events *recorder) Server {
+	return Server{
+		Name:   name,
+		Listen: ListenTCP(address),
+		Serve: func(ln net.Listener) error {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return nil
+				}
+				conn.Close()
+			}
+		},
+		Shutdown: events.hook("shutdown " + name),
+	}
+}
+
+// запускает Run в фоне — результат придет в канал
+func runLifecycle(ctx context.Context, l *Lifecycle) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- l.Run(ctx)
+	}()
+	return result
+}
+
+func waitResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("lifecycle did not stop")
+		return nil
+	}
+}
+
+func TestLifecycle_ShutdownOrder(t *testing.T) {
+	logger.New()
+
+	events := &recorder{}
+	started := make(chan struct{})
+
+	l := NewLifecycle(time.Second)
+	l.AddServer(fakeServer("a", "127.0.0.1:0", events))
+	l.AddServer(fakeServer("b", "127.0.0.1:0", events))
+	l.Go("worker", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		events.add("worker stopped")
+	})
+	l.BeforeStop("readiness", events.hook("readiness"))
+	l.AfterStop("deleter", events.hook("deleter"))
+	l.AfterStop("storage", events.hook("storage"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runLifecycle(ctx, l)
+
+	<-started
+	cancel()
+
+	require.NoError(t, waitResult(t, result))
+
+	got := events.list()
+	require.Len(t, got, 6)
+	assert.Equal(t, "readiness", got[0])
+	assert.ElementsMatch(t, []string{"shutdown a", "shutdown b"}, got[1:3])
+	assert.Equal(t, []string{"worker stopped", "deleter", "storage"}, got[3:])
+}
+
+func TestLifecycle_ListenError(t *testing.T) {
+	logger.New()
+
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	events := &recorder{}
+
+	l := NewLifecycle(time.Second)
+	l.AddServer(fakeServer("a", "127.0.0.1:0", events))
+	l.AddServer(fakeServer("b", taken.Addr().String(), events))
+	l.Go("worker", func(ctx context.Context) {
+		events.add("worker started")
+	})
+	l.AfterStop("storage", events.hook("storage"))
+
+	err = waitResult(t, runLifecycle(context.Background(), l))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "b: ")
+
+	// занятый адрес останавливает запуск, но то, что уже запущено, останавливается как обычно
+	assert.Equal(t, []string{"shutdown a", "storage"}, events.list())
+}
+
+func TestLifecycle_ServerFailure(t *testing.T) {
+	logger.New()
+
+	errServe := errors.New("serve failed")
+
+	tests := []struct {
+		name     string
+		serveErr error
+		wantErr  error
+	}{
+		{
+			name:     "server fails",
+			serveErr: errServe,
+			wantErr:  errServe,
+		},
+		{
+			name:     "server returns without being stopped",
+			serveErr: nil,
+			wantErr:  ErrServerStopped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &recorder{}
+
+			failing := fakeServer("failing", "127.0.0.1:0", events)
+			failing.Serve = func(ln net.Listener) error {
+				return tt.serveErr
+			}
+
+			l := NewLifecycle(time.Second)
+			l.AddServer(fakeServer("a", "127.0.0.1:0", events))
+			l.AddServer(failing)
+			l.AfterStop("storage", events.hook("storage"))
+
+			err := waitResult(t, runLifecycle(context.Background(), l))
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.ErrorContains(t, err, "failing: ")
+
+			got := events.list()
+			require.Len(t, got, 3)
+			assert.ElementsMatch(t, []string{"shutdown a", "shutdown failing"}, got[:2])
+			assert.Equal(t, "storage", got[2])
+		})
+	}
+}
+
+func TestLifecycle_DrainsInFlightRequests(t *testing.T) {
+	logger.New()
+
+	events := &recorder{}
+	handling := make(chan struct{})
+	release := make(chan struct{})
+
+	server := fiber.New(fiber.Config{DisableStartupMessage: true})
+	server.Get("/slow", func(ctx *fiber.Ctx) error {
+		close(handling)
+		<-release
+		events.add("request done")
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	l := NewLifecycle(5 * time.Second)
+	l.AddServer(FiberServer("http", server, func() (net.Listener, error) {
+		return ln, nil
+	}))
+	l.AfterStop("storage", events.hook("storage"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runLifecycle(ctx, l)
+
+	type response struct {
+		status int
+		err    error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/slow", ln.Addr()))
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		resp.Body.Close()
+		responses <- response{status: resp.StatusCode}
+	}()
+
+	<-handling
+	cancel()
+
+	// остановка ждет запрос в обработке
+	select {
+	case err := <-result:
+		t.Fatalf("lifecycle stopped before request was done: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	resp := <-responses
+	require.NoError(t, resp.err)
+	assert.Equal(t, fiber.StatusOK, resp.status)
+
+	require.NoError(t, waitResult(t, result))
+	assert.Equal(t, []string{"request done", "storage"}, events.list())
+}
+
+func TestLifecycle_ShutdownTimeout(t *testing.T) {
+	logger.New()
+
+	events := &recorder{}
+
+	l := NewLifecycle(50 * time.Millisecond)
+	l.AddServer(fakeServer("a", "127.0.0.1:0", events))
+	l.AfterStop("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	l.AfterStop("storage", events.hook("storage"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := waitResult(t, runLifecycle(ctx, l))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stuck: ")
+
+	// зависший шаг не мешает выполнить следующие
+	assert.Equal(t, []string{"shutdown a", "storage"}, events.list())
+}
+
+func TestLifecycle_AfterStopHasOwnTimeout(t *testing.T) {
+	logger.New()
+
+	events := &recorder{}
+
+	// сервер дообрабатывает запросы, пока не выйдет все время остановки
+	stuck := fakeServer("stuck", "127.0.0.1:0", events)
+	stuck.Shutdown = func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	l := NewLifecycle(50 * time.Millisecond)
+	l.AddServer(stuck)
+	l.AfterStop("storage", func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		events.add("storage")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := waitResult(t, runLifecycle(ctx, l))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stuck: ")
+	assert.NotContains(t, err.Error(), "storage: ")
+
+	// буферы сбрасываются, хотя время на остановку серверов вышло
+	assert.Equal(t, []string{"storage"}, events.list())
+}
@@ -0,0 +1,192 @@
+// модуль auth отвечает за выпуск и проверку подписанных токенов пользователя.
+// токен — JWT с подписью HMAC-SHA256, в котором хранится ID пользователя.
+// поддерживается ротация ключей: новые токены подписываются текущим ключом,
+// а токены, подписанные предыдущими ключами, продолжают проверяться.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Ошибка если токен поврежден, подписан неизвестным ключом или подпись не совпадает
+var ErrInvalidToken = errors.New("invalid token")
+
+// Ошибка если срок действия токена истек
+var ErrExpiredToken = errors.New("token is expired")
+
+// Ошибка если не задан ключ подписи
+var ErrEmptySecret = errors.New("auth secret is empty")
+
+// заголовок токена
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// полезная нагрузка токена
+type claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// ключ подписи и его идентификатор
+type key struct {
+	id     string
+	secret []byte
+}
+
+// выпускает и проверяет токены пользователей
+type Manager struct {
+	current  key
+	keys     map[string]key
+	tokenTTL time.Duration
+}
+
+// выпускает токен для пользователя, подписанный текущим ключом
+func (m *Manager) Issue(userID string) (string, error) {
+	now := time.Now()
+
+	payload := claims{
+		Subject:  userID,
+		IssuedAt: now.Unix(),
+	}
+
+	if m.tokenTTL > 0 {
+		payload.ExpiresAt = now.Add(m.tokenTTL).Unix()
+	}
+
+	headerJSON, err := json.Marshal(header{
+		Alg: "HS256",
+		Typ: "JWT",
+		Kid: m.current.id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := encode(headerJSON) + "." + encode(payloadJSON)
+
+	return unsigned + "." + encode(sign(m.current.secret, unsigned)), nil
+}
+
+// проверяет токен и возвращает ID пользователя из него
+func (m *Manager) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	headerJSON, err := decode(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	var h header
+	if err = json.Unmarshal(headerJSON, &h); err != nil || h.Alg != "HS256" {
+		return "", ErrInvalidToken
+	}
+
+	k, ok := m.keys[h.Kid]
+	if !ok {
+		return "", ErrInvalidToken
+	}
+
+	signature, err := decode(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, sign(k.secret, parts[0]+"."+parts[1])) {
+		return "", ErrInvalidToken
+	}
+
+	payloadJSON, err := decode(parts[1])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	var c claims
+	if err = json.Unmarshal(payloadJSON, &c); err != nil || c.Subject == "" {
+		return "", ErrInvalidToken
+	}
+
+	if c.ExpiresAt != 0 && time.Now().Unix() >= c.ExpiresAt {
+		return "", ErrExpiredToken
+	}
+
+	return c.Subject, nil
+}
+
+func sign(secret []byte, data string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(data)
+}
+
+// идентификатор ключа — начало его хэша, сам ключ в токен не попадает
+func newKey(secret string) key {
+	hash := sha256.Sum256([]byte(secret))
+	return key{
+		id:     hex.EncodeToString(hash[:4]),
+		secret: []byte(secret),
+	}
+}
+
+// генерирует случайный ключ подписи
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// создает новый экземпляр менеджера токенов
+// secret — текущий ключ подписи, previous — прежние ключи, которые принимаются только при проверке
+// tokenTTL — срок действия токена, 0 — бессрочный
+func New(secret string, previous []string, tokenTTL time.Duration) (*Manager, error) {
+	if secret == "" {
+		return nil, ErrEmptySecret
+	}
+
+	current := newKey(secret)
+
+	m := Manager{
+		current:  current,
+		keys:     map[string]key{current.id: current},
+		tokenTTL: tokenTTL,
+	}
+
+	for _, secret := range previous {
+		if secret == "" {
+			continue
+		}
+		k := newKey(secret)
+		m.keys[k.id] = k
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_IssueVerify(t *testing.T) {
+	manager, err := New("secret", nil, 0)
+	require.NoError(t, err)
+
+	token, err := manager.Issue("user-uuid-1")
+	require.NoError(t, err)
+
+	user, err := manager.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-uuid-1", user)
+}
+
+func TestManager_VerifyInvalid(t *testing.T) {
+	manager, err := New("secret", nil, 0)
+	require.NoError(t, err)
+
+	other, err := New("other-secret", nil, 0)
+	require.NoError(t, err)
+
+	token, err := manager.Issue("user-uuid-1")
+	require.NoError(t, err)
+
+	foreign, err := other.Issue("user-uuid-1")
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	forgedPayload := encode([]byte(`{"sub":"user-uuid-2","iat":0}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "Raw user id", token: "user-uuid-1"},
+		{name: "Empty", token: ""},
+		{name: "Forged payload", token: parts[0] + "." + forgedPayload + "." + parts[2]},
+		{name: "Signed with unknown key", token: foreign},
+		{name: "Broken signature", token: parts[0] + "." + parts[1] + ".AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.Verify(tt.token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestManager_Expired(t *testing.T) {
+	manager, err := New("secret", nil, time.Hour)
+	require.NoError(t, err)
+
+	token, err := manager.Issue("user-uuid-1")
+	require.NoError(t, err)
+	_, err = manager.Verify(token)
+	assert.NoError(t, err)
+
+	// токен, срок действия которого истек минуту назад
+	headerJSON, err := json.Marshal(header{Alg: "HS256", Typ: "JWT", Kid: manager.current.id})
+	require.NoError(t, err)
+	payloadJSON, err := json.Marshal(claims{
+		Subject:   "user-uuid-1",
+		IssuedAt:  time.Now().Add(-time.Hour).Unix(),
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	unsigned := encode(headerJSON) + "." + encode(payloadJSON)
+	expired := unsigned + "." + encode(sign(manager.current.secret, unsigned))
+
+	_, err = manager.Verify(expired)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestManager_Rotation(t *testing.T) {
+	old, err := New("old-secret", nil, 0)
+	require.NoError(t, err)
+
+	token, err := old.Issue("user-uuid-1")
+	require.NoError(t, err)
+
+	rotated, err := New("new-secret", []string{"old-secret"}, 0)
+	require.NoError(t, err)
+
+	// токены, подписанные прежним ключом, все еще принимаются
+	user, err := rotated.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-uuid-1", user)
+
+	// после удаления прежнего ключа — нет
+	withoutOld, err := New("new-secret", nil, 0)
+	require.NoError(t, err)
+
+	_, err = withoutOld.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNew_EmptySecret(t *testing.T) {
+	_, err := New("", nil, 0)
+	assert.ErrorIs(t, err, ErrEmptySecret)
+}
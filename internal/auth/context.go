@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type contextKey struct{}
+
+// сохраняет ID пользователя в контексте запроса
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// получает ID пользователя из контекста запроса — пустая строка, если его нет
+func UserFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(contextKey{}).(string)
+	return userID
+}
@@ -5,6 +5,8 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/augustjourney/urlshrt/internal/logger"
 )
@@ -21,20 +23,105 @@ type Config struct {
 	CertKeyPath       string `json:"-"`
 	Config            string `env:"CONFIG" json:"-"`
 	TrustedSubnet     string `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	// Адрес, на котором отдаются метрики в формате Prometheus, пустой — метрики не отдаются.
+	// по умолчанию пустой, явно заданный пустой флаг или переменная окружения отключает адрес из файла конфига
+	MetricsAddress string `env:"METRICS_ADDRESS" json:"metrics_address"`
+	// Куда пишутся спаны трассировки: none или stdout
+	TracingExporter string `env:"TRACING_EXPORTER" json:"tracing_exporter"`
+	// Уровень логов: debug, info, warn, error
+	LogLevel string `env:"LOG_LEVEL" json:"log_level"`
+	// Формат логов: json или text
+	LogFormat string `env:"LOG_FORMAT" json:"log_format"`
+	// Как часто фоновая задача помечает удаленными ссылки с истекшим сроком жизни
+	ExpiredReapInterval Duration `env:"EXPIRED_REAP_INTERVAL" json:"expired_reap_interval"`
+	// Сколько переходов по ссылкам может ждать сохранения в буфере
+	AnalyticsBufferSize int `env:"ANALYTICS_BUFFER_SIZE" json:"analytics_buffer_size"`
+	// Как часто накопленные переходы сохраняются в хранилище
+	AnalyticsFlushInterval Duration `env:"ANALYTICS_FLUSH_INTERVAL" json:"analytics_flush_interval"`
+	// Стратегия генерации коротких кодов: hash, random или sequence
+	ShortCodeStrategy string `env:"SHORT_CODE_STRATEGY" json:"short_code_strategy"`
+	// Длина короткого кода, для sequence — минимальная; 0 — длина по умолчанию для стратегии
+	ShortCodeLength int `env:"SHORT_CODE_LENGTH" json:"short_code_length"`
+	// Ключ подписи токенов пользователей; если не задан — генерируется при запуске
+	AuthSecret string `env:"AUTH_SECRET" json:"auth_secret"`
+	// Прежние ключи подписи — токены, подписанные ими, все еще принимаются
+	AuthPreviousSecrets []string `env:"AUTH_PREVIOUS_SECRETS" json:"auth_previous_secrets"`
+	// Срок действия токена пользователя, 0 — бессрочный
+	AuthTokenTTL Duration `env:"AUTH_TOKEN_TTL" json:"auth_token_ttl"`
+	// Сколько ссылок в секунду может создавать один клиент, значение <= 0 выключает ограничение
+	RateLimitCreateRPS float64 `env:"RATE_LIMIT_CREATE_RPS" json:"rate_limit_create_rps"`
+	// Сколько ссылок клиент может создать разом
+	RateLimitCreateBurst int `env:"RATE_LIMIT_CREATE_BURST" json:"rate_limit_create_burst"`
+	// Сколько переходов в секунду может делать один клиент, значение <= 0 выключает ограничение
+	RateLimitRedirectRPS float64 `env:"RATE_LIMIT_REDIRECT_RPS" json:"rate_limit_redirect_rps"`
+	// Сколько переходов клиент может сделать разом
+	RateLimitRedirectBurst int `env:"RATE_LIMIT_REDIRECT_BURST" json:"rate_limit_redirect_burst"`
+	// Сколько коротких ссылок хранится в кэше, значение <= 0 выключает кэш
+	CacheSize int `env:"CACHE_SIZE" json:"cache_size"`
+	// Сколько найденная ссылка хранится в кэше
+	CacheTTL Duration `env:"CACHE_TTL" json:"cache_ttl"`
+	// Сколько в кэше хранится отсутствие ссылки
+	CacheNegativeTTL Duration `env:"CACHE_NEGATIVE_TTL" json:"cache_negative_ttl"`
+	// Когда журнал ссылок в файле сбрасывается на диск: always, interval или never
+	FileSyncPolicy string `env:"FILE_SYNC_POLICY" json:"file_sync_policy"`
+	// Как часто журнал сбрасывается на диск при политике interval, неположительный — раз в секунду
+	FileSyncInterval Duration `env:"FILE_SYNC_INTERVAL" json:"file_sync_interval"`
+	// Как часто журнал ссылок в файле сжимается, 0 — не сжимается
+	FileCompactInterval Duration `env:"FILE_COMPACT_INTERVAL" json:"file_compact_interval"`
+	// Хранилище ссылок: memory, file или postgres.
+	// Если не задано — postgres при заданном DatabaseDSN, иначе file при непустом FileStoragePath, иначе memory.
+	// у FileStoragePath есть путь по умолчанию — чтобы выбрать memory, его нужно явно задать пустым
+	StorageBackend string `env:"STORAGE_BACKEND" json:"storage_backend"`
+	// Сколько воркеров удаляют ссылки в фоне
+	DeleteWorkers int `env:"DELETE_WORKERS" json:"delete_workers"`
+	// Сколько запросов на удаление может ждать в очереди
+	DeleteQueueSize int `env:"DELETE_QUEUE_SIZE" json:"delete_queue_size"`
+	// Сколько ссылок удаляется за один запрос к хранилищу
+	DeleteBatchSize int `env:"DELETE_BATCH_SIZE" json:"delete_batch_size"`
+	// Как часто удаляются накопленные ссылки, если пачка еще не набралась
+	DeleteFlushInterval Duration `env:"DELETE_FLUSH_INTERVAL" json:"delete_flush_interval"`
+	// Сколько удаленная ссылка хранится: в этот срок ее можно восстановить, потом она удаляется окончательно.
+	// 0 — хранится всегда
+	DeletedRetention Duration `env:"DELETED_RETENTION" json:"deleted_retention"`
+	// Как часто окончательно удаляются ссылки, срок хранения которых истек
+	DeletedPurgeInterval Duration `env:"DELETED_PURGE_INTERVAL" json:"deleted_purge_interval"`
+	// Путь к списку заблокированных хостов, пустой — ссылки не проверяются
+	BlocklistPath string `env:"BLOCKLIST_PATH" json:"blocklist_path"`
+	// Как часто проверяется, не изменился ли список заблокированных хостов
+	BlocklistReloadInterval Duration `env:"BLOCKLIST_RELOAD_INTERVAL" json:"blocklist_reload_interval"`
+	// Как часто проверяется готовность сервиса для grpc.health.v1.Health
+	HealthCheckInterval Duration `env:"HEALTH_CHECK_INTERVAL" json:"health_check_interval"`
+	// Сколько может длиться каждый этап остановки сервиса: дообработка запросов и фоновых задач,
+	// затем сброс буферов и закрытие хранилищ. должно быть положительным
+	ShutdownTimeout Duration `env:"SHUTDOWN_TIMEOUT" json:"shutdown_timeout"`
+	// Сколько сервис продолжает обслуживать запросы после того, как /readyz начал отвечать 503, —
+	// чтобы балансировщик успел это заметить. ограничена ShutdownTimeout, 0 — без ожидания
+	ShutdownDrainDelay Duration `env:"SHUTDOWN_DRAIN_DELAY" json:"shutdown_drain_delay"`
 }
 
 var config *Config
 
+// проверяет, указан ли флаг в командной строке — в том числе с пустым значением
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func parseJSONConfig(pathToConfigFile string, config *Config) {
 	configFile, err := os.ReadFile(pathToConfigFile)
 	if err != nil {
-		logger.Log.Error(err.Error())
+		logger.Log.WithError(err).WithField("path", pathToConfigFile).Error("Could not read config file")
 		return
 	}
 
 	err = json.Unmarshal(configFile, config)
 	if err != nil {
-		logger.Log.Error(err.Error())
+		logger.Log.WithError(err).WithField("path", pathToConfigFile).Error("Could not parse config file")
 	}
 }
 
@@ -48,20 +135,83 @@ func New() *Config {
 		"baseURL":           "http://localhost:8080",
 		"serverAddress":     "localhost:8080",
 		"grpcServerAddress": "localhost:3070",
+		"tracingExporter":   "none",
+		"logLevel":          "info",
+		"logFormat":         "json",
 		"fileStoragePath":   "/tmp/short-url-db.json",
 		"certPemPath":       "certs/cert.pem",
 		"certKeyPath":       "certs/cert.key",
+		"shortCodeStrategy": "hash",
+		"fileSyncPolicy":    "always",
 	}
 
+	defaultExpiredReapInterval := time.Minute
+	defaultAnalyticsBufferSize := 10000
+	defaultAnalyticsFlushInterval := time.Second
+	defaultRateLimitCreateRPS := 5.0
+	defaultRateLimitCreateBurst := 20
+	defaultRateLimitRedirectRPS := 100.0
+	defaultRateLimitRedirectBurst := 200
+	defaultCacheSize := 10000
+	defaultCacheTTL := 5 * time.Minute
+	defaultCacheNegativeTTL := 30 * time.Second
+	defaultFileSyncInterval := time.Second
+	defaultFileCompactInterval := time.Hour
+	defaultDeleteWorkers := 2
+	defaultDeleteQueueSize := 1000
+	defaultDeleteBatchSize := 500
+	defaultDeleteFlushInterval := time.Second
+	defaultBlocklistReloadInterval := 10 * time.Second
+	defaultHealthCheckInterval := 5 * time.Second
+	defaultShutdownTimeout := 15 * time.Second
+	defaultShutdownDrainDelay := 5 * time.Second
+	defaultDeletedRetention := 7 * 24 * time.Hour
+	defaultDeletedPurgeInterval := time.Hour
+
 	var (
 		flagServerAddress     = flag.String("a", "", "Server address on which server is running")
 		flagGrpcServerAddress = flag.String("g", "", "Grpc Server address on which server is running")
+		flagMetricsAddress    = flag.String("metrics-address", "", "Address on which prometheus metrics are served, empty disables them")
+		flagTracingExporter   = flag.String("tracing-exporter", "", "Where trace spans are exported: none or stdout")
+		flagLogLevel          = flag.String("log-level", "", "Log level: debug, info, warn or error")
+		flagLogFormat         = flag.String("log-format", "", "Log format: json or text")
 		flagBaseURL           = flag.String("b", "", "Base URL which short urls will be accessible")
 		flagFileStoragePath   = flag.String("f", "", "Path to file where urls data will be stored")
 		flagDatabaseDSN       = flag.String("d", "", "Database DSN")
 		flagEnableHTTPS       = flag.Bool("s", false, "Enable HTTPS")
 		flagConfig            = flag.String("c", "", "Config in JSON")
 		flagTrustedSubnet     = flag.String("t", "", "Trusted subnet")
+
+		flagExpiredReapInterval    = flag.Duration("expired-reap-interval", 0, "How often expired urls are marked as deleted")
+		flagAnalyticsBufferSize    = flag.Int("analytics-buffer-size", 0, "How many clicks can wait in buffer to be saved")
+		flagAnalyticsFlushInterval = flag.Duration("analytics-flush-interval", 0, "How often buffered clicks are saved")
+		flagShortCodeStrategy      = flag.String("short-code-strategy", "", "Short code generation strategy: hash, random or sequence")
+		flagShortCodeLength        = flag.Int("short-code-length", 0, "Short code length, minimal length for sequence strategy")
+		flagAuthSecret             = flag.String("auth-secret", "", "Secret to sign user tokens")
+		flagAuthPreviousSecrets    = flag.String("auth-previous-secrets", "", "Comma-separated previous secrets accepted to verify user tokens")
+		flagAuthTokenTTL           = flag.Duration("auth-token-ttl", 0, "User token lifetime, 0 means tokens do not expire")
+		flagRateLimitCreateRPS     = flag.Float64("rate-limit-create-rps", 0, "Urls a client can create per second, negative disables the limit")
+		flagRateLimitCreateBurst   = flag.Int("rate-limit-create-burst", 0, "Urls a client can create at once")
+		flagRateLimitRedirectRPS   = flag.Float64("rate-limit-redirect-rps", 0, "Redirects a client can make per second, negative disables the limit")
+		flagRateLimitRedirectBurst = flag.Int("rate-limit-redirect-burst", 0, "Redirects a client can make at once")
+		flagCacheSize              = flag.Int("cache-size", 0, "How many short urls are cached, negative disables the cache")
+		flagCacheTTL               = flag.Duration("cache-ttl", 0, "How long a found url is cached")
+		flagCacheNegativeTTL       = flag.Duration("cache-negative-ttl", 0, "How long a missing url is cached")
+		flagFileSyncPolicy         = flag.String("file-sync-policy", "", "When the file storage log is synced to disk: always, interval or never")
+		flagFileSyncInterval       = flag.Duration("file-sync-interval", 0, "How often the file storage log is synced with interval policy")
+		flagFileCompactInterval    = flag.Duration("file-compact-interval", 0, "How often the file storage log is compacted")
+		flagStorageBackend         = flag.String("storage-backend", "", "Storage backend: memory, file or postgres")
+		flagDeleteWorkers          = flag.Int("delete-workers", 0, "How many workers delete urls in background")
+		flagDeleteQueueSize        = flag.Int("delete-queue-size", 0, "How many deletion requests can wait in queue")
+		flagDeleteBatchSize        = flag.Int("delete-batch-size", 0, "How many urls are deleted in one storage query")
+		flagDeleteFlushInterval    = flag.Duration("delete-flush-interval", 0, "How often queued urls are deleted")
+		flagDeletedRetention       = flag.Duration("deleted-retention", 0, "How long deleted urls can be restored before they are purged")
+		flagDeletedPurgeInterval   = flag.Duration("deleted-purge-interval", 0, "How often deleted urls past retention are purged")
+		flagBlocklistPath          = flag.String("blocklist", "", "Path to blocklist of hosts that cannot be shortened")
+		flagBlocklistReload        = flag.Duration("blocklist-reload-interval", 0, "How often the blocklist is checked for changes")
+		flagHealthCheckInterval    = flag.Duration("health-check-interval", 0, "How often readiness is checked for grpc health service")
+		flagShutdownTimeout        = flag.Duration("shutdown-timeout", 0, "How long graceful shutdown can take before it is aborted")
+		flagShutdownDrainDelay     = flag.Duration("shutdown-drain-delay", 0, "How long requests are still served after readiness starts failing")
 	)
 
 	flag.Parse()
@@ -75,6 +225,35 @@ func New() *Config {
 		BaseURL:           defaults["baseURL"],
 		FileStoragePath:   defaults["fileStoragePath"],
 		GrpcServerAddress: defaults["grpcServerAddress"],
+		TracingExporter:   defaults["tracingExporter"],
+		LogLevel:          defaults["logLevel"],
+		LogFormat:         defaults["logFormat"],
+
+		ExpiredReapInterval:    Duration{defaultExpiredReapInterval},
+		AnalyticsBufferSize:    defaultAnalyticsBufferSize,
+		AnalyticsFlushInterval: Duration{defaultAnalyticsFlushInterval},
+		ShortCodeStrategy:      defaults["shortCodeStrategy"],
+		RateLimitCreateRPS:     defaultRateLimitCreateRPS,
+		RateLimitCreateBurst:   defaultRateLimitCreateBurst,
+		RateLimitRedirectRPS:   defaultRateLimitRedirectRPS,
+		RateLimitRedirectBurst: defaultRateLimitRedirectBurst,
+		CacheSize:              defaultCacheSize,
+		CacheTTL:               Duration{defaultCacheTTL},
+		CacheNegativeTTL:       Duration{defaultCacheNegativeTTL},
+		FileSyncPolicy:         defaults["fileSyncPolicy"],
+		FileSyncInterval:       Duration{defaultFileSyncInterval},
+		FileCompactInterval:    Duration{defaultFileCompactInterval},
+		DeleteWorkers:          defaultDeleteWorkers,
+		DeleteQueueSize:        defaultDeleteQueueSize,
+		DeleteBatchSize:        defaultDeleteBatchSize,
+		DeleteFlushInterval:    Duration{defaultDeleteFlushInterval},
+
+		DeletedRetention:        Duration{defaultDeletedRetention},
+		DeletedPurgeInterval:    Duration{defaultDeletedPurgeInterval},
+		BlocklistReloadInterval: Duration{defaultBlocklistReloadInterval},
+		HealthCheckInterval:     Duration{defaultHealthCheckInterval},
+		ShutdownTimeout:         Duration{defaultShutdownTimeout},
+		ShutdownDrainDelay:      Duration{defaultShutdownDrainDelay},
 	}
 
 	// Если указан путь до конфиг-файла из json, парсим его
@@ -91,7 +270,8 @@ func New() *Config {
 		config.BaseURL = *flagBaseURL
 	}
 
-	if *flagFileStoragePath != "" {
+	// явно указанный пустой путь отключает хранение в файле
+	if isFlagSet("f") {
 		config.FileStoragePath = *flagFileStoragePath
 	}
 
@@ -111,6 +291,143 @@ func New() *Config {
 		config.GrpcServerAddress = *flagGrpcServerAddress
 	}
 
+	if isFlagSet("metrics-address") {
+		config.MetricsAddress = *flagMetricsAddress
+	}
+
+	if *flagTracingExporter != "" {
+		config.TracingExporter = *flagTracingExporter
+	}
+
+	if *flagLogLevel != "" {
+		config.LogLevel = *flagLogLevel
+	}
+
+	if *flagLogFormat != "" {
+		config.LogFormat = *flagLogFormat
+	}
+
+	if *flagExpiredReapInterval != 0 {
+		config.ExpiredReapInterval.Duration = *flagExpiredReapInterval
+	}
+
+	if *flagAnalyticsBufferSize != 0 {
+		config.AnalyticsBufferSize = *flagAnalyticsBufferSize
+	}
+
+	if *flagAnalyticsFlushInterval != 0 {
+		config.AnalyticsFlushInterval.Duration = *flagAnalyticsFlushInterval
+	}
+
+	if *flagShortCodeStrategy != "" {
+		config.ShortCodeStrategy = *flagShortCodeStrategy
+	}
+
+	if *flagShortCodeLength != 0 {
+		config.ShortCodeLength = *flagShortCodeLength
+	}
+
+	if *flagAuthSecret != "" {
+		config.AuthSecret = *flagAuthSecret
+	}
+
+	if *flagAuthPreviousSecrets != "" {
+		config.AuthPreviousSecrets = strings.Split(*flagAuthPreviousSecrets, ",")
+	}
+
+	if *flagAuthTokenTTL != 0 {
+		config.AuthTokenTTL.Duration = *flagAuthTokenTTL
+	}
+
+	if *flagRateLimitCreateRPS != 0 {
+		config.RateLimitCreateRPS = *flagRateLimitCreateRPS
+	}
+
+	if *flagRateLimitCreateBurst != 0 {
+		config.RateLimitCreateBurst = *flagRateLimitCreateBurst
+	}
+
+	if *flagRateLimitRedirectRPS != 0 {
+		config.RateLimitRedirectRPS = *flagRateLimitRedirectRPS
+	}
+
+	if *flagRateLimitRedirectBurst != 0 {
+		config.RateLimitRedirectBurst = *flagRateLimitRedirectBurst
+	}
+
+	if *flagCacheSize != 0 {
+		config.CacheSize = *flagCacheSize
+	}
+
+	if *flagCacheTTL != 0 {
+		config.CacheTTL.Duration = *flagCacheTTL
+	}
+
+	if *flagCacheNegativeTTL != 0 {
+		config.CacheNegativeTTL.Duration = *flagCacheNegativeTTL
+	}
+
+	if *flagFileSyncPolicy != "" {
+		config.FileSyncPolicy = *flagFileSyncPolicy
+	}
+
+	if *flagFileSyncInterval != 0 {
+		config.FileSyncInterval.Duration = *flagFileSyncInterval
+	}
+
+	if *flagFileCompactInterval != 0 {
+		config.FileCompactInterval.Duration = *flagFileCompactInterval
+	}
+
+	if *flagStorageBackend != "" {
+		config.StorageBackend = *flagStorageBackend
+	}
+
+	if *flagDeleteWorkers != 0 {
+		config.DeleteWorkers = *flagDeleteWorkers
+	}
+
+	if *flagDeleteQueueSize != 0 {
+		config.DeleteQueueSize = *flagDeleteQueueSize
+	}
+
+	if *flagDeleteBatchSize != 0 {
+		config.DeleteBatchSize = *flagDeleteBatchSize
+	}
+
+	if *flagDeleteFlushInterval != 0 {
+		config.DeleteFlushInterval.Duration = *flagDeleteFlushInterval
+	}
+
+	if *flagDeletedRetention != 0 {
+		config.DeletedRetention.Duration = *flagDeletedRetention
+	}
+
+	if *flagDeletedPurgeInterval != 0 {
+		config.DeletedPurgeInterval.Duration = *flagDeletedPurgeInterval
+	}
+
+	if *flagBlocklistPath != "" {
+		config.BlocklistPath = *flagBlocklistPath
+	}
+
+	if *flagBlocklistReload != 0 {
+		config.BlocklistReloadInterval.Duration = *flagBlocklistReload
+	}
+
+	if *flagHealthCheckInterval != 0 {
+		config.HealthCheckInterval.Duration = *flagHealthCheckInterval
+	}
+
+	if *flagShutdownTimeout != 0 {
+		config.ShutdownTimeout.Duration = *flagShutdownTimeout
+	}
+
+	// явный 0 отключает ожидание
+	if isFlagSet("shutdown-drain-delay") {
+		config.ShutdownDrainDelay.Duration = *flagShutdownDrainDelay
+	}
+
 	// Берем переменные из окружения
 	if serverAddress := os.Getenv("SERVER_ADDRESS"); serverAddress != "" {
 		config.ServerAddress = serverAddress
@@ -120,7 +437,7 @@ func New() *Config {
 		config.BaseURL = baseURL
 	}
 
-	if fileStoragePath := os.Getenv("FILE_STORAGE_PATH"); fileStoragePath != "" {
+	if fileStoragePath, ok := os.LookupEnv("FILE_STORAGE_PATH"); ok {
 		config.FileStoragePath = fileStoragePath
 	}
 
@@ -140,6 +457,214 @@ func New() *Config {
 		config.GrpcServerAddress = grpcServerAddress
 	}
 
+	if metricsAddress, ok := os.LookupEnv("METRICS_ADDRESS"); ok {
+		config.MetricsAddress = metricsAddress
+	}
+
+	if tracingExporter := os.Getenv("TRACING_EXPORTER"); tracingExporter != "" {
+		config.TracingExporter = tracingExporter
+	}
+
+	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
+		config.LogLevel = logLevel
+	}
+
+	if logFormat := os.Getenv("LOG_FORMAT"); logFormat != "" {
+		config.LogFormat = logFormat
+	}
+
+	if expiredReapInterval := os.Getenv("EXPIRED_REAP_INTERVAL"); expiredReapInterval != "" {
+		interval, err := time.ParseDuration(expiredReapInterval)
+		if err == nil {
+			config.ExpiredReapInterval.Duration = interval
+		}
+	}
+
+	if analyticsBufferSize := os.Getenv("ANALYTICS_BUFFER_SIZE"); analyticsBufferSize != "" {
+		size, err := strconv.Atoi(analyticsBufferSize)
+		if err == nil {
+			config.AnalyticsBufferSize = size
+		}
+	}
+
+	if analyticsFlushInterval := os.Getenv("ANALYTICS_FLUSH_INTERVAL"); analyticsFlushInterval != "" {
+		interval, err := time.ParseDuration(analyticsFlushInterval)
+		if err == nil {
+			config.AnalyticsFlushInterval.Duration = interval
+		}
+	}
+
+	if shortCodeStrategy := os.Getenv("SHORT_CODE_STRATEGY"); shortCodeStrategy != "" {
+		config.ShortCodeStrategy = shortCodeStrategy
+	}
+
+	if shortCodeLength := os.Getenv("SHORT_CODE_LENGTH"); shortCodeLength != "" {
+		length, err := strconv.Atoi(shortCodeLength)
+		if err == nil {
+			config.ShortCodeLength = length
+		}
+	}
+
+	if authSecret := os.Getenv("AUTH_SECRET"); authSecret != "" {
+		config.AuthSecret = authSecret
+	}
+
+	if authPreviousSecrets := os.Getenv("AUTH_PREVIOUS_SECRETS"); authPreviousSecrets != "" {
+		config.AuthPreviousSecrets = strings.Split(authPreviousSecrets, ",")
+	}
+
+	if authTokenTTL := os.Getenv("AUTH_TOKEN_TTL"); authTokenTTL != "" {
+		ttl, err := time.ParseDuration(authTokenTTL)
+		if err == nil {
+			config.AuthTokenTTL.Duration = ttl
+		}
+	}
+
+	if rateLimitCreateRPS := os.Getenv("RATE_LIMIT_CREATE_RPS"); rateLimitCreateRPS != "" {
+		rps, err := strconv.ParseFloat(rateLimitCreateRPS, 64)
+		if err == nil {
+			config.RateLimitCreateRPS = rps
+		}
+	}
+
+	if rateLimitCreateBurst := os.Getenv("RATE_LIMIT_CREATE_BURST"); rateLimitCreateBurst != "" {
+		burst, err := strconv.Atoi(rateLimitCreateBurst)
+		if err == nil {
+			config.RateLimitCreateBurst = burst
+		}
+	}
+
+	if rateLimitRedirectRPS := os.Getenv("RATE_LIMIT_REDIRECT_RPS"); rateLimitRedirectRPS != "" {
+		rps, err := strconv.ParseFloat(rateLimitRedirectRPS, 64)
+		if err == nil {
+			config.RateLimitRedirectRPS = rps
+		}
+	}
+
+	if rateLimitRedirectBurst := os.Getenv("RATE_LIMIT_REDIRECT_BURST"); rateLimitRedirectBurst != "" {
+		burst, err := strconv.Atoi(rateLimitRedirectBurst)
+		if err == nil {
+			config.RateLimitRedirectBurst = burst
+		}
+	}
+
+	if cacheSize := os.Getenv("CACHE_SIZE"); cacheSize != "" {
+		size, err := strconv.Atoi(cacheSize)
+		if err == nil {
+			config.CacheSize = size
+		}
+	}
+
+	if cacheTTL := os.Getenv("CACHE_TTL"); cacheTTL != "" {
+		ttl, err := time.ParseDuration(cacheTTL)
+		if err == nil {
+			config.CacheTTL.Duration = ttl
+		}
+	}
+
+	if cacheNegativeTTL := os.Getenv("CACHE_NEGATIVE_TTL"); cacheNegativeTTL != "" {
+		ttl, err := time.ParseDuration(cacheNegativeTTL)
+		if err == nil {
+			config.CacheNegativeTTL.Duration = ttl
+		}
+	}
+
+	if fileSyncPolicy := os.Getenv("FILE_SYNC_POLICY"); fileSyncPolicy != "" {
+		config.FileSyncPolicy = fileSyncPolicy
+	}
+
+	if fileSyncInterval := os.Getenv("FILE_SYNC_INTERVAL"); fileSyncInterval != "" {
+		interval, err := time.ParseDuration(fileSyncInterval)
+		if err == nil {
+			config.FileSyncInterval.Duration = interval
+		}
+	}
+
+	if fileCompactInterval := os.Getenv("FILE_COMPACT_INTERVAL"); fileCompactInterval != "" {
+		interval, err := time.ParseDuration(fileCompactInterval)
+		if err == nil {
+			config.FileCompactInterval.Duration = interval
+		}
+	}
+
+	if storageBackend := os.Getenv("STORAGE_BACKEND"); storageBackend != "" {
+		config.StorageBackend = storageBackend
+	}
+
+	if deleteWorkers := os.Getenv("DELETE_WORKERS"); deleteWorkers != "" {
+		workers, err := strconv.Atoi(deleteWorkers)
+		if err == nil {
+			config.DeleteWorkers = workers
+		}
+	}
+
+	if deleteQueueSize := os.Getenv("DELETE_QUEUE_SIZE"); deleteQueueSize != "" {
+		size, err := strconv.Atoi(deleteQueueSize)
+		if err == nil {
+			config.DeleteQueueSize = size
+		}
+	}
+
+	if deleteBatchSize := os.Getenv("DELETE_BATCH_SIZE"); deleteBatchSize != "" {
+		size, err := strconv.Atoi(deleteBatchSize)
+		if err == nil {
+			config.DeleteBatchSize = size
+		}
+	}
+
+	if deleteFlushInterval := os.Getenv("DELETE_FLUSH_INTERVAL"); deleteFlushInterval != "" {
+		interval, err := time.ParseDuration(deleteFlushInterval)
+		if err == nil {
+			config.DeleteFlushInterval.Duration = interval
+		}
+	}
+
+	if deletedRetention := os.Getenv("DELETED_RETENTION"); deletedRetention != "" {
+		retention, err := time.ParseDuration(deletedRetention)
+		if err == nil {
+			config.DeletedRetention.Duration = retention
+		}
+	}
+
+	if deletedPurgeInterval := os.Getenv("DELETED_PURGE_INTERVAL"); deletedPurgeInterval != "" {
+		interval, err := time.ParseDuration(deletedPurgeInterval)
+		if err == nil {
+			config.DeletedPurgeInterval.Duration = interval
+		}
+	}
+
+	if blocklistPath := os.Getenv("BLOCKLIST_PATH"); blocklistPath != "" {
+		config.BlocklistPath = blocklistPath
+	}
+
+	if blocklistReload := os.Getenv("BLOCKLIST_RELOAD_INTERVAL"); blocklistReload != "" {
+		interval, err := time.ParseDuration(blocklistReload)
+		if err == nil {
+			config.BlocklistReloadInterval.Duration = interval
+		}
+	}
+
+	if healthCheckInterval := os.Getenv("HEALTH_CHECK_INTERVAL"); healthCheckInterval != "" {
+		interval, err := time.ParseDuration(healthCheckInterval)
+		if err == nil {
+			config.HealthCheckInterval.Duration = interval
+		}
+	}
+
+	if shutdownTimeout := os.Getenv("SHUTDOWN_TIMEOUT"); shutdownTimeout != "" {
+		timeout, err := time.ParseDuration(shutdownTimeout)
+		if err == nil {
+			config.ShutdownTimeout.Duration = timeout
+		}
+	}
+
+	if drainDelay := os.Getenv("SHUTDOWN_DRAIN_DELAY"); drainDelay != "" {
+		delay, err := time.ParseDuration(drainDelay)
+		if err == nil {
+			config.ShutdownDrainDelay.Duration = delay
+		}
+	}
+
 	if enableHTTPS := os.Getenv("ENABLE_HTTPS"); enableHTTPS != "" {
 		enableHTTPS, err := strconv.ParseBool(os.Getenv("ENABLE_HTTPS"))
 		if err == nil && enableHTTPS {
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// конфиг создается один раз на процесс, поэтому все проверки New — в одном тесте
+func TestNew(t *testing.T) {
+	// пустой путь, заданный явно, заменяет путь по умолчанию — так выбирается хранилище в памяти
+	t.Setenv("FILE_STORAGE_PATH", "")
+
+	c := New()
+
+	assert.Equal(t, "", c.FileStoragePath)
+	// метрики по умолчанию не отдаются
+	assert.Equal(t, "", c.MetricsAddress)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		drainDelay time.Duration
+		wantErr    error
+	}{
+		{name: "positive timeout", timeout: 15 * time.Second},
+		{name: "zero timeout", timeout: 0, wantErr: ErrInvalidShutdownTimeout},
+		{name: "negative timeout", timeout: -time.Second, wantErr: ErrInvalidShutdownTimeout},
+		{name: "drain delay", timeout: 15 * time.Second, drainDelay: 5 * time.Second},
+		{name: "negative drain delay", timeout: 15 * time.Second, drainDelay: -time.Second, wantErr: ErrInvalidDrainDelay},
+		{name: "drain delay takes whole timeout", timeout: 5 * time.Second, drainDelay: 5 * time.Second, wantErr: ErrInvalidDrainDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{ShutdownTimeout: Duration{tt.timeout}, ShutdownDrainDelay: Duration{tt.drainDelay}}
+			assert.ErrorIs(t, c.Validate(), tt.wantErr)
+		})
+	}
+}
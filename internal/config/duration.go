@@ -0,0 +1,30 @@
+package config
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Длительность, которая в json-конфиге задается строкой вида "1m30s"
+type Duration struct {
+	time.Duration
+}
+
+// Разбирает длительность из json-строки
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	d.Duration, err = time.ParseDuration(value)
+
+	return err
+}
+
+// Сериализует длительность в json-строку
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
@@ -38,13 +38,13 @@ func (c *Config) generateCerts() error {
 
 	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
 	if err != nil {
-		logger.Log.Fatal(err)
+		logger.Log.WithError(err).Fatal("Could not generate private key")
 		return err
 	}
 
 	certBytes, err := x509.CreateCertificate(rand.Reader, cert, cert, &privateKey.PublicKey, privateKey)
 	if err != nil {
-		logger.Log.Fatal(err)
+		logger.Log.WithError(err).Fatal("Could not create certificate")
 		return err
 	}
 
@@ -62,7 +62,7 @@ func (c *Config) generateCerts() error {
 
 	pemFile, err := os.Create(c.CertPemPath)
 	if err != nil {
-		logger.Log.Fatal(err)
+		logger.Log.WithError(err).Fatal("Could not create cert file")
 		return err
 	}
 
@@ -70,13 +70,13 @@ func (c *Config) generateCerts() error {
 
 	_, err = pemFile.Write(certPEM.Bytes())
 	if err != nil {
-		logger.Log.Fatal(err)
+		logger.Log.WithError(err).Fatal("Could not write cert file")
 		return err
 	}
 
 	keyFile, err := os.Create(c.CertKeyPath)
 	if err != nil {
-		logger.Log.Fatal(err)
+		logger.Log.WithError(err).Fatal("Could not create key file")
 		return err
 	}
 
@@ -84,7 +84,7 @@ func (c *Config) generateCerts() error {
 
 	_, err = keyFile.Write(privateKeyPEM.Bytes())
 	if err != nil {
-		logger.Log.Fatal(err)
+		logger.Log.WithError(err).Fatal("Could not write key file")
 		return err
 	}
 
@@ -104,7 +104,7 @@ func (c *Config) checkIfCertsExist() bool {
 func (c *Config) GetCerts() (string, string, error) {
 	exist := c.checkIfCertsExist()
 	if exist {
-		logger.Log.Info("Certs exist")
+		logger.Log.WithField("path", c.CertPemPath).Info("Certs exist")
 		return c.CertPemPath, c.CertKeyPath, nil
 	}
 
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Ошибка если время на остановку сервиса не положительное
+var ErrInvalidShutdownTimeout = errors.New("shutdown timeout must be positive")
+
+// Ошибка если задержка перед остановкой серверов отрицательная или не оставляет времени на саму остановку
+var ErrInvalidDrainDelay = errors.New("shutdown drain delay must be non-negative and less than shutdown timeout")
+
+// проверяет значения, с которыми сервис не может работать
+func (c *Config) Validate() error {
+	if c.ShutdownTimeout.Duration <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidShutdownTimeout, c.ShutdownTimeout.Duration)
+	}
+
+	if c.ShutdownDrainDelay.Duration < 0 || c.ShutdownDrainDelay.Duration >= c.ShutdownTimeout.Duration {
+		return fmt.Errorf("%w: %s", ErrInvalidDrainDelay, c.ShutdownDrainDelay.Duration)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Структура body по созданию аккаунта
+type APICreateAccountBody struct {
+	Name string `json:"name"`
+}
+
+// Результат выпуска API-ключа — сам ключ показывается только в этом ответе
+type APICreateKeyResult struct {
+	Key string `json:"key"`
+	accounts.APIKey
+}
+
+// Обрабатывает http-запрос на создание аккаунта сервисного клиента
+func (c *Controller) CreateAccount(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	var body APICreateAccountBody
+
+	err := json.Unmarshal(ctx.Body(), &body)
+	if err != nil {
+		return ctx.SendStatus(http.StatusBadRequest)
+	}
+
+	account, err := c.accounts.CreateAccount(ctx.UserContext(), utils.CopyString(body.Name))
+
+	if errors.Is(err, accounts.ErrEmptyName) {
+		return c.sendAPIError(ctx, http.StatusBadRequest, err)
+	}
+
+	if err != nil {
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not create account")
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	response, err := json.Marshal(account)
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusCreated).Send(response)
+}
+
+// Обрабатывает http-запрос на выпуск API-ключа для аккаунта
+func (c *Controller) CreateAPIKey(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	secret, key, err := c.accounts.CreateKey(ctx.UserContext(), utils.CopyString(ctx.Params("id")))
+
+	if errors.Is(err, accounts.ErrNotFound) {
+		return ctx.SendStatus(http.StatusNotFound)
+	}
+
+	if err != nil {
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not create api key")
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	response, err := json.Marshal(APICreateKeyResult{
+		Key:    secret,
+		APIKey: key,
+	})
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusCreated).Send(response)
+}
+
+// Обрабатывает http-запрос на отзыв API-ключа
+func (c *Controller) RevokeAPIKey(ctx *fiber.Ctx) error {
+	err := c.accounts.RevokeKey(ctx.UserContext(), utils.CopyString(ctx.Params("id")))
+
+	if errors.Is(err, accounts.ErrNotFound) {
+		return ctx.SendStatus(http.StatusNotFound)
+	}
+
+	if err != nil {
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not revoke api key")
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
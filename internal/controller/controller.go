@@ -2,25 +2,38 @@
 package controller
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	"github.com/augustjourney/urlshrt/internal/auth"
 	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/middleware"
 	"github.com/augustjourney/urlshrt/internal/service"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/sirupsen/logrus"
 )
 
 // Структура контроллера с методами, которые обрабатывают http-запросы
 type Controller struct {
-	service service.IService
+	service  service.IService
+	auth     *auth.Manager
+	accounts *accounts.Service
 }
 
 // Структура body по сокращению ссылок в api-запросе
 type APICreateURLBody struct {
-	URL string `json:"url"`
+	URL       string     `json:"url"`
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Срок жизни ссылки в секундах — альтернатива ExpiresAt
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 // Резлуьтат по сокращению ссылок в api-запросе
@@ -28,6 +41,30 @@ type APICreateURLResult struct {
 	Result string `json:"result"`
 }
 
+// Заголовок с ключом следующей страницы ссылок пользователя
+const NextCursorHeader = "X-Next-Cursor"
+
+// Структура body по изменению ссылки — пустые поля не меняются
+type APIUpdateURLBody struct {
+	OriginalURL string `json:"original_url"`
+	// Пользователь, которому передается ссылка
+	UserID string `json:"user_id"`
+}
+
+// Результат восстановления удаленных ссылок
+type APIRestoreResult struct {
+	// Восстановленные короткие ссылки — чужие и уже не восстановимые пропускаются
+	Restored []string `json:"restored"`
+}
+
+// Ошибка в ответе на api-запрос
+type APIErrorResult struct {
+	Error string `json:"error"`
+	// Для ошибок проверки ссылки — поле и машиночитаемая причина
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
 // Обрабатывает http-запрос на отсутствующий адрес
 func (c *Controller) BadRequest(ctx *fiber.Ctx) error {
 	ctx.Set("Content-type", "text/plain")
@@ -44,7 +81,7 @@ func (c *Controller) CreateURL(ctx *fiber.Ctx) error {
 	user, err := c.checkAuth(ctx, true)
 
 	if err != nil {
-		return ctx.SendStatus(http.StatusInternalServerError)
+		return ctx.SendStatus(authErrorStatus(err))
 	}
 
 	originalURL := string(ctx.Body())
@@ -53,7 +90,10 @@ func (c *Controller) CreateURL(ctx *fiber.Ctx) error {
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
-	result, err := c.service.Shorten(originalURL, user)
+	result, err := c.service.Shorten(ctx.UserContext(), originalURL, user, service.ShortenOptions{})
+	if errors.Is(err, service.ErrInvalidURL) {
+		return ctx.Status(http.StatusBadRequest).SendString(err.Error())
+	}
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).SendString(err.Error())
 	}
@@ -78,7 +118,7 @@ func (c *Controller) APICreateURLBatch(ctx *fiber.Ctx) error {
 	user, err := c.checkAuth(ctx, true)
 
 	if err != nil {
-		return ctx.SendStatus(http.StatusInternalServerError)
+		return ctx.SendStatus(authErrorStatus(err))
 	}
 
 	var body []service.BatchURL
@@ -86,30 +126,46 @@ func (c *Controller) APICreateURLBatch(ctx *fiber.Ctx) error {
 	err = json.Unmarshal(ctx.Body(), &body)
 
 	if err != nil {
-		logger.Log.Error(err)
+		logger.FromContext(ctx.UserContext()).WithError(err).Debug("Invalid batch body")
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
 	if len(body) == 0 {
-		logger.Log.Error("empty body in creating url batch")
+		logger.FromContext(ctx.UserContext()).Debug("Empty batch body")
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
-	result, err := c.service.ShortenBatch(body, user)
+	// Неверные ссылки не мешают сократить остальные — их статусы в ответе
+	result, err := c.service.ShortenBatch(ctx.UserContext(), body, user)
 
 	if err != nil {
-		logger.Log.Error(err)
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not shorten batch")
 		return ctx.SendStatus(http.StatusInternalServerError)
 	}
 
 	response, err := json.Marshal(result)
 
 	if err != nil {
-		logger.Log.Error(err)
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not encode batch result")
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
-	return ctx.Status(http.StatusCreated).Send(response)
+	return ctx.Status(batchStatus(result)).Send(response)
+}
+
+// код ответа на пачку: 201, если сохранена хоть одна ссылка или все уже были сокращены раньше.
+// если не сохранилось ничего — 500 при внутренней ошибке, иначе 400; статусы ссылок в теле в любом случае
+func batchStatus(result []service.BatchResultURL) int {
+	status := http.StatusBadRequest
+	for _, url := range result {
+		switch url.Status {
+		case service.BatchStatusCreated, service.BatchStatusExisted:
+			return http.StatusCreated
+		case service.BatchStatusFailed:
+			status = http.StatusInternalServerError
+		}
+	}
+	return status
 }
 
 // Обрабатывает http-запрос на удаление множества ссылок
@@ -121,8 +177,8 @@ func (c *Controller) APIDeleteBatch(ctx *fiber.Ctx) error {
 	user, err := c.checkAuth(ctx, false)
 
 	if err != nil {
-		logger.Log.Error(err)
-		return ctx.SendStatus(http.StatusInternalServerError)
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not authenticate user")
+		return ctx.SendStatus(authErrorStatus(err))
 	}
 
 	if user == "" {
@@ -134,14 +190,11 @@ func (c *Controller) APIDeleteBatch(ctx *fiber.Ctx) error {
 	err = json.Unmarshal(ctx.Body(), &shortIds)
 
 	if err != nil {
-		logger.Log.Error(err)
+		logger.FromContext(ctx.UserContext()).WithError(err).Debug("Invalid deletion body")
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
-	rctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	err = c.service.DeleteBatch(rctx, shortIds, user)
+	err = c.service.DeleteBatch(ctx.UserContext(), shortIds, user)
 
 	if err != nil {
 		return ctx.SendStatus(http.StatusInternalServerError)
@@ -161,7 +214,7 @@ func (c *Controller) APICreateURL(ctx *fiber.Ctx) error {
 	user, err := c.checkAuth(ctx, true)
 
 	if err != nil {
-		return ctx.SendStatus(http.StatusInternalServerError)
+		return ctx.SendStatus(authErrorStatus(err))
 	}
 
 	var body APICreateURLBody
@@ -169,12 +222,29 @@ func (c *Controller) APICreateURL(ctx *fiber.Ctx) error {
 	err = json.Unmarshal(ctx.Body(), &body)
 
 	if err != nil || body.URL == "" {
-		logger.Log.Error(err)
+		logger.FromContext(ctx.UserContext()).WithError(err).Debug("Invalid shorten body")
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
 	// Make a short url
-	result, err := c.service.Shorten(body.URL, user)
+	result, err := c.service.Shorten(ctx.UserContext(), body.URL, user, service.ShortenOptions{
+		Alias:     body.Alias,
+		ExpiresAt: body.ExpiresAt,
+		TTL:       time.Duration(body.TTL) * time.Second,
+	})
+
+	if errors.Is(err, service.ErrInvalidURL) ||
+		errors.Is(err, service.ErrInvalidAlias) ||
+		errors.Is(err, service.ErrInvalidExpiration) {
+		return c.sendAPIError(ctx, http.StatusBadRequest, err)
+	}
+
+	// Псевдоним занят другой ссылкой — конфликт,
+	// но в отличие от уже существующей ссылки в ответе ошибка, а не результат
+	if errors.Is(err, service.ErrAliasTaken) {
+		return c.sendAPIError(ctx, http.StatusConflict, err)
+	}
+
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).SendString(err.Error())
 	}
@@ -184,7 +254,7 @@ func (c *Controller) APICreateURL(ctx *fiber.Ctx) error {
 	})
 
 	if err != nil {
-		logger.Log.Error(err)
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not encode shorten result")
 		return ctx.SendStatus(http.StatusBadRequest)
 	}
 
@@ -210,11 +280,11 @@ func (c *Controller) GetURL(ctx *fiber.Ctx) error {
 	short := ctx.Params("short")
 
 	// Find original
-	originalURL, err := c.service.FindOriginal(short)
+	originalURL, err := c.service.FindOriginal(ctx.UserContext(), short)
 
 	// TODO: наверное, будет лучше вынести эти ошибки из сервиса
 	// Куда-то в отдельный модуль со всеми ошибками
-	if errors.Is(err, service.ErrIsDeleted) {
+	if errors.Is(err, service.ErrIsDeleted) || errors.Is(err, service.ErrExpired) || errors.Is(err, service.ErrIsBlocked) {
 		return ctx.SendStatus(http.StatusGone)
 	}
 
@@ -227,6 +297,16 @@ func (c *Controller) GetURL(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusInternalServerError).SendString(err.Error())
 	}
 
+	// Переход сохраняется асинхронно, а fiber переиспользует буферы запроса —
+	// поэтому строки копируем
+	c.service.RecordClick(analytics.Click{
+		Short:     utils.CopyString(short),
+		Timestamp: time.Now(),
+		Referer:   utils.CopyString(ctx.Get(fiber.HeaderReferer)),
+		UserAgent: utils.CopyString(ctx.Get(fiber.HeaderUserAgent)),
+		IP:        utils.CopyString(middleware.ClientIP(ctx)),
+	})
+
 	// Response
 	ctx.Location(originalURL)
 	return ctx.Status(http.StatusTemporaryRedirect).SendString(originalURL)
@@ -246,29 +326,195 @@ func (c *Controller) GetUserURLs(ctx *fiber.Ctx) error {
 		return ctx.SendStatus(http.StatusUnauthorized)
 	}
 
-	urls, err := c.service.GetUserURLs(context.Background(), user)
+	limit, err := strconv.Atoi(ctx.Query("limit", "0"))
+	if err != nil {
+		return c.sendAPIError(ctx, http.StatusBadRequest, service.ErrInvalidListOptions)
+	}
+
+	page, err := c.service.GetUserURLs(ctx.UserContext(), user, service.ListOptions{
+		Cursor:   ctx.Query("cursor"),
+		Limit:    limit,
+		Order:    ctx.Query("order"),
+		Contains: utils.CopyString(ctx.Query("contains")),
+		Host:     ctx.Query("host"),
+	})
+
+	if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidListOptions) {
+		return c.sendAPIError(ctx, http.StatusBadRequest, err)
+	}
 
 	if err != nil {
 		return ctx.SendStatus(http.StatusInternalServerError)
 	}
 
-	if len(urls) == 0 {
+	// Ключ следующей страницы передается в заголовке — тело остается списком ссылок
+	if page.NextCursor != "" {
+		ctx.Set(NextCursorHeader, page.NextCursor)
+	}
+
+	if len(page.URLs) == 0 {
 		return ctx.SendStatus(http.StatusNoContent)
 	}
 
-	response, err := json.Marshal(urls)
+	response, err := json.Marshal(page.URLs)
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).Send(response)
+
+}
+
+// Обрабатывает http-запрос на получение статистики переходов по ссылке пользователя
+func (c *Controller) GetURLStats(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	user, _ := c.checkAuth(ctx, false)
+
+	if user == "" {
+		return ctx.SendStatus(http.StatusUnauthorized)
+	}
+
+	stats, err := c.service.GetURLStats(ctx.UserContext(), ctx.Params("short"), user)
+
+	if errors.Is(err, service.ErrNotFound) {
+		return ctx.SendStatus(http.StatusNotFound)
+	}
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	response, err := json.Marshal(stats)
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).Send(response)
+}
+
+// обрабатывает http-запрос на получение журнала изменений ссылки пользователя
+func (c *Controller) GetURLChanges(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	user, _ := c.checkAuth(ctx, false)
+
+	if user == "" {
+		return ctx.SendStatus(http.StatusUnauthorized)
+	}
+
+	changes, err := c.service.GetURLChanges(ctx.UserContext(), ctx.Params("short"), user)
+
+	if errors.Is(err, service.ErrNotFound) {
+		return ctx.SendStatus(http.StatusNotFound)
+	}
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	response, err := json.Marshal(changes)
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).Send(response)
+}
+
+// Обрабатывает http-запрос на восстановление удаленных ссылок пользователя
+func (c *Controller) APIRestoreBatch(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	user, err := c.checkAuth(ctx, false)
+
+	if err != nil {
+		logger.FromContext(ctx.UserContext()).WithError(err).Error("Could not authenticate user")
+		return ctx.SendStatus(authErrorStatus(err))
+	}
+
+	if user == "" {
+		return ctx.SendStatus(http.StatusUnauthorized)
+	}
+
+	var shortIds []string
+
+	err = json.Unmarshal(ctx.Body(), &shortIds)
+
+	if err != nil {
+		logger.FromContext(ctx.UserContext()).WithError(err).Debug("Invalid restore body")
+		return ctx.SendStatus(http.StatusBadRequest)
+	}
+
+	restored, err := c.service.RestoreBatch(ctx.UserContext(), shortIds, user)
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
 
+	response, err := json.Marshal(APIRestoreResult{Restored: restored})
 	if err != nil {
 		return ctx.SendStatus(http.StatusInternalServerError)
 	}
 
 	return ctx.Status(http.StatusOK).Send(response)
+}
+
+// Обрабатывает http-запрос на изменение адреса ссылки или передачу ее другому пользователю
+func (c *Controller) APIUpdateURL(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	user, _ := c.checkAuth(ctx, false)
+
+	if user == "" {
+		return ctx.SendStatus(http.StatusUnauthorized)
+	}
 
+	var body APIUpdateURLBody
+
+	err := json.Unmarshal(ctx.Body(), &body)
+	if err != nil {
+		return ctx.SendStatus(http.StatusBadRequest)
+	}
+
+	url, err := c.service.UpdateURL(ctx.UserContext(), utils.CopyString(ctx.Params("short")), user, service.UpdateOptions{
+		OriginalURL: body.OriginalURL,
+		UserUUID:    body.UserID,
+	})
+
+	if errors.Is(err, service.ErrInvalidURL) || errors.Is(err, service.ErrNothingToUpdate) || errors.Is(err, service.ErrUnknownUser) {
+		return c.sendAPIError(ctx, http.StatusBadRequest, err)
+	}
+
+	if errors.Is(err, service.ErrNotFound) {
+		return ctx.SendStatus(http.StatusNotFound)
+	}
+
+	if errors.Is(err, service.ErrIsBlocked) {
+		return c.sendAPIError(ctx, http.StatusForbidden, err)
+	}
+
+	if errors.Is(err, service.ErrURLConflict) {
+		return c.sendAPIError(ctx, http.StatusConflict, err)
+	}
+
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	response, err := json.Marshal(url)
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).Send(response)
 }
 
 // обрабатывает http-запрос на получение внутренней статистикиы
 func (c *Controller) GetStats(ctx *fiber.Ctx) error {
-	stats, err := c.service.GetStats(context.Background())
+	stats, err := c.service.GetStats(ctx.UserContext())
 	if err != nil {
 		return ctx.SendStatus(http.StatusInternalServerError)
 	}
@@ -281,40 +527,97 @@ func (c *Controller) GetStats(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).Send(resp)
 }
 
+// отправляет ошибку в формате json с указанным статусом
+func (c *Controller) sendAPIError(ctx *fiber.Ctx, status int, err error) error {
+	result := APIErrorResult{
+		Error: err.Error(),
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		result.Field = validationErr.Field
+		result.Reason = validationErr.Reason
+	}
+
+	response, marshalErr := json.Marshal(result)
+
+	if marshalErr != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(status).Send(response)
+}
+
+// получает ID пользователя из подписанного токена и добавляет его в поля лога запроса.
+// если токена нет, а createIfEmpty — true, создает нового пользователя
+// и выдает ему токен в куке и заголовке Authorization.
+// токен, который не прошел проверку, — ошибка, как и в grpc: новый пользователь для него не создается
 func (c *Controller) checkAuth(ctx *fiber.Ctx, createIfEmpty bool) (string, error) {
-	// ID пользователя может храниться
+	user, err := c.authenticate(ctx, createIfEmpty)
+	if user != "" {
+		logger.AddFields(ctx.UserContext(), logrus.Fields{"user": user})
+	}
+	return user, err
+}
+
+// проверяет токен или API-ключ пользователя — см. checkAuth
+func (c *Controller) authenticate(ctx *fiber.Ctx, createIfEmpty bool) (string, error) {
+	// Токен пользователя может храниться
 	// Либо в заголовке Authorization
 	// Либо в куке user
-	user := ctx.Get("Authorization")
-	cookie := ctx.Cookies("user")
+	token := strings.TrimPrefix(ctx.Get("Authorization"), "Bearer ")
 
-	if cookie != "" && user == "" {
-		user = cookie
+	// Сервисные клиенты передают API-ключ — ссылки принадлежат их аккаунту
+	if accounts.IsAPIKey(token) {
+		return c.accounts.Authenticate(ctx.UserContext(), token)
 	}
 
-	if createIfEmpty && user == "" {
-		var err error
-		user, err = c.service.GenerateID()
-		if err != nil {
-			return "", err
-		}
+	if token == "" {
+		token = ctx.Cookies("user")
+	}
 
-		cookie := new(fiber.Cookie)
-		cookie.Name = "user"
-		cookie.Value = user
+	if token != "" {
+		return c.auth.Verify(token)
+	}
 
-		ctx.Cookie(cookie)
+	if !createIfEmpty {
+		return "", nil
+	}
 
-		ctx.Set("Authorization", user)
-		return user, nil
+	user, err := c.service.GenerateID()
+	if err != nil {
+		return "", err
+	}
+
+	token, err = c.auth.Issue(user)
+	if err != nil {
+		return "", err
 	}
 
+	cookie := new(fiber.Cookie)
+	cookie.Name = "user"
+	cookie.Value = token
+	cookie.HTTPOnly = true
+
+	ctx.Cookie(cookie)
+
+	ctx.Set("Authorization", token)
 	return user, nil
 }
 
+// статус ответа на ошибку проверки пользователя
+func authErrorStatus(err error) int {
+	if errors.Is(err, accounts.ErrInvalidKey) || errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrExpiredToken) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
 // Создает новый экземпляр контроллера
-func NewHTTPController(service service.IService) *Controller {
+func NewHTTPController(service service.IService, auth *auth.Manager, accounts *accounts.Service) *Controller {
 	return &Controller{
-		service: service,
+		service:  service,
+		auth:     auth,
+		accounts: accounts,
 	}
 }
@@ -3,13 +3,37 @@ package controller
 import (
 	"context"
 	"errors"
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	"github.com/augustjourney/urlshrt/internal/auth"
+	"github.com/augustjourney/urlshrt/internal/middleware"
 	pb "github.com/augustjourney/urlshrt/internal/proto"
 	"github.com/augustjourney/urlshrt/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"time"
 )
 
+// переводит ошибку проверки ссылки в InvalidArgument с описанием неверного поля
+func invalidArgument(err *service.ValidationError) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: err.Field, Description: err.Reason + ": " + err.Message},
+		},
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
 // Grpc-контроллер
 type GrpcController struct {
 	service service.IService
@@ -20,12 +44,16 @@ type GrpcController struct {
 func (c *GrpcController) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
 	var res pb.GetResponse
 
-	originalURL, err := c.service.FindOriginal(req.ShortUrl)
+	originalURL, err := c.service.FindOriginal(ctx, req.ShortUrl)
 	if errors.Is(err, service.ErrIsDeleted) {
 		return &res, status.Errorf(codes.InvalidArgument, err.Error())
 	}
 
-	if errors.Is(err, service.ErrNotFound) {
+	if errors.Is(err, service.ErrIsBlocked) {
+		return &res, status.Errorf(codes.PermissionDenied, err.Error())
+	}
+
+	if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrExpired) {
 		return &res, status.Errorf(codes.NotFound, err.Error())
 	}
 
@@ -33,6 +61,8 @@ func (c *GrpcController) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetRe
 		return &res, status.Errorf(codes.Internal, err.Error())
 	}
 
+	c.service.RecordClick(clickFromContext(ctx, req.ShortUrl))
+
 	res.OriginalUrl = originalURL
 
 	return &res, nil
@@ -42,7 +72,7 @@ func (c *GrpcController) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetRe
 func (c *GrpcController) Create(ctx context.Context, req *pb.CreateRequest) (*pb.CreateResponse, error) {
 	var res pb.CreateResponse
 
-	user, err := c.getUserFromMetadata(ctx)
+	user, err := c.getUserFromContext(ctx)
 
 	if err != nil {
 		return &res, err
@@ -52,7 +82,25 @@ func (c *GrpcController) Create(ctx context.Context, req *pb.CreateRequest) (*pb
 		return &res, status.Errorf(codes.InvalidArgument, "original url is required")
 	}
 
-	result, err := c.service.Shorten(req.OriginalUrl, user)
+	result, err := c.service.Shorten(ctx, req.OriginalUrl, user, service.ShortenOptions{
+		Alias:     req.Alias,
+		ExpiresAt: timestampToTime(req.ExpiresAt),
+		TTL:       time.Duration(req.Ttl) * time.Second,
+	})
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return &res, invalidArgument(validationErr)
+	}
+
+	if errors.Is(err, service.ErrInvalidAlias) || errors.Is(err, service.ErrInvalidExpiration) {
+		return &res, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if errors.Is(err, service.ErrAliasTaken) {
+		return &res, status.Errorf(codes.AlreadyExists, err.Error())
+	}
+
 	if err != nil {
 		return &res, status.Errorf(codes.Internal, err.Error())
 	}
@@ -69,7 +117,7 @@ func (c *GrpcController) Create(ctx context.Context, req *pb.CreateRequest) (*pb
 func (c *GrpcController) CreateBatch(ctx context.Context, req *pb.CreateBatchRequest) (*pb.CreateBatchResponse, error) {
 	var res pb.CreateBatchResponse
 
-	user, err := c.getUserFromMetadata(ctx)
+	user, err := c.getUserFromContext(ctx)
 
 	if err != nil {
 		return &res, err
@@ -94,11 +142,15 @@ func (c *GrpcController) CreateBatch(ctx context.Context, req *pb.CreateBatchReq
 			body = append(body, service.BatchURL{
 				OriginalURL:   url.OriginalUrl,
 				CorrelationID: url.CorrelationId,
+				Alias:         url.Alias,
+				ExpiresAt:     timestampToTime(url.ExpiresAt),
+				TTL:           url.Ttl,
 			})
 		}
 	}
 
-	result, err := c.service.ShortenBatch(body, user)
+	result, err := c.service.ShortenBatch(ctx, body, user)
+
 	if err != nil {
 		return &res, status.Errorf(codes.Internal, err.Error())
 	}
@@ -107,6 +159,8 @@ func (c *GrpcController) CreateBatch(ctx context.Context, req *pb.CreateBatchReq
 		res.Urls = append(res.Urls, &pb.BatchURLResult{
 			ShortUrl:      url.ShortURL,
 			CorrelationId: url.CorrelationID,
+			Status:        url.Status,
+			Error:         url.Error,
 		})
 	}
 
@@ -116,32 +170,48 @@ func (c *GrpcController) CreateBatch(ctx context.Context, req *pb.CreateBatchReq
 func (c *GrpcController) GetUserURLs(ctx context.Context, req *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error) {
 	var res pb.GetUserURLsResponse
 
-	user, err := c.getUserFromMetadata(ctx)
+	user, err := c.getUserFromContext(ctx)
 
 	if err != nil {
 		return &res, err
 	}
 
-	urls, err := c.service.GetUserURLs(ctx, user)
+	page, err := c.service.GetUserURLs(ctx, user, service.ListOptions{
+		Cursor:   req.Cursor,
+		Limit:    int(req.Limit),
+		Order:    req.Order,
+		Contains: req.Contains,
+		Host:     req.Host,
+	})
+
+	if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidListOptions) {
+		return &res, status.Errorf(codes.InvalidArgument, err.Error())
+	}
 
 	if err != nil {
 		return &res, status.Errorf(codes.Internal, err.Error())
 	}
 
-	for _, url := range urls {
-		res.Urls = append(res.Urls, &pb.UserURL{
+	for _, url := range page.URLs {
+		userURL := &pb.UserURL{
 			ShortUrl:    url.ShortURL,
 			OriginalUrl: url.OriginalURL,
-		})
+		}
+		if url.CreatedAt != nil {
+			userURL.CreatedAt = timestamppb.New(*url.CreatedAt)
+		}
+		res.Urls = append(res.Urls, userURL)
 	}
 
+	res.NextCursor = page.NextCursor
+
 	return &res, nil
 }
 
 func (c *GrpcController) DeleteBatch(ctx context.Context, req *pb.DeleteBatchRequest) (*pb.DeleteBatchResponse, error) {
 	var res pb.DeleteBatchResponse
 
-	user, err := c.getUserFromMetadata(ctx)
+	user, err := c.getUserFromContext(ctx)
 
 	if err != nil {
 		return &res, err
@@ -155,37 +225,212 @@ func (c *GrpcController) DeleteBatch(ctx context.Context, req *pb.DeleteBatchReq
 	return &res, nil
 }
 
+// Восстанавливает удаленные ссылки пользователя через grpc
+func (c *GrpcController) RestoreBatch(ctx context.Context, req *pb.RestoreBatchRequest) (*pb.RestoreBatchResponse, error) {
+	var res pb.RestoreBatchResponse
+
+	user, err := c.getUserFromContext(ctx)
+
+	if err != nil {
+		return &res, err
+	}
+
+	res.ShortUrls, err = c.service.RestoreBatch(ctx, req.ShortUrls, user)
+	if err != nil {
+		return &res, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &res, nil
+}
+
 func (c *GrpcController) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
 	var res pb.GetStatsResponse
 
-	stats, err := c.service.GetStats(context.Background())
+	stats, err := c.service.GetStats(ctx)
 	if err != nil {
 		return &res, status.Errorf(codes.Internal, err.Error())
 	}
 
 	res.Urls = int32(stats.Urls)
 	res.Users = int32(stats.Users)
+	res.PendingDeletions = int32(stats.PendingDeletions)
+
+	return &res, nil
+}
+
+// Получает статистику переходов по ссылке пользователя через grpc
+func (c *GrpcController) GetURLStats(ctx context.Context, req *pb.GetURLStatsRequest) (*pb.GetURLStatsResponse, error) {
+	var res pb.GetURLStatsResponse
+
+	user, err := c.getUserFromContext(ctx)
+
+	if err != nil {
+		return &res, err
+	}
+
+	stats, err := c.service.GetURLStats(ctx, req.ShortUrl, user)
+
+	if errors.Is(err, service.ErrNotFound) {
+		return &res, status.Errorf(codes.NotFound, err.Error())
+	}
+
+	if err != nil {
+		return &res, status.Errorf(codes.Internal, err.Error())
+	}
+
+	res.Clicks = int64(stats.Clicks)
+	res.UniqueVisitors = int64(stats.UniqueVisitors)
+
+	if stats.LastClickAt != nil {
+		res.LastClickAt = timestamppb.New(*stats.LastClickAt)
+	}
+
+	for _, referer := range stats.Referers {
+		res.Referers = append(res.Referers, &pb.RefererStats{
+			Referer: referer.Referer,
+			Clicks:  int64(referer.Clicks),
+		})
+	}
+
+	return &res, nil
+}
+
+// Получает журнал изменений ссылки пользователя через grpc
+func (c *GrpcController) GetURLChanges(ctx context.Context, req *pb.GetURLChangesRequest) (*pb.GetURLChangesResponse, error) {
+	var res pb.GetURLChangesResponse
+
+	user, err := c.getUserFromContext(ctx)
+
+	if err != nil {
+		return &res, err
+	}
+
+	changes, err := c.service.GetURLChanges(ctx, req.ShortUrl, user)
+
+	if errors.Is(err, service.ErrNotFound) {
+		return &res, status.Errorf(codes.NotFound, err.Error())
+	}
+
+	if err != nil {
+		return &res, status.Errorf(codes.Internal, err.Error())
+	}
+
+	for _, change := range changes {
+		res.Changes = append(res.Changes, &pb.URLChange{
+			ChangedBy:      change.ChangedBy,
+			OldOriginalUrl: change.OldOriginal,
+			NewOriginalUrl: change.NewOriginal,
+			OldUserId:      change.OldUserUUID,
+			NewUserId:      change.NewUserUUID,
+			ChangedAt:      timestamppb.New(change.ChangedAt),
+		})
+	}
 
 	return &res, nil
 }
 
-// получает пользователя из gprc metadata context
-func (c *GrpcController) getUserFromMetadata(ctx context.Context) (string, error) {
-	var user string
+// Меняет адрес ссылки или передает ее другому пользователю через grpc
+func (c *GrpcController) Update(ctx context.Context, req *pb.UpdateRequest) (*pb.UpdateResponse, error) {
+	var res pb.UpdateResponse
+
+	user, err := c.getUserFromContext(ctx)
+
+	if err != nil {
+		return &res, err
+	}
+
+	url, err := c.service.UpdateURL(ctx, req.ShortUrl, user, service.UpdateOptions{
+		OriginalURL: req.OriginalUrl,
+		UserUUID:    req.UserId,
+	})
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return &res, invalidArgument(validationErr)
+	}
 
-	md, ok := metadata.FromIncomingContext(ctx)
+	if errors.Is(err, service.ErrNothingToUpdate) || errors.Is(err, service.ErrUnknownUser) {
+		return &res, status.Errorf(codes.InvalidArgument, err.Error())
+	}
 
+	if errors.Is(err, service.ErrNotFound) {
+		return &res, status.Errorf(codes.NotFound, err.Error())
+	}
+
+	if errors.Is(err, service.ErrIsBlocked) {
+		return &res, status.Errorf(codes.PermissionDenied, err.Error())
+	}
+
+	if errors.Is(err, service.ErrURLConflict) {
+		return &res, status.Errorf(codes.AlreadyExists, err.Error())
+	}
+
+	if err != nil {
+		return &res, status.Errorf(codes.Internal, err.Error())
+	}
+
+	res.ShortUrl = url.ShortURL
+	res.OriginalUrl = url.OriginalURL
+
+	return &res, nil
+}
+
+// собирает информацию о переходе из grpc metadata и адреса клиента
+func clickFromContext(ctx context.Context, short string) analytics.Click {
+	click := analytics.Click{
+		Short:     short,
+		Timestamp: time.Now(),
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get("referer"); len(values) > 0 {
+		click.Referer = values[0]
+	}
+
+	if values := md.Get("user-agent"); len(values) > 0 {
+		click.UserAgent = values[0]
+	}
+
+	click.IP = peerHost(ctx)
+
+	// x-real-ip верим, только если запрос пришел от прокси из доверенной подсети — как и в http
+	if values := md.Get("x-real-ip"); len(values) > 0 && middleware.InTrustedSubnet(click.IP) {
+		click.IP = values[0]
+	}
+
+	return click
+}
+
+// получает ip-адрес соединения без порта — иначе один клиент считался бы разными адресами
+func peerHost(ctx context.Context) string {
+	requestPeer, ok := peer.FromContext(ctx)
 	if !ok {
-		return user, status.Errorf(codes.Unauthenticated, "metadata is not provided")
+		return ""
 	}
 
-	values := md.Get("user")
+	addr := requestPeer.Addr.String()
 
-	if len(values) == 0 {
-		return user, status.Errorf(codes.Unauthenticated, "metadata is not provided")
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
 	}
 
-	user = values[0]
+	return host
+}
+
+// переводит proto-время в time.Time, пустое время — в nil
+func timestampToTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	result := ts.AsTime()
+	return &result
+}
+
+// получает пользователя, которого auth-интерсептор сохранил в контексте запроса
+func (c *GrpcController) getUserFromContext(ctx context.Context) (string, error) {
+	user := auth.UserFromContext(ctx)
 
 	if user == "" {
 		return user, status.Errorf(codes.Unauthenticated, "user is not provided")
@@ -2,24 +2,33 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	analyticsInmemory "github.com/augustjourney/urlshrt/internal/analytics/inmemory"
 	"github.com/augustjourney/urlshrt/internal/app"
 	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/health"
 	"github.com/augustjourney/urlshrt/internal/logger"
 	pb "github.com/augustjourney/urlshrt/internal/proto"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
 	"github.com/augustjourney/urlshrt/internal/service"
 	"github.com/augustjourney/urlshrt/internal/storage"
 	"github.com/augustjourney/urlshrt/internal/storage/inmemory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"net"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func newGrpcAppInstance() (pb.URLServiceClient, storage.IRepo, service.Service, func()) {
@@ -27,9 +36,11 @@ func newGrpcAppInstance() (pb.URLServiceClient, storage.IRepo, service.Service,
 	logger.New()
 
 	repo := inmemory.New()
-	urlService := service.New(repo, cfg)
+	tracker := analytics.NewTracker(analyticsInmemory.New(), 100, 10*time.Millisecond)
+	tracker.Start()
+	urlService := service.New(repo, cfg, service.WithAnalytics(tracker), service.WithUserDirectory(testAccounts))
 	controller := NewGrpcController(&urlService)
-	grpcServer := app.NewGrpcServer(controller)
+	grpcServer := app.NewGrpcServer(controller, newTestAuth(), testAccounts, ratelimit.Limits{}, nil, nil)
 
 	// Соединение для тестирования
 	listener := bufconn.Listen(1024 * 1024)
@@ -147,15 +158,25 @@ func TestGrpcController_Create(t *testing.T) {
 			originalURL: "http://yandex.ru?q=09123123gg",
 			code:        codes.AlreadyExists,
 		},
+		{
+			name:        "URL conflict after normalization",
+			originalURL: "HTTP://YANDEX.ru/?q=09123123gg",
+			code:        codes.AlreadyExists,
+		},
 		{
 			name:        "Empty body",
 			originalURL: "",
 			code:        codes.InvalidArgument,
 		},
+		{
+			name:        "Scheme not allowed",
+			originalURL: "javascript:alert(1)",
+			code:        codes.InvalidArgument,
+		},
 	}
 
 	md := metadata.New(map[string]string{
-		"user": "user-uuid-0123",
+		"authorization": authToken("user-uuid-0123"),
 	})
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
@@ -178,6 +199,355 @@ func TestGrpcController_Create(t *testing.T) {
 	}
 }
 
+func TestGrpcController_CreateInvalidURLDetails(t *testing.T) {
+	t.Parallel()
+	client, _, _, cleanup := newGrpcAppInstance()
+
+	t.Cleanup(cleanup)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken("user-uuid-invalid-url")))
+
+	_, err := client.Create(ctx, &pb.CreateRequest{OriginalUrl: "ftp://example.com/file"})
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+
+	require.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.FieldViolations, 1)
+	assert.Equal(t, "original_url", badRequest.FieldViolations[0].Field)
+	assert.Contains(t, badRequest.FieldViolations[0].Description, service.ReasonSchemeNotAllowed)
+}
+
+func TestGrpcController_Auth(t *testing.T) {
+	t.Parallel()
+	client, _, _, cleanup := newGrpcAppInstance()
+
+	t.Cleanup(cleanup)
+
+	// Без токена при создании ссылки выдается новый токен в заголовке ответа
+	var header metadata.MD
+	_, err := client.Create(context.Background(), &pb.CreateRequest{
+		OriginalUrl: "http://google.com?q=grpc-auth-issue",
+	}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	tokens := header.Get("authorization")
+	require.Len(t, tokens, 1)
+
+	owner, err := newTestAuth().Verify(tokens[0])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{name: "Issued token", token: tokens[0], code: codes.OK},
+		{name: "Raw user id", token: owner, code: codes.Unauthenticated},
+		{name: "No token", code: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", tt.token))
+			}
+			resp, err := client.GetUserURLs(ctx, &pb.GetUserURLsRequest{})
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code == codes.OK {
+				assert.Len(t, resp.Urls, 1)
+			}
+		})
+	}
+}
+
+func TestGrpcController_APIKey(t *testing.T) {
+	t.Parallel()
+	client, _, _, cleanup := newGrpcAppInstance()
+
+	t.Cleanup(cleanup)
+
+	account, err := testAccounts.CreateAccount(context.Background(), "grpc-backend-jobs")
+	require.NoError(t, err)
+
+	apiKey, key, err := testAccounts.CreateKey(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+apiKey))
+
+	resp, err := client.CreateBatch(ctx, &pb.CreateBatchRequest{
+		Urls: []*pb.BatchURL{
+			{OriginalUrl: "http://google.com?q=grpc-api-key-1", CorrelationId: "1"},
+			{OriginalUrl: "http://google.com?q=grpc-api-key-2", CorrelationId: "2"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Urls, 2)
+
+	userURLs, err := client.GetUserURLs(ctx, &pb.GetUserURLsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, userURLs.Urls, 2)
+
+	require.NoError(t, testAccounts.RevokeKey(context.Background(), key.ID))
+
+	_, err = client.GetUserURLs(ctx, &pb.GetUserURLsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGrpcController_CreateWithAlias(t *testing.T) {
+	t.Parallel()
+	client, _, _, cleanup := newGrpcAppInstance()
+
+	t.Cleanup(cleanup)
+
+	tests := []struct {
+		name        string
+		code        codes.Code
+		originalURL string
+		alias       string
+	}{
+		{
+			name:        "URL created with alias",
+			code:        codes.OK,
+			originalURL: "http://yandex.ru?q=alias-grpc",
+			alias:       "grpc-alias",
+		},
+		{
+			name:        "Alias is taken by another URL",
+			code:        codes.AlreadyExists,
+			originalURL: "http://yandex.ru?q=alias-grpc-2",
+			alias:       "grpc-alias",
+		},
+		{
+			name:        "Invalid alias",
+			code:        codes.InvalidArgument,
+			originalURL: "http://yandex.ru?q=alias-grpc-3",
+			alias:       "ping",
+		},
+	}
+
+	md := metadata.New(map[string]string{
+		"authorization": authToken("user-uuid-alias"),
+	})
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Create(ctx, &pb.CreateRequest{
+				OriginalUrl: tt.originalURL,
+				Alias:       tt.alias,
+			})
+			if tt.code == codes.OK {
+				require.NoError(t, err)
+				assert.Regexp(t, "/"+tt.alias+"$", resp.ShortUrl)
+			} else {
+				errCode, ok := status.FromError(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.code, errCode.Code())
+			}
+		})
+	}
+}
+
+func TestGrpcController_GetURLStats(t *testing.T) {
+	t.Parallel()
+	client, repo, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	owner := "user-uuid-stats-grpc"
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "uid-stats-grpc",
+		UserUUID: owner,
+		Original: "http://google.com?q=stats-grpc",
+		Short:    "stats-grpc",
+	})
+
+	_, err := client.Get(metadata.AppendToOutgoingContext(context.Background(), "referer", "http://ya.ru"), &pb.GetRequest{
+		ShortUrl: "stats-grpc",
+	})
+	require.NoError(t, err)
+
+	ownerCtx := metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{
+		"authorization": authToken(owner),
+	}))
+
+	assert.Eventually(t, func() bool {
+		resp, err := client.GetURLStats(ownerCtx, &pb.GetURLStatsRequest{ShortUrl: "stats-grpc"})
+		return err == nil && resp.Clicks == 1
+	}, time.Second, 20*time.Millisecond)
+
+	resp, err := client.GetURLStats(ownerCtx, &pb.GetURLStatsRequest{ShortUrl: "stats-grpc"})
+	require.NoError(t, err)
+	require.Len(t, resp.Referers, 1)
+	assert.Equal(t, "http://ya.ru", resp.Referers[0].Referer)
+
+	anotherCtx := metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{
+		"authorization": authToken("user-uuid-another"),
+	}))
+
+	_, err = client.GetURLStats(anotherCtx, &pb.GetURLStatsRequest{ShortUrl: "stats-grpc"})
+	errCode, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.NotFound, errCode.Code())
+}
+
+func TestGrpcController_Update(t *testing.T) {
+	t.Parallel()
+	client, repo, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	owner := "user-uuid-update-grpc"
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "uid-update-grpc",
+		UserUUID: owner,
+		Original: "http://google.com?q=update-grpc",
+		Short:    "update-grpc",
+	})
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "uid-update-grpc-heir",
+		UserUUID: "user-uuid-heir-grpc",
+		Original: "http://google.com?q=update-grpc-heir",
+		Short:    "update-grpc-heir",
+	})
+
+	ownerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken(owner)))
+
+	tests := []struct {
+		name string
+		req  *pb.UpdateRequest
+		code codes.Code
+	}{
+		{name: "nothing to update", req: &pb.UpdateRequest{ShortUrl: "update-grpc"}, code: codes.InvalidArgument},
+		{name: "invalid url", req: &pb.UpdateRequest{ShortUrl: "update-grpc", OriginalUrl: "javascript:alert(1)"}, code: codes.InvalidArgument},
+		{name: "unknown url", req: &pb.UpdateRequest{ShortUrl: "unknown-grpc", UserId: "someone"}, code: codes.NotFound},
+		{name: "change destination", req: &pb.UpdateRequest{ShortUrl: "update-grpc", OriginalUrl: "http://google.com?q=moved-grpc"}, code: codes.OK},
+		{name: "unknown user", req: &pb.UpdateRequest{ShortUrl: "update-grpc", UserId: "user-uuid-stranger-grpc"}, code: codes.InvalidArgument},
+		{name: "transfer", req: &pb.UpdateRequest{ShortUrl: "update-grpc", UserId: "user-uuid-heir-grpc"}, code: codes.OK},
+		{name: "former owner", req: &pb.UpdateRequest{ShortUrl: "update-grpc", UserId: owner}, code: codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Update(ownerCtx, tt.req)
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code == codes.OK {
+				assert.Equal(t, "http://google.com?q=moved-grpc", resp.OriginalUrl)
+			}
+		})
+	}
+
+	url, err := repo.Get(context.TODO(), "update-grpc")
+	require.NoError(t, err)
+	assert.Equal(t, "user-uuid-heir-grpc", url.UserUUID)
+}
+
+func TestGrpcController_GetURLChanges(t *testing.T) {
+	t.Parallel()
+	client, repo, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	owner := "user-uuid-history-grpc"
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "uid-history-grpc",
+		UserUUID: owner,
+		Original: "http://google.com?q=history-grpc",
+		Short:    "history-grpc",
+	})
+
+	ownerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken(owner)))
+	strangerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken("user-uuid-stranger-grpc")))
+
+	_, err := client.Update(ownerCtx, &pb.UpdateRequest{ShortUrl: "history-grpc", OriginalUrl: "http://google.com?q=history-moved-grpc"})
+	require.NoError(t, err)
+
+	_, err = client.GetURLChanges(strangerCtx, &pb.GetURLChangesRequest{ShortUrl: "history-grpc"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetURLChanges(ownerCtx, &pb.GetURLChangesRequest{ShortUrl: "unknown-grpc"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	resp, err := client.GetURLChanges(ownerCtx, &pb.GetURLChangesRequest{ShortUrl: "history-grpc"})
+	require.NoError(t, err)
+	require.Len(t, resp.Changes, 1)
+	assert.Equal(t, owner, resp.Changes[0].ChangedBy)
+	assert.Equal(t, "http://google.com?q=history-grpc", resp.Changes[0].OldOriginalUrl)
+	assert.Equal(t, "http://google.com?q=history-moved-grpc", resp.Changes[0].NewOriginalUrl)
+	assert.NotNil(t, resp.Changes[0].ChangedAt)
+}
+
+func TestGrpcController_RestoreBatch(t *testing.T) {
+	t.Parallel()
+	client, repo, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	owner := "user-uuid-restore-grpc"
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "uid-restore-grpc",
+		UserUUID: owner,
+		Original: "http://google.com?q=restore-grpc",
+		Short:    "restore-grpc",
+	})
+	require.NoError(t, repo.Delete(context.TODO(), []string{"restore-grpc"}, owner))
+
+	anotherCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken("user-uuid-another")))
+	resp, err := client.RestoreBatch(anotherCtx, &pb.RestoreBatchRequest{ShortUrls: []string{"restore-grpc"}})
+	require.NoError(t, err)
+	assert.Empty(t, resp.ShortUrls)
+
+	ownerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken(owner)))
+	resp, err = client.RestoreBatch(ownerCtx, &pb.RestoreBatchRequest{ShortUrls: []string{"restore-grpc"}})
+	require.NoError(t, err)
+	require.Len(t, resp.ShortUrls, 1)
+
+	getResp, err := client.Get(context.Background(), &pb.GetRequest{ShortUrl: "restore-grpc"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com?q=restore-grpc", getResp.OriginalUrl)
+}
+
+func TestGrpcController_GetUserURLsPagination(t *testing.T) {
+	t.Parallel()
+	client, repo, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	owner := "user-uuid-pages-grpc"
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		repo.Create(context.TODO(), storage.URL{
+			UUID:      fmt.Sprintf("uid-pages-grpc-%d", i),
+			UserUUID:  owner,
+			Original:  fmt.Sprintf("http://google.com?q=pages-grpc-%d", i),
+			Short:     fmt.Sprintf("pages-grpc-%d", i),
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authToken(owner)))
+
+	resp, err := client.GetUserURLs(ctx, &pb.GetUserURLsRequest{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Urls, 2)
+	assert.Equal(t, base, resp.Urls[0].CreatedAt.AsTime())
+	require.NotEmpty(t, resp.NextCursor)
+
+	resp, err = client.GetUserURLs(ctx, &pb.GetUserURLsRequest{Limit: 2, Cursor: resp.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, resp.Urls, 1)
+	assert.Equal(t, "http://google.com?q=pages-grpc-2", resp.Urls[0].OriginalUrl)
+	assert.Empty(t, resp.NextCursor)
+
+	_, err = client.GetUserURLs(ctx, &pb.GetUserURLsRequest{Order: "random"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestGrpcController_GetStats(t *testing.T) {
 	t.Parallel()
 	client, repo, _, cleanup := newGrpcAppInstance()
@@ -221,10 +591,10 @@ func TestGrpcController_CreateBatch(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	tests := []struct {
-		name             string
-		body             []*pb.BatchURL
-		code             codes.Code
-		resultUrlsLength int
+		name     string
+		body     []*pb.BatchURL
+		code     codes.Code
+		statuses []string
 	}{
 		{
 			name: "Batch URL created",
@@ -242,17 +612,16 @@ func TestGrpcController_CreateBatch(t *testing.T) {
 					CorrelationId: "3",
 				},
 			},
-			resultUrlsLength: 3,
-			code:             codes.OK,
+			statuses: []string{service.BatchStatusCreated, service.BatchStatusCreated, service.BatchStatusCreated},
+			code:     codes.OK,
 		},
 		{
-			name:             "Batch URL Bad Request — Emtpy Urls",
-			resultUrlsLength: 0,
-			body:             []*pb.BatchURL{},
-			code:             codes.InvalidArgument,
+			name: "Batch URL Bad Request — Emtpy Urls",
+			body: []*pb.BatchURL{},
+			code: codes.InvalidArgument,
 		},
 		{
-			name: "Batch URL Created — Only With Correlation ID",
+			name: "Batch URL Created — Without Correlation ID Skipped",
 			body: []*pb.BatchURL{
 				&pb.BatchURL{
 					OriginalUrl:   "http://yandex.ru/015432",
@@ -263,13 +632,23 @@ func TestGrpcController_CreateBatch(t *testing.T) {
 					CorrelationId: "",
 				},
 			},
-			resultUrlsLength: 1,
-			code:             codes.OK,
+			statuses: []string{service.BatchStatusCreated, service.BatchStatusSkipped},
+			code:     codes.OK,
+		},
+		{
+			name: "Batch URL Created Partially — Invalid Items Reported",
+			body: []*pb.BatchURL{
+				{OriginalUrl: "http://yandex.ru/123123", CorrelationId: "1"},
+				{OriginalUrl: "not a url", CorrelationId: "2"},
+				{OriginalUrl: "http://yandex.ru/grpc-bad-alias", CorrelationId: "3", Alias: "api"},
+			},
+			statuses: []string{service.BatchStatusExisted, service.BatchStatusInvalid, service.BatchStatusInvalid},
+			code:     codes.OK,
 		},
 	}
 
 	md := metadata.New(map[string]string{
-		"user": "user-uuid-010987",
+		"authorization": authToken("user-uuid-010987"),
 	})
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
@@ -280,7 +659,10 @@ func TestGrpcController_CreateBatch(t *testing.T) {
 			})
 			if tt.code == codes.OK {
 				require.NoError(t, err)
-				assert.Equal(t, tt.resultUrlsLength, len(resp.Urls))
+				require.Len(t, resp.Urls, len(tt.statuses))
+				for i, url := range resp.Urls {
+					assert.Equal(t, tt.statuses[i], url.Status, url.CorrelationId)
+				}
 			} else {
 				errCode, ok := status.FromError(err)
 				assert.True(t, ok)
@@ -321,15 +703,15 @@ func TestGrpcController_GetUserURLs(t *testing.T) {
 	})
 
 	md1 := metadata.New(map[string]string{
-		"user": userID1,
+		"authorization": authToken(userID1),
 	})
 
 	md2 := metadata.New(map[string]string{
-		"user": userID2,
+		"authorization": authToken(userID2),
 	})
 
 	md3 := metadata.New(map[string]string{
-		"user": userID3,
+		"authorization": authToken(userID3),
 	})
 
 	resp, err := client.GetUserURLs(metadata.NewOutgoingContext(context.Background(), md1), &pb.GetUserURLsRequest{})
@@ -386,15 +768,15 @@ func TestGrpcController_DeleteBatch(t *testing.T) {
 	})
 
 	// фиксируем количество урлов у пользователя 1
-	urls, _ := repo.GetByUserUUID(context.Background(), userID1)
+	urls, _ := repo.ListByUser(context.Background(), userID1, storage.ListOptions{})
 
 	require.NotNil(t, urls)
 
-	initialUrlsLength := len(*urls)
+	initialUrlsLength := len(urls)
 
 	// удаляем 2 урла у пользователя 1
 	md := metadata.New(map[string]string{
-		"user": userID1,
+		"authorization": authToken(userID1),
 	})
 
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
@@ -406,11 +788,11 @@ func TestGrpcController_DeleteBatch(t *testing.T) {
 	require.NoError(t, err)
 
 	// получаем урлы, которые остались у пользователя 1
-	urls, _ = repo.GetByUserUUID(context.Background(), userID1)
+	urls, _ = repo.ListByUser(context.Background(), userID1, storage.ListOptions{})
 
 	require.NotNil(t, urls)
 
-	doneUrlsLength := len(*urls)
+	doneUrlsLength := len(urls)
 
 	// должно остаться initialUrlsLength - 2
 	// так как 2 урла удалили
@@ -418,7 +800,7 @@ func TestGrpcController_DeleteBatch(t *testing.T) {
 
 	// удаляем 1 урл у пользователя 2
 	md2 := metadata.New(map[string]string{
-		"user": userID2,
+		"authorization": authToken(userID2),
 	})
 
 	ctx2 := metadata.NewOutgoingContext(context.Background(), md2)
@@ -430,11 +812,11 @@ func TestGrpcController_DeleteBatch(t *testing.T) {
 	require.NoError(t, err)
 
 	// получаем урлы, которые остались у пользователя 2
-	urls, _ = repo.GetByUserUUID(context.Background(), userID2)
+	urls, _ = repo.ListByUser(context.Background(), userID2, storage.ListOptions{})
 
 	require.NotNil(t, urls)
 
-	doneUrlsLength = len(*urls)
+	doneUrlsLength = len(urls)
 
 	// должно остаться 0 урлов
 	// так как был 1 урл и 1 урл удалили
@@ -442,9 +824,136 @@ func TestGrpcController_DeleteBatch(t *testing.T) {
 
 	// проверяем урлы пользователя 3
 	// должно остаться, как и было — 1
-	urls, _ = repo.GetByUserUUID(context.Background(), userID3)
+	urls, _ = repo.ListByUser(context.Background(), userID3, storage.ListOptions{})
 
 	require.NotNil(t, urls)
 
-	assert.Equal(t, len(*urls), 1)
+	assert.Equal(t, len(urls), 1)
+}
+
+func TestGrpcController_Tracing(t *testing.T) {
+	spans := setupTestTracing(t)
+
+	client, repo, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	require.NoError(t, repo.Create(context.Background(), storage.URL{
+		UUID:     "some-uuid-grpc-tracing",
+		Short:    "grpctracing1",
+		Original: "http://google.com/grpc-tracing",
+	}))
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", testTraceparent)
+	_, err := client.Get(ctx, &pb.GetRequest{ShortUrl: "grpctracing1"}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	require.NotEmpty(t, header.Get("traceparent"))
+	assert.Contains(t, header.Get("traceparent")[0], testTraceID)
+
+	recorded := spans()
+	require.Contains(t, recorded, pb.URLService_Get_FullMethodName)
+	require.Contains(t, recorded, "service.FindOriginal")
+
+	server := recorded[pb.URLService_Get_FullMethodName]
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), recorded["service.FindOriginal"].Parent().SpanID())
+}
+
+func TestGrpcController_RequestID(t *testing.T) {
+	client, _, _, cleanup := newGrpcAppInstance()
+	t.Cleanup(cleanup)
+
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "client request id", requestID: "req-grpc-1", keep: true},
+		{name: "no request id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", tt.requestID)
+			}
+
+			var header metadata.MD
+			_, err := client.GetStats(ctx, &pb.GetStatsRequest{}, grpc.Header(&header))
+			require.NoError(t, err)
+
+			values := header.Get("x-request-id")
+			require.Len(t, values, 1)
+			assert.NotEmpty(t, values[0])
+			assert.Equal(t, tt.keep, values[0] == tt.requestID)
+		})
+	}
+}
+
+func TestGrpcController_Health(t *testing.T) {
+	logger.New()
+
+	checker := health.New(health.DefaultTimeout)
+	grpcServer := app.NewGrpcServer(NewGrpcController(nil), newTestAuth(), testAccounts, ratelimit.Limits{}, nil, checker)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	client := healthpb.NewHealthClient(conn)
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		return res.Status
+	}
+
+	// готовность еще не проверялась
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check())
+
+	checker.Ready(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check())
+
+	checker.Shutdown()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check())
+}
+
+func TestClickFromContext(t *testing.T) {
+	cfg := config.New()
+	previous := cfg.TrustedSubnet
+	cfg.TrustedSubnet = "10.0.0.0/8"
+	t.Cleanup(func() { cfg.TrustedSubnet = previous })
+
+	tests := []struct {
+		name   string
+		peer   string
+		realIP string
+		want   string
+	}{
+		{name: "port is dropped", peer: "203.0.113.1:51234", want: "203.0.113.1"},
+		{name: "ipv6 peer", peer: "[2001:db8::1]:51234", want: "2001:db8::1"},
+		{name: "trusted proxy", peer: "10.0.0.5:51234", realIP: "198.51.100.7", want: "198.51.100.7"},
+		{name: "untrusted client sets header", peer: "203.0.113.1:51234", realIP: "198.51.100.7", want: "203.0.113.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tt.peer)
+			require.NoError(t, err)
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-real-ip", tt.realIP))
+			}
+
+			assert.Equal(t, tt.want, clickFromContext(ctx, "short").IP)
+		})
+	}
 }
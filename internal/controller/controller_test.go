@@ -4,37 +4,88 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/augustjourney/urlshrt/internal/storage/inmemory"
 	"github.com/google/uuid"
 
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	accountsInmemory "github.com/augustjourney/urlshrt/internal/accounts/inmemory"
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	analyticsInmemory "github.com/augustjourney/urlshrt/internal/analytics/inmemory"
 	"github.com/augustjourney/urlshrt/internal/app"
+	"github.com/augustjourney/urlshrt/internal/auth"
 	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/health"
 	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/metrics"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
+	"github.com/augustjourney/urlshrt/internal/screening"
 	"github.com/augustjourney/urlshrt/internal/service"
 	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/augustjourney/urlshrt/internal/storage/instrumented"
+	"github.com/augustjourney/urlshrt/internal/tracing"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
+// аккаунты сервисных клиентов, общие для всех тестовых приложений
+var testAccounts = accounts.New(accountsInmemory.New())
+
+// ключ подписи токенов в тестах
+const testAuthSecret = "test-auth-secret"
+
+func newTestAuth() *auth.Manager {
+	manager, err := auth.New(testAuthSecret, nil, 0)
+	if err != nil {
+		panic(err)
+	}
+	return manager
+}
+
+// выпускает токен для пользователя тем же ключом, что и тестовое приложение
+func authToken(user string) string {
+	token, err := newTestAuth().Issue(user)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// тестовые запросы приходят с 0.0.0.0 — доверяем ему как прокси, чтобы адрес клиента брался из X-Real-IP
+func trustTestProxy(t *testing.T) {
+	cfg := config.New()
+	previous := cfg.TrustedSubnet
+	cfg.TrustedSubnet = "0.0.0.0/32"
+	t.Cleanup(func() { cfg.TrustedSubnet = previous })
+}
+
 func newAppInstance() (*fiber.App, storage.IRepo, service.Service) {
 	cfg := config.New()
 	logger.New()
 
 	repo := inmemory.New()
-	urlService := service.New(repo, cfg)
-	controller := NewHTTPController(&urlService)
+	tracker := analytics.NewTracker(analyticsInmemory.New(), 100, 10*time.Millisecond)
+	tracker.Start()
+	urlService := service.New(repo, cfg, service.WithAnalytics(tracker), service.WithUserDirectory(testAccounts))
+	controller := NewHTTPController(&urlService, newTestAuth(), testAccounts)
 
-	httpServer := app.NewHTTPServer(controller, nil)
+	httpServer := app.NewHTTPServer(controller, repo, ratelimit.Limits{}, newTestAuth(), nil, nil)
 
 	return httpServer, repo, urlService
 }
@@ -144,6 +195,320 @@ func TestGetURL(t *testing.T) {
 	}
 }
 
+func TestGetURLExpired(t *testing.T) {
+	app, repo, _ := newAppInstance()
+
+	expired := time.Now().Add(-time.Minute)
+	alive := time.Now().Add(time.Hour)
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:      "some-uuid-expired",
+		Short:     "expired1",
+		Original:  "http://google.com/expired",
+		ExpiresAt: &expired,
+	})
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:      "some-uuid-alive",
+		Short:     "alive1",
+		Original:  "http://google.com/alive",
+		ExpiresAt: &alive,
+	})
+
+	tests := []struct {
+		name     string
+		code     int
+		shortURL string
+	}{
+		{
+			name:     "Expired url",
+			code:     http.StatusGone,
+			shortURL: "expired1",
+		},
+		{
+			name:     "Not yet expired url",
+			code:     http.StatusTemporaryRedirect,
+			shortURL: "alive1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.shortURL, nil)
+			res, err := app.Test(req, 10)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+		})
+	}
+
+	deleted, err := repo.DeleteExpired(context.TODO(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	// после удаления фоновой задачей ссылка все так же считается истекшей
+	req := httptest.NewRequest(http.MethodGet, "/expired1", nil)
+	res, err := app.Test(req, 10)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusGone, res.StatusCode)
+}
+
+func TestApiCreateURLWithExpiration(t *testing.T) {
+	app, _, _ := newAppInstance()
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		body APICreateURLBody
+		code int
+	}{
+		{
+			name: "URL created with ttl",
+			body: APICreateURLBody{URL: "http://yandex.ru/ttl", TTL: 60},
+			code: http.StatusCreated,
+		},
+		{
+			name: "URL created with expires_at",
+			body: APICreateURLBody{URL: "http://yandex.ru/expires-at", ExpiresAt: &future},
+			code: http.StatusCreated,
+		},
+		{
+			name: "Expiration in the past",
+			body: APICreateURLBody{URL: "http://yandex.ru/past", ExpiresAt: &past},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Both ttl and expires_at",
+			body: APICreateURLBody{URL: "http://yandex.ru/both", ExpiresAt: &future, TTL: 60},
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			result, err := app.Test(request, 100)
+			require.NoError(t, err)
+			result.Body.Close()
+
+			assert.Equal(t, tt.code, result.StatusCode)
+		})
+	}
+}
+
+func TestGetURLStats(t *testing.T) {
+	app, repo, _ := newAppInstance()
+	trustTestProxy(t)
+
+	owner := "user-stats-owner"
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-stats",
+		Short:    "stats1",
+		Original: "http://google.com/stats",
+		UserUUID: owner,
+	})
+
+	clicks := []struct {
+		referer string
+		ip      string
+	}{
+		{referer: "http://ya.ru", ip: "10.0.0.1"},
+		{referer: "http://ya.ru", ip: "10.0.0.2"},
+		{referer: "http://vk.com", ip: "10.0.0.1"},
+	}
+
+	for _, click := range clicks {
+		req := httptest.NewRequest(http.MethodGet, "/stats1", nil)
+		req.Header.Set("Referer", click.referer)
+		req.Header.Set("X-Real-IP", click.ip)
+		res, err := app.Test(req, 10)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+	}
+
+	getStats := func(user string) (*http.Response, analytics.LinkStats) {
+		var stats analytics.LinkStats
+		req := httptest.NewRequest(http.MethodGet, "/api/user/urls/stats1/stats", nil)
+		if user != "" {
+			req.Header.Set("Authorization", authToken(user))
+		}
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&stats))
+		}
+		return res, stats
+	}
+
+	// переходы сохраняются асинхронно
+	assert.Eventually(t, func() bool {
+		_, stats := getStats(owner)
+		return stats.Clicks == len(clicks)
+	}, time.Second, 20*time.Millisecond)
+
+	res, stats := getStats(owner)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, stats.UniqueVisitors)
+	require.Len(t, stats.Referers, 2)
+	assert.Equal(t, analytics.RefererStats{Referer: "http://ya.ru", Clicks: 2}, stats.Referers[0])
+	assert.NotNil(t, stats.LastClickAt)
+
+	res, _ = getStats("another-user")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, _ = getStats("")
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestApiUpdateURL(t *testing.T) {
+	app, repo, _ := newAppInstance()
+
+	owner := "user-update-owner"
+	heir := "user-update-heir"
+
+	mustCreate := func(url storage.URL) {
+		require.NoError(t, repo.Create(context.TODO(), url))
+	}
+	mustCreate(storage.URL{UUID: "uuid-update-1", Short: "update1", Original: "http://google.com/update", UserUUID: owner})
+	mustCreate(storage.URL{UUID: "uuid-update-2", Short: "update2", Original: "http://google.com/update-taken", UserUUID: owner})
+	mustCreate(storage.URL{UUID: "uuid-update-3", Short: "update3", Original: "http://google.com/update-heir", UserUUID: heir})
+
+	tests := []struct {
+		name     string
+		user     string
+		short    string
+		body     string
+		code     int
+		original string
+	}{
+		{name: "without auth", short: "update1", body: `{"original_url": "http://google.com/moved"}`, code: http.StatusUnauthorized},
+		{name: "not an owner", user: heir, short: "update1", body: `{"original_url": "http://google.com/moved"}`, code: http.StatusNotFound},
+		{name: "unknown url", user: owner, short: "unknown", body: `{"original_url": "http://google.com/moved"}`, code: http.StatusNotFound},
+		{name: "nothing to update", user: owner, short: "update1", body: `{}`, code: http.StatusBadRequest},
+		{name: "invalid url", user: owner, short: "update1", body: `{"original_url": "ftp://google.com"}`, code: http.StatusBadRequest},
+		{name: "url of another link", user: owner, short: "update1", body: `{"original_url": "http://google.com/update-taken"}`, code: http.StatusConflict},
+		{name: "change destination", user: owner, short: "update1", body: `{"original_url": "HTTP://Google.com/moved"}`, code: http.StatusOK, original: "http://google.com/moved"},
+		{name: "unknown user", user: owner, short: "update1", body: `{"user_id": "user-update-stranger"}`, code: http.StatusBadRequest},
+		{name: "transfer", user: owner, short: "update1", body: `{"user_id": "` + heir + `"}`, code: http.StatusOK, original: "http://google.com/moved"},
+		{name: "former owner", user: owner, short: "update1", body: `{"user_id": "` + owner + `"}`, code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/"+tt.short, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.user != "" {
+				req.Header.Set("Authorization", authToken(tt.user))
+			}
+			res, err := app.Test(req, 100)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.code, res.StatusCode)
+
+			if tt.code == http.StatusOK {
+				var result service.UserURLResult
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+				assert.Equal(t, tt.original, result.OriginalURL)
+				assert.True(t, strings.HasSuffix(result.ShortURL, "/"+tt.short))
+			}
+		})
+	}
+
+	// короткий адрес прежний, а ведет по новому адресу
+	req := httptest.NewRequest(http.MethodGet, "/update1", nil)
+	res, err := app.Test(req, 10)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, "http://google.com/moved", res.Header.Get("Location"))
+
+	changes, err := repo.GetChanges(context.TODO(), "update1")
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, owner, changes[1].OldUserUUID)
+	assert.Equal(t, heir, changes[1].NewUserUUID)
+}
+
+func TestGetURLChanges(t *testing.T) {
+	app, repo, _ := newAppInstance()
+
+	owner := "user-history-owner"
+	account, err := testAccounts.CreateAccount(context.Background(), "history-heir")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(context.TODO(), storage.URL{UUID: "uuid-history-1", Short: "history1", Original: "http://google.com/history", UserUUID: owner}))
+
+	request := func(method string, target string, user string, body string) *http.Response {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("Authorization", authToken(user))
+		}
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		return res
+	}
+
+	// ссылка без изменений — пустой журнал
+	res := request(http.MethodGet, "/api/user/urls/history1/history", owner, "")
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `[]`, string(body))
+
+	res = request(http.MethodPatch, "/api/user/urls/history1", owner, `{"original_url": "http://google.com/history-moved"}`)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// ссылку можно передать аккаунту, у которого еще нет ссылок
+	res = request(http.MethodPatch, "/api/user/urls/history1", owner, `{"user_id": "`+account.ID+`"}`)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	tests := []struct {
+		name  string
+		user  string
+		short string
+		code  int
+	}{
+		{name: "without auth", short: "history1", code: http.StatusUnauthorized},
+		{name: "former owner", user: owner, short: "history1", code: http.StatusNotFound},
+		{name: "unknown url", user: account.ID, short: "unknown", code: http.StatusNotFound},
+		{name: "owner", user: account.ID, short: "history1", code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := request(http.MethodGet, "/api/user/urls/"+tt.short+"/history", tt.user, "")
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.code, res.StatusCode)
+
+			if tt.code == http.StatusOK {
+				var changes []storage.URLChange
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&changes))
+				require.Len(t, changes, 2)
+				assert.Equal(t, "http://google.com/history", changes[0].OldOriginal)
+				assert.Equal(t, "http://google.com/history-moved", changes[0].NewOriginal)
+				assert.Equal(t, owner, changes[1].ChangedBy)
+				assert.Equal(t, owner, changes[1].OldUserUUID)
+				assert.Equal(t, account.ID, changes[1].NewUserUUID)
+			}
+		})
+	}
+}
+
 func BenchmarkGetURL(b *testing.B) {
 	app, repo, _ := newAppInstance()
 
@@ -197,6 +562,33 @@ func TestCreateURL(t *testing.T) {
 			originalURL: "http://yandex.ru",
 			method:      http.MethodPost,
 		},
+		{
+			name: "URL conflict after normalization",
+			want: want{
+				code:        http.StatusConflict,
+				contentType: "text/plain",
+			},
+			originalURL: "HTTP://Yandex.RU:80/",
+			method:      http.MethodPost,
+		},
+		{
+			name: "Scheme not allowed",
+			want: want{
+				code:        http.StatusBadRequest,
+				contentType: "text/plain",
+			},
+			originalURL: "javascript:alert(1)",
+			method:      http.MethodPost,
+		},
+		{
+			name: "Not a URL",
+			want: want{
+				code:        http.StatusBadRequest,
+				contentType: "text/plain",
+			},
+			originalURL: "just some text",
+			method:      http.MethodPost,
+		},
 		{
 			name: "Wront HTTP method",
 			want: want{
@@ -230,13 +622,162 @@ func TestCreateURL(t *testing.T) {
 			require.NoError(t, err)
 			assert.Equal(t, tt.want.code, result.StatusCode)
 			assert.Equal(t, tt.want.contentType, result.Header.Get("Content-Type"))
-			if tt.method == http.MethodPost && tt.originalURL != "" {
+			if tt.want.code != http.StatusBadRequest {
 				assert.Equal(t, true, shortMatch)
 			}
 		})
 	}
 }
 
+// генератор, который выдает коды по очереди — для проверки повторов при коллизиях
+type stubGenerator struct {
+	codes []string
+	calls int
+}
+
+func (g *stubGenerator) Generate(ctx context.Context, original string, attempt int) (string, error) {
+	code := g.codes[g.calls%len(g.codes)]
+	g.calls++
+	return code, nil
+}
+
+func TestCreateURLShortCodeCollision(t *testing.T) {
+	cfg := config.New()
+	logger.New()
+
+	repo := inmemory.New()
+	generator := &stubGenerator{codes: []string{"taken1", "ping", "free1"}}
+	urlService := service.New(repo, cfg, service.WithGenerator(generator))
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), nil, nil)
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-taken",
+		Short:    "taken1",
+		Original: "http://google.com/taken",
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("http://google.com/collision"))
+	result, err := app.Test(request, 100)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(result.Body)
+	result.Body.Close()
+	require.NoError(t, err)
+
+	// занятый код и зарезервированный адрес пропускаются
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.True(t, strings.HasSuffix(string(body), "/free1"))
+	assert.Equal(t, 3, generator.calls)
+}
+
+func TestApiCreateURLBatchFailed(t *testing.T) {
+	cfg := config.New()
+	logger.New()
+
+	repo := inmemory.New()
+	// генератор все время выдает занятый код — попытки сохранить ссылку заканчиваются
+	generator := &stubGenerator{codes: []string{"taken2"}}
+	urlService := service.New(repo, cfg, service.WithGenerator(generator))
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), nil, nil)
+
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-taken2",
+		Short:    "taken2",
+		Original: "http://google.com/taken2",
+	})
+
+	body := `[{"original_url": "http://google.com/batch-failed", "correlation_id": "1"}]`
+	request := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(body))
+	result, err := app.Test(request, 100)
+	require.NoError(t, err)
+
+	var resultBody []service.BatchResultURL
+	err = json.NewDecoder(result.Body).Decode(&resultBody)
+	result.Body.Close()
+	require.NoError(t, err)
+
+	// внутренняя ошибка не выдается за неверную или пропущенную ссылку
+	assert.Equal(t, http.StatusInternalServerError, result.StatusCode)
+	require.Len(t, resultBody, 1)
+	assert.Equal(t, service.BatchStatusFailed, resultBody[0].Status)
+	assert.Equal(t, service.ErrInternalError.Error(), resultBody[0].Error)
+}
+
+// хранилище, которое, как postgres, проверяет занятость короткого адреса раньше оригинального
+type shortFirstRepo struct {
+	storage.IRepo
+}
+
+func (r shortFirstRepo) Create(ctx context.Context, url storage.URL) error {
+	existing, err := r.IRepo.Get(ctx, url.Short)
+	if err == nil && existing.Original != "" {
+		return storage.ErrShortAlreadyExists
+	}
+	return r.IRepo.Create(ctx, url)
+}
+
+func TestCreateURLExistingShortConflict(t *testing.T) {
+	cfg := config.New()
+	logger.New()
+
+	repo := shortFirstRepo{IRepo: inmemory.New()}
+	// код зависит только от ссылки — повторные попытки упираются в тот же код
+	generator := &stubGenerator{codes: []string{"same1"}}
+	urlService := service.New(repo, cfg, service.WithGenerator(generator))
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), nil, nil)
+
+	shorten := func() (int, string) {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("http://google.com/existing"))
+		result, err := app.Test(request, 100)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(result.Body)
+		result.Body.Close()
+		require.NoError(t, err)
+
+		return result.StatusCode, string(body)
+	}
+
+	status, created := shorten()
+	require.Equal(t, http.StatusCreated, status)
+
+	// код из хэша той же ссылки занят ею самой — это повторное сокращение, а не коллизия
+	status, existing := shorten()
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, created, existing)
+}
+
+// хранилище, которое всегда недоступно
+type downPinger struct{}
+
+func (downPinger) Ping(ctx context.Context) error {
+	return fmt.Errorf("storage is down")
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name   string
+		pinger app.Pinger
+		status int
+	}{
+		{name: "storage is up", pinger: inmemory.New(), status: http.StatusOK},
+		{name: "storage is down", pinger: downPinger{}, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urlService := service.New(inmemory.New(), config.New())
+			app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), tt.pinger, ratelimit.Limits{}, newTestAuth(), nil, nil)
+
+			result, err := app.Test(httptest.NewRequest(http.MethodGet, "/ping", nil), 100)
+			require.NoError(t, err)
+			defer result.Body.Close()
+
+			assert.Equal(t, tt.status, result.StatusCode)
+		})
+	}
+}
+
 func BenchmarkCreateURL(b *testing.B) {
 	app, _, _ := newAppInstance()
 
@@ -329,45 +870,158 @@ func TestApiCreateURL(t *testing.T) {
 	}
 }
 
-func BenchmarkApiCreateURL(b *testing.B) {
-	app, _, _ := newAppInstance()
-
-	b.Run("create url", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			originalURL, err := uuid.NewRandom()
-			require.NoError(b, err)
-			body, _ := json.Marshal(APICreateURLBody{
-				URL: originalURL.String(),
-			})
-			request := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(body))
-			result, err := app.Test(request, 100)
-			require.NoError(b, err)
-			result.Body.Close()
-		}
-	})
-}
-
-func TestApiCreateURLBatch(t *testing.T) {
+func TestApiCreateURLWithAlias(t *testing.T) {
 	app, _, _ := newAppInstance()
 
 	type want struct {
-		code             int
-		contentType      string
-		resultUrlsLength int
+		code   int
+		result string
+		error  string
 	}
 
 	tests := []struct {
-		name string
-		body string
-		want want
+		name        string
+		want        want
+		originalURL string
+		alias       string
 	}{
 		{
-			name: "Batch URL created",
-			body: `[{
-					"original_url": "http://yandex.ru/123123",
-					"correlation_id": "1"
-				},
-					{
+			name: "URL created with alias",
+			want: want{
+				code:   http.StatusCreated,
+				result: "/q4-report",
+			},
+			originalURL: "http://yandex.ru/q4-report",
+			alias:       "q4-report",
+		},
+		{
+			name: "Same URL with same alias",
+			want: want{
+				code:   http.StatusConflict,
+				result: "/q4-report",
+			},
+			originalURL: "http://yandex.ru/q4-report",
+			alias:       "q4-report",
+		},
+		{
+			name: "Alias is taken by another URL",
+			want: want{
+				code:  http.StatusConflict,
+				error: service.ErrAliasTaken.Error(),
+			},
+			originalURL: "http://yandex.ru/q3-report",
+			alias:       "q4-report",
+		},
+		{
+			name: "Alias with invalid characters",
+			want: want{
+				code:  http.StatusBadRequest,
+				error: service.ErrInvalidAlias.Error(),
+			},
+			originalURL: "http://yandex.ru/q2-report",
+			alias:       "q2 report!",
+		},
+		{
+			name: "Reserved alias",
+			want: want{
+				code:  http.StatusBadRequest,
+				error: service.ErrInvalidAlias.Error(),
+			},
+			originalURL: "http://yandex.ru/q1-report",
+			alias:       "API",
+		},
+		{
+			name: "Liveness probe alias",
+			want: want{
+				code:  http.StatusBadRequest,
+				error: service.ErrInvalidAlias.Error(),
+			},
+			originalURL: "http://yandex.ru/q1-report",
+			alias:       "healthz",
+		},
+		{
+			name: "Readiness probe alias",
+			want: want{
+				code:  http.StatusBadRequest,
+				error: service.ErrInvalidAlias.Error(),
+			},
+			originalURL: "http://yandex.ru/q1-report",
+			alias:       "ReadyZ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(APICreateURLBody{
+				URL:   tt.originalURL,
+				Alias: tt.alias,
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			result, err := app.Test(request, 100)
+			require.NoError(t, err)
+
+			var resultBody struct {
+				APICreateURLResult
+				APIErrorResult
+			}
+
+			err = json.NewDecoder(result.Body).Decode(&resultBody)
+			result.Body.Close()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.error, resultBody.Error)
+
+			if tt.want.result != "" {
+				assert.True(t, strings.HasSuffix(resultBody.Result, tt.want.result))
+			}
+		})
+	}
+}
+
+func BenchmarkApiCreateURL(b *testing.B) {
+	app, _, _ := newAppInstance()
+
+	b.Run("create url", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			originalURL, err := uuid.NewRandom()
+			require.NoError(b, err)
+			body, _ := json.Marshal(APICreateURLBody{
+				URL: originalURL.String(),
+			})
+			request := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(body))
+			result, err := app.Test(request, 100)
+			require.NoError(b, err)
+			result.Body.Close()
+		}
+	})
+}
+
+func TestApiCreateURLBatch(t *testing.T) {
+	app, _, _ := newAppInstance()
+
+	type want struct {
+		code        int
+		contentType string
+		// статусы ссылок в порядке запроса
+		statuses []string
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want want
+	}{
+		{
+			name: "Batch URL created",
+			body: `[{
+					"original_url": "http://yandex.ru/123123",
+					"correlation_id": "1"
+				},
+					{
 					"original_url": "http://vk.com/12322222",
 					"correlation_id": "2"
 				},
@@ -376,9 +1030,9 @@ func TestApiCreateURLBatch(t *testing.T) {
 					"correlation_id": "3"
 				}]`,
 			want: want{
-				contentType:      "application/json",
-				code:             http.StatusCreated,
-				resultUrlsLength: 3,
+				contentType: "application/json",
+				code:        http.StatusCreated,
+				statuses:    []string{service.BatchStatusCreated, service.BatchStatusCreated, service.BatchStatusCreated},
 			},
 		},
 		{
@@ -393,13 +1047,12 @@ func TestApiCreateURLBatch(t *testing.T) {
 					"correlation_id": "2"
 				},]`,
 			want: want{
-				contentType:      "application/json",
-				code:             http.StatusBadRequest,
-				resultUrlsLength: 0,
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
 			},
 		},
 		{
-			name: "Batch URL Created — Only With Correlation ID",
+			name: "Batch URL Created — Without Correlation ID Skipped",
 			body: `[
 				{
 					"original_url": "http://yandex.ru/123",
@@ -410,9 +1063,83 @@ func TestApiCreateURLBatch(t *testing.T) {
 				}
 			]`,
 			want: want{
-				contentType:      "application/json",
-				code:             http.StatusCreated,
-				resultUrlsLength: 1,
+				contentType: "application/json",
+				code:        http.StatusCreated,
+				statuses:    []string{service.BatchStatusCreated, service.BatchStatusSkipped},
+			},
+		},
+		{
+			name: "Batch URL Created — Already Existing Reported",
+			body: `[
+				{
+					"original_url": "http://yandex.ru/123123",
+					"correlation_id": "1"
+				},
+				{
+					"original_url": "http://vk.com/new-in-batch",
+					"correlation_id": "2"
+				}
+			]`,
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusCreated,
+				statuses:    []string{service.BatchStatusExisted, service.BatchStatusCreated},
+			},
+		},
+		{
+			name: "Batch URL Created Partially — Invalid Items Reported",
+			body: `[
+				{
+					"original_url": "javascript alert",
+					"correlation_id": "1"
+				},
+				{
+					"original_url": "http://vk.com/batch-alias-1",
+					"correlation_id": "2",
+					"alias": "batch-alias"
+				},
+				{
+					"original_url": "http://vk.com/batch-alias-2",
+					"correlation_id": "3",
+					"alias": "batch-alias"
+				},
+				{
+					"original_url": "http://vk.com/batch-alias-3",
+					"correlation_id": "4",
+					"alias": "ping"
+				},
+				{
+					"original_url": "http://vk.com/batch-valid",
+					"correlation_id": "5"
+				}
+			]`,
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusCreated,
+				statuses: []string{
+					service.BatchStatusInvalid,
+					service.BatchStatusCreated,
+					service.BatchStatusInvalid,
+					service.BatchStatusInvalid,
+					service.BatchStatusCreated,
+				},
+			},
+		},
+		{
+			name: "Batch URL Bad Request — Nothing Created",
+			body: `[
+				{
+					"original_url": "javascript alert",
+					"correlation_id": "1"
+				},
+				{
+					"original_url": "http://vk.com/nothing-created"
+				}
+			]`,
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				statuses:    []string{service.BatchStatusInvalid, service.BatchStatusSkipped},
 			},
 		},
 	}
@@ -436,14 +1163,223 @@ func TestApiCreateURLBatch(t *testing.T) {
 			assert.Equal(t, tt.want.code, result.StatusCode)
 			assert.Equal(t, tt.want.contentType, result.Header.Get("Content-Type"), fmt.Sprintf("Content Type should be %s", tt.want.contentType))
 
-			if result.StatusCode == http.StatusCreated {
+			if tt.want.statuses != nil {
 				require.NoError(t, err)
-				assert.Equal(t, tt.want.resultUrlsLength, len(resultBody))
+				require.Len(t, resultBody, len(tt.want.statuses))
+
+				for i, item := range resultBody {
+					assert.Equal(t, tt.want.statuses[i], item.Status, item.CorrelationID)
+
+					switch item.Status {
+					case service.BatchStatusCreated, service.BatchStatusExisted:
+						assert.NotEmpty(t, item.ShortURL)
+						assert.Empty(t, item.Error)
+					default:
+						assert.Empty(t, item.ShortURL)
+						assert.NotEmpty(t, item.Error)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserURLsAuth(t *testing.T) {
+	app, _, _ := newAppInstance()
+
+	// Первый запрос без токена — сервис выдает новый токен в куке и заголовке
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("http://google.com/auth-owner"))
+	res, err := app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	token := res.Header.Get("Authorization")
+	require.NotEmpty(t, token)
+
+	var cookie *http.Cookie
+	for _, c := range res.Cookies() {
+		if c.Name == "user" {
+			cookie = c
+		}
+	}
+	require.NotNil(t, cookie)
+	assert.Equal(t, token, cookie.Value)
+	assert.True(t, cookie.HttpOnly)
+
+	owner, err := newTestAuth().Verify(token)
+	require.NoError(t, err)
+
+	forger, err := auth.New("another-secret", nil, 0)
+	require.NoError(t, err)
+	forged, err := forger.Issue(owner)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        string
+		code          int
+	}{
+		{name: "Token in header", authorization: token, code: http.StatusOK},
+		{name: "Bearer token in header", authorization: "Bearer " + token, code: http.StatusOK},
+		{name: "Token in cookie", cookie: token, code: http.StatusOK},
+		{name: "Raw user id", authorization: owner, code: http.StatusUnauthorized},
+		{name: "Raw user id in cookie", cookie: owner, code: http.StatusUnauthorized},
+		{name: "Token signed with another secret", authorization: forged, code: http.StatusUnauthorized},
+		{name: "No token", code: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "user", Value: tt.cookie})
+			}
+			res, err := app.Test(req, 100)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+		})
+	}
+}
+
+func TestCreateURLWithInvalidToken(t *testing.T) {
+	app, _, _ := newAppInstance()
+
+	forger, err := auth.New("another-secret", nil, 0)
+	require.NoError(t, err)
+	forged, err := forger.Issue("user-forged")
+	require.NoError(t, err)
+
+	shortLived, err := auth.New(testAuthSecret, nil, time.Nanosecond)
+	require.NoError(t, err)
+	expired, err := shortLived.Issue("user-expired")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        string
+		code          int
+	}{
+		{name: "No token", code: http.StatusCreated},
+		{name: "Valid token", authorization: authToken("user-valid"), code: http.StatusCreated},
+		{name: "Token signed with another secret", authorization: forged, code: http.StatusUnauthorized},
+		{name: "Tampered token in cookie", cookie: forged, code: http.StatusUnauthorized},
+		{name: "Expired token", authorization: expired, code: http.StatusUnauthorized},
+		{name: "Garbage token", authorization: "not-a-token", code: http.StatusUnauthorized},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf("http://google.com/invalid-token-%d", i)))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "user", Value: tt.cookie})
+			}
+			res, err := app.Test(req, 100)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+
+			// для недействительного токена новый пользователь не создается — как и в grpc
+			if tt.code == http.StatusUnauthorized {
+				assert.Empty(t, res.Header.Get("Authorization"))
+				assert.Empty(t, res.Cookies())
 			}
 		})
 	}
 }
 
+func TestAPIKeyAccount(t *testing.T) {
+	app, repo, _ := newAppInstance()
+	// админские методы вызываются напрямую из доверенной подсети
+	trustTestProxy(t)
+
+	doRequest := func(method, url, body, apiKey string) *http.Response {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := doRequest(http.MethodPost, "/api/internal/accounts", `{"name": "backend-jobs"}`, "")
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var account accounts.Account
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&account))
+	res.Body.Close()
+
+	res = doRequest(http.MethodPost, "/api/internal/accounts/"+account.ID+"/keys", "", "")
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var key APICreateKeyResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&key))
+	res.Body.Close()
+	require.True(t, accounts.IsAPIKey(key.Key))
+	assert.True(t, strings.HasPrefix(key.Key, key.Prefix))
+
+	batch := `[{"original_url": "http://google.com/api-key-1", "correlation_id": "1"}]`
+
+	res = doRequest(http.MethodPost, "/api/shorten/batch", batch, key.Key)
+	res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	// Клиенту с ключом токен пользователя не выдается
+	assert.Empty(t, res.Header.Get("Authorization"))
+
+	// Ссылка принадлежит аккаунту
+	urls, err := repo.ListByUser(context.TODO(), account.ID, storage.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+
+	res = doRequest(http.MethodDelete, "/api/internal/keys/"+key.ID, "", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res = doRequest(http.MethodPost, "/api/shorten/batch", batch, key.Key)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = doRequest(http.MethodGet, "/api/user/urls", "", key.Key)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = doRequest(http.MethodPost, "/api/internal/accounts/unknown-account/keys", "", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res = doRequest(http.MethodDelete, "/api/internal/keys/unknown-key", "", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// Клиент за прокси не из доверенной подсети
+	req := httptest.NewRequest(http.MethodPost, "/api/internal/accounts", strings.NewReader(`{"name": "x"}`))
+	req.Header.Set("X-Real-IP", "203.0.113.1")
+	res, err = app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// Без доверенной подсети админские методы закрыты, что бы ни было в заголовке
+	config.New().TrustedSubnet = ""
+	req = httptest.NewRequest(http.MethodPost, "/api/internal/accounts", strings.NewReader(`{"name": "x"}`))
+	req.Header.Set("X-Real-IP", "127.0.0.1")
+	res, err = app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
 func TestApiDeleteBatch(t *testing.T) {
 	app, _, urlsService := newAppInstance()
 
@@ -480,10 +1416,10 @@ func TestApiDeleteBatch(t *testing.T) {
 
 	userID := "user-123"
 
-	urlsToStore, err := urlsService.ShortenBatch(batch2, userID)
+	urlsToStore, err := urlsService.ShortenBatch(context.TODO(), batch2, userID)
 	require.NoError(t, err)
 
-	urlsToDelete, err := urlsService.ShortenBatch(batch1, userID)
+	urlsToDelete, err := urlsService.ShortenBatch(context.TODO(), batch1, userID)
 	require.NoError(t, err)
 
 	// Сейчас созданы все необходимые урлы
@@ -505,7 +1441,7 @@ func TestApiDeleteBatch(t *testing.T) {
 
 	request := httptest.NewRequest(http.MethodDelete, url, bytes.NewReader(requestBody))
 
-	request.Header.Set("authorization", userID)
+	request.Header.Set("authorization", authToken(userID))
 
 	request.Header.Set("Content-Type", "application/json")
 
@@ -537,3 +1473,494 @@ func TestApiDeleteBatch(t *testing.T) {
 	_ = result.Body.Close()
 
 }
+
+func TestBlocklist(t *testing.T) {
+	logger.New()
+
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(blocklist, []byte("evil.com\n*.bad.org\n"), 0666))
+
+	screener, err := screening.New(blocklist)
+	require.NoError(t, err)
+
+	repo := inmemory.New()
+	urlService := service.New(repo, config.New(), service.WithScreener(screener))
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), nil, nil)
+
+	// ссылка сокращена до того, как хост попал в список
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-blocked",
+		Short:    "blocked1",
+		Original: "http://evil.com/old",
+	})
+
+	// удаленные ссылки не отключаются
+	repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-blocked-deleted",
+		Short:    "blocked2",
+		Original: "http://evil.com/deleted",
+		UserUUID: "user-blocked",
+	})
+	require.NoError(t, repo.Delete(context.TODO(), []string{"blocked2"}, "user-blocked"))
+
+	doRequest := func(method, url, body, ip string) *http.Response {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ip != "" {
+			req.Header.Set("X-Real-IP", ip)
+		}
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := doRequest(http.MethodPost, "/", "http://EVIL.com/new", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = doRequest(http.MethodPost, "/api/shorten", `{"url": "https://login.bad.org"}`, "")
+	var apiErr APIErrorResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&apiErr))
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, service.ReasonBlocked, apiErr.Reason)
+
+	// поддомены запрещены, сам домен — нет
+	res = doRequest(http.MethodPost, "/api/shorten", `{"url": "https://bad.org"}`, "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	res = doRequest(http.MethodGet, "/blocked1", "", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+
+	// без доверенной подсети отключить ссылки нельзя
+	res = doRequest(http.MethodPost, "/api/internal/screening/disable", "", "127.0.0.1")
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	trustTestProxy(t)
+
+	res = doRequest(http.MethodPost, "/api/internal/screening/disable", "", "")
+	var disabled APIDisableBlockedResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&disabled))
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 1, disabled.Disabled)
+	require.Len(t, disabled.ShortURLs, 1)
+	assert.True(t, strings.HasSuffix(disabled.ShortURLs[0], "/blocked1"))
+
+	res = doRequest(http.MethodGet, "/blocked1", "", "")
+	res.Body.Close()
+	assert.Equal(t, http.StatusGone, res.StatusCode)
+
+	// повторный вызов уже отключенные ссылки не трогает
+	res = doRequest(http.MethodPost, "/api/internal/screening/disable", "", "")
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&disabled))
+	res.Body.Close()
+	assert.Equal(t, 0, disabled.Disabled)
+}
+
+func TestDisableBlockedWithoutBlocklist(t *testing.T) {
+	app, _, _ := newAppInstance()
+
+	trustTestProxy(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/internal/screening/disable", nil)
+	res, err := app.Test(req, 100)
+	require.NoError(t, err)
+
+	var apiErr APIErrorResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&apiErr))
+	res.Body.Close()
+
+	// без списка отключать нечего — это ошибка настройки, а не пустой результат
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+	assert.Equal(t, service.ErrNoBlocklist.Error(), apiErr.Error)
+}
+
+func TestApiRestoreBatch(t *testing.T) {
+	app, repo, _ := newAppInstance()
+
+	owner := "user-restore-owner"
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+
+	for _, url := range []storage.URL{
+		{UUID: "uuid-restore-1", Short: "restore1", Original: "http://google.com/restore-1", UserUUID: owner},
+		{UUID: "uuid-restore-2", Short: "restore2", Original: "http://google.com/restore-2", UserUUID: owner, ExpiresAt: &longAgo},
+		{UUID: "uuid-restore-3", Short: "restore3", Original: "http://google.com/restore-3", UserUUID: "user-restore-another"},
+	} {
+		require.NoError(t, repo.Create(context.TODO(), url))
+	}
+
+	require.NoError(t, repo.Delete(context.TODO(), []string{"restore1"}, owner))
+	require.NoError(t, repo.Delete(context.TODO(), []string{"restore3"}, "user-restore-another"))
+
+	// удалена раньше срока хранения — восстановить нельзя
+	_, err := repo.DeleteExpired(context.TODO(), longAgo)
+	require.NoError(t, err)
+
+	doRestore := func(user string, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/urls/restore", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("Authorization", authToken(user))
+		}
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := doRestore("", `["restore1"]`)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = doRestore(owner, `not json`)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res = doRestore(owner, `["restore1", "restore2", "restore3", "unknown"]`)
+	var result APIRestoreResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, result.Restored, 1)
+	assert.True(t, strings.HasSuffix(result.Restored[0], "/restore1"))
+
+	for short, code := range map[string]int{
+		"restore1": http.StatusTemporaryRedirect,
+		"restore3": http.StatusGone,
+	} {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/"+short, nil), 10)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, code, res.StatusCode, short)
+	}
+}
+
+func TestGetUserURLsPagination(t *testing.T) {
+	app, repo, _ := newAppInstance()
+
+	owner := "user-pages-owner"
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		host := "pages.example.com"
+		if i%2 == 1 {
+			host = "pages.other.org"
+		}
+		require.NoError(t, repo.Create(context.TODO(), storage.URL{
+			UUID:      fmt.Sprintf("uuid-pages-%d", i),
+			Short:     fmt.Sprintf("pages%d", i),
+			Original:  fmt.Sprintf("https://%s/%d", host, i),
+			UserUUID:  owner,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	getPage := func(query string) (*http.Response, []service.UserURLResult) {
+		req := httptest.NewRequest(http.MethodGet, "/api/user/urls?"+query, nil)
+		req.Header.Set("Authorization", authToken(owner))
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var urls []service.UserURLResult
+		if res.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&urls))
+		}
+		return res, urls
+	}
+
+	shorts := func(urls []service.UserURLResult) []string {
+		var result []string
+		for _, url := range urls {
+			result = append(result, url.ShortURL[strings.LastIndex(url.ShortURL, "/")+1:])
+		}
+		return result
+	}
+
+	// проходим все страницы по ключу из заголовка
+	var seen []string
+	query := "limit=2&order=desc"
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+
+		res, urls := getPage(query)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		seen = append(seen, shorts(urls)...)
+
+		cursor := res.Header.Get(NextCursorHeader)
+		if cursor == "" {
+			break
+		}
+		query = "limit=2&order=desc&cursor=" + cursor
+	}
+	assert.Equal(t, []string{"pages4", "pages3", "pages2", "pages1", "pages0"}, seen)
+
+	res, urls := getPage("host=Pages.Example.com")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{"pages0", "pages2", "pages4"}, shorts(urls))
+	require.NotNil(t, urls[0].CreatedAt)
+	assert.True(t, base.Equal(*urls[0].CreatedAt))
+
+	res, urls = getPage("contains=OTHER.org/3")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{"pages3"}, shorts(urls))
+
+	res, _ = getPage("host=nothing.example.com")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	for _, query := range []string{"cursor=broken", "limit=-1", "limit=many", "order=sideways"} {
+		res, _ := getPage(query)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+	}
+}
+
+func TestGetUserURLsWithoutPaging(t *testing.T) {
+	app, repo, _ := newAppInstance()
+
+	owner := "user-unpaged-owner"
+	total := service.DefaultPageSize + 5
+
+	for i := 0; i < total; i++ {
+		require.NoError(t, repo.Create(context.TODO(), storage.URL{
+			UUID:     fmt.Sprintf("uuid-unpaged-%d", i),
+			Short:    fmt.Sprintf("unpaged%d", i),
+			Original: fmt.Sprintf("https://unpaged.example.com/%d", i),
+			UserUUID: owner,
+		}))
+	}
+
+	getURLs := func(query string) (*http.Response, []service.UserURLResult) {
+		req := httptest.NewRequest(http.MethodGet, "/api/user/urls?"+query, nil)
+		req.Header.Set("Authorization", authToken(owner))
+		res, err := app.Test(req, 100)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var urls []service.UserURLResult
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&urls))
+		return res, urls
+	}
+
+	// без limit и cursor отдаются все ссылки, как раньше
+	res, urls := getURLs("")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, urls, total)
+	assert.Empty(t, res.Header.Get(NextCursorHeader))
+
+	res, urls = getURLs("limit=1")
+	require.Len(t, urls, 1)
+	cursor := res.Header.Get(NextCursorHeader)
+	require.NotEmpty(t, cursor)
+
+	// с ключом страницы, но без limit — страница по умолчанию
+	res, urls = getURLs("cursor=" + cursor)
+	assert.Len(t, urls, service.DefaultPageSize)
+	assert.NotEmpty(t, res.Header.Get(NextCursorHeader))
+}
+
+func TestMetrics(t *testing.T) {
+	logger.New()
+
+	m := metrics.New()
+	repo := instrumented.New(inmemory.New(), m)
+	urlService := service.New(repo, config.New(), service.WithRedirectObserver(m))
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), m, nil)
+
+	require.NoError(t, repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-metrics",
+		Short:    "metrics1",
+		Original: "http://google.com/metrics",
+	}))
+
+	for _, short := range []string{"metrics1", "metrics1", "missing1"} {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/"+short, nil), 100)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+
+	// в метках маршрут, а не короткий адрес
+	assert.Contains(t, body, `urlshrt_http_requests_total{method="GET",route="/:short",status="307"} 2`)
+	assert.Contains(t, body, `urlshrt_http_requests_total{method="GET",route="/:short",status="400"} 1`)
+	assert.Contains(t, body, `urlshrt_http_request_duration_seconds_count{method="GET",route="/:short",status="307"} 2`)
+	assert.Contains(t, body, `urlshrt_redirects_total{result="hit"} 2`)
+	assert.Contains(t, body, `urlshrt_redirects_total{result="miss"} 1`)
+	assert.Contains(t, body, `urlshrt_storage_operation_duration_seconds_count{operation="Create",result="ok"} 1`)
+	assert.Contains(t, body, `urlshrt_storage_operation_duration_seconds_count{operation="Get",result="ok"} 3`)
+	assert.NotContains(t, body, "missing1")
+	assert.Contains(t, body, "go_goroutines")
+}
+
+// traceparent клиента, который вызывает сервис
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceparent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+// подключает трассировку со спанами в памяти и возвращает функцию, которая отдает записанные спаны трассы testTraceID
+func setupTestTracing(t *testing.T) func() map[string]sdktrace.ReadOnlySpan {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.Setup(exporter)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	return func() map[string]sdktrace.ReadOnlySpan {
+		require.NoError(t, provider.ForceFlush(context.Background()))
+
+		spans := make(map[string]sdktrace.ReadOnlySpan)
+		for _, span := range exporter.GetSpans().Snapshots() {
+			if span.SpanContext().TraceID().String() == testTraceID {
+				spans[span.Name()] = span
+			}
+		}
+		return spans
+	}
+}
+
+func TestTracing(t *testing.T) {
+	logger.New()
+	spans := setupTestTracing(t)
+
+	repo := instrumented.New(inmemory.New(), nil)
+	urlService := service.New(repo, config.New())
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), nil, nil)
+
+	require.NoError(t, repo.Create(context.TODO(), storage.URL{
+		UUID:     "some-uuid-tracing",
+		Short:    "tracing1",
+		Original: "http://google.com/tracing",
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/tracing1", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	res, err := app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+
+	// спан запроса продолжает трассу клиента и возвращается ему
+	assert.Contains(t, res.Header.Get("traceparent"), testTraceID)
+
+	recorded := spans()
+	require.Contains(t, recorded, "GET /:short")
+	require.Contains(t, recorded, "service.FindOriginal")
+	require.Contains(t, recorded, "storage.Get")
+
+	server := recorded["GET /:short"]
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), recorded["service.FindOriginal"].Parent().SpanID())
+	assert.Equal(t, recorded["service.FindOriginal"].SpanContext().SpanID(), recorded["storage.Get"].Parent().SpanID())
+	assert.Equal(t, otelcodes.Unset, recorded["service.FindOriginal"].Status().Code)
+
+	// ошибка сервиса отмечается в его спане
+	req = httptest.NewRequest(http.MethodGet, "/tracing-missing", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	res, err = app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	recorded = spans()
+	require.Contains(t, recorded, "service.FindOriginal")
+	assert.Equal(t, otelcodes.Error, recorded["service.FindOriginal"].Status().Code)
+	assert.Equal(t, service.ErrNotFound.Error(), recorded["service.FindOriginal"].Status().Description)
+}
+
+func TestRequestLogging(t *testing.T) {
+	app, _, _ := newAppInstance()
+
+	var out bytes.Buffer
+	require.NoError(t, logger.Setup("info", logger.FormatJSON))
+	logger.Log.SetOutput(&out)
+	t.Cleanup(func() { logger.New() })
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(`{"url": "http://google.com/logging"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logger.RequestIDHeader, "req-logging-1")
+	res, err := app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "req-logging-1", res.Header.Get(logger.RequestIDHeader))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "req-logging-1", entry["request_id"])
+	assert.NotEmpty(t, entry["user"])
+	assert.Equal(t, http.MethodPost, entry["method"])
+	assert.Equal(t, "/api/shorten", entry["path"])
+	assert.EqualValues(t, http.StatusCreated, entry["status"])
+	assert.Contains(t, entry, "duration_ms")
+
+	// ID, которым можно испортить лог, заменяется новым
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(logger.RequestIDHeader, "req logging")
+	res, err = app.Test(req, 100)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	requestID := res.Header.Get(logger.RequestIDHeader)
+	assert.NotEmpty(t, requestID)
+	assert.NotEqual(t, "req logging", requestID)
+}
+
+func TestHealth(t *testing.T) {
+	logger.New()
+
+	repo := inmemory.New()
+	urlService := service.New(repo, config.New())
+
+	storageErr := error(nil)
+	checker := health.New(health.DefaultTimeout)
+	checker.Add("storage", func(ctx context.Context) error { return storageErr })
+
+	app := app.NewHTTPServer(NewHTTPController(&urlService, newTestAuth(), testAccounts), repo, ratelimit.Limits{}, newTestAuth(), nil, checker)
+
+	get := func(path string) (int, health.Report) {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), 100)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var report health.Report
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+		return res.StatusCode, report
+	}
+
+	status, report := get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusUp, report.Status)
+
+	// до первой фоновой проверки сервис не готов
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	checker.Ready(context.Background())
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.Report{Status: health.StatusUp}, report)
+
+	// отдается только общий статус, без ошибок зависимостей
+	storageErr = errors.New("connection refused")
+	checker.Ready(context.Background())
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.Report{Status: health.StatusDown}, report)
+
+	// при остановке сервис перестает быть готовым, но остается живым
+	storageErr = nil
+	checker.Ready(context.Background())
+	checker.Shutdown()
+
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.StatusDown, report.Status)
+
+	status, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/augustjourney/urlshrt/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Результат отключения ссылок на заблокированные адреса
+type APIDisableBlockedResult struct {
+	Disabled  int      `json:"disabled"`
+	ShortURLs []string `json:"short_urls"`
+}
+
+// Обрабатывает http-запрос на отключение уже сокращенных ссылок,
+// адреса которых попали в список заблокированных
+func (c *Controller) DisableBlockedURLs(ctx *fiber.Ctx) error {
+	ctx.Set("Content-type", "application/json")
+
+	shortURLs, err := c.service.DisableBlocked(ctx.UserContext())
+	if errors.Is(err, service.ErrNoBlocklist) {
+		return c.sendAPIError(ctx, http.StatusNotImplemented, err)
+	}
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	if shortURLs == nil {
+		shortURLs = []string{}
+	}
+
+	response, err := json.Marshal(APIDisableBlockedResult{
+		Disabled:  len(shortURLs),
+		ShortURLs: shortURLs,
+	})
+	if err != nil {
+		return ctx.SendStatus(http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).Send(response)
+}
@@ -0,0 +1,168 @@
+// модуль deleter удаляет ссылки в фоне:
+// запросы на удаление от разных пользователей копятся в очереди,
+// воркеры собирают их в пачки и помечают удаленными одним запросом к хранилищу
+// по размеру пачки или по таймеру.
+package deleter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+)
+
+// Ошибка если удаление запрошено после остановки
+var ErrClosed = errors.New("deleter is closed")
+
+// удаляет ссылки пачками в фоне
+type Deleter struct {
+	repo          storage.IRepo
+	requests      chan []storage.Deletion
+	workers       int
+	batchSize     int
+	flushInterval time.Duration
+
+	// сколько ссылок ждут удаления, включая собранные в пачки, но еще не удаленные
+	pending atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+// ставит ссылки пользователя в очередь на удаление.
+// если очередь заполнена — ждет, пока в ней освободится место, или отмены ctx
+func (d *Deleter) Enqueue(ctx context.Context, shorts []string, userUUID string) error {
+	if len(shorts) == 0 {
+		return nil
+	}
+
+	deletions := make([]storage.Deletion, len(shorts))
+	for i, short := range shorts {
+		deletions[i] = storage.Deletion{Short: short, UserUUID: userUUID}
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+
+	select {
+	case d.requests <- deletions:
+		d.pending.Add(int64(len(deletions)))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// количество ссылок, которые ждут удаления
+func (d *Deleter) Pending() int {
+	return int(d.pending.Load())
+}
+
+// запускает воркеры
+func (d *Deleter) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.run()
+	}
+
+	go func() {
+		d.wg.Wait()
+		close(d.done)
+	}()
+}
+
+// прекращает прием запросов и дожидается удаления уже принятых
+func (d *Deleter) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.requests)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Deleter) run() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]storage.Deletion, 0, d.batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := d.repo.DeleteBatch(context.Background(), batch)
+		if err != nil {
+			logger.Log.WithError(err).WithField("urls", len(batch)).Error("Could not delete urls")
+		}
+		d.pending.Add(-int64(len(batch)))
+		batch = make([]storage.Deletion, 0, d.batchSize)
+	}
+
+	for {
+		select {
+		case deletions, ok := <-d.requests:
+			if !ok {
+				flush()
+				return
+			}
+			// большой запрос делится на пачки, чтобы ни одна не превышала batchSize
+			for len(deletions) > 0 {
+				n := min(d.batchSize-len(batch), len(deletions))
+				batch = append(batch, deletions[:n]...)
+				deletions = deletions[n:]
+				if len(batch) >= d.batchSize {
+					flush()
+				}
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// создает удалятель ссылок
+// queueSize — сколько запросов на удаление может ждать в очереди,
+// batchSize — сколько ссылок удаляется за один запрос к хранилищу
+func New(repo storage.IRepo, workers int, queueSize int, batchSize int, flushInterval time.Duration) *Deleter {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	return &Deleter{
+		repo:          repo,
+		requests:      make(chan []storage.Deletion, queueSize),
+		workers:       workers,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+}
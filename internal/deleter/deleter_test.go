@@ -0,0 +1,147 @@
+package deleter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// хранилище, которое запоминает пачки удалений
+type batchRepo struct {
+	storage.IRepo
+	mu      sync.Mutex
+	batches [][]storage.Deletion
+}
+
+func (r *batchRepo) DeleteBatch(ctx context.Context, deletions []storage.Deletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, deletions)
+	return nil
+}
+
+func (r *batchRepo) snapshot() [][]storage.Deletion {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]storage.Deletion(nil), r.batches...)
+}
+
+func TestDeleter_FlushBySize(t *testing.T) {
+	logger.New()
+	ctx := context.Background()
+	repo := &batchRepo{}
+
+	d := New(repo, 1, 10, 3, time.Hour)
+	d.Start()
+
+	// запросы разных пользователей попадают в одну пачку
+	require.NoError(t, d.Enqueue(ctx, []string{"short1", "short2"}, "user1"))
+	require.NoError(t, d.Enqueue(ctx, []string{"short3"}, "user2"))
+
+	require.Eventually(t, func() bool { return len(repo.snapshot()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []storage.Deletion{
+		{Short: "short1", UserUUID: "user1"},
+		{Short: "short2", UserUUID: "user1"},
+		{Short: "short3", UserUUID: "user2"},
+	}, repo.snapshot()[0])
+	assert.Equal(t, 0, d.Pending())
+
+	require.NoError(t, d.Close(ctx))
+}
+
+func TestDeleter_SplitsLargeRequest(t *testing.T) {
+	logger.New()
+	ctx := context.Background()
+	repo := &batchRepo{}
+
+	d := New(repo, 1, 10, 3, time.Hour)
+	d.Start()
+
+	require.NoError(t, d.Enqueue(ctx, []string{"short1"}, "user1"))
+	require.NoError(t, d.Enqueue(ctx, []string{"short2", "short3", "short4", "short5", "short6", "short7", "short8"}, "user2"))
+	require.NoError(t, d.Close(ctx))
+
+	var sizes []int
+	for _, batch := range repo.snapshot() {
+		sizes = append(sizes, len(batch))
+	}
+	assert.Equal(t, []int{3, 3, 2}, sizes)
+	assert.Equal(t, 0, d.Pending())
+}
+
+func TestDeleter_FlushByInterval(t *testing.T) {
+	logger.New()
+	ctx := context.Background()
+	repo := &batchRepo{}
+
+	d := New(repo, 2, 10, 100, 10*time.Millisecond)
+	d.Start()
+	defer d.Close(ctx)
+
+	require.NoError(t, d.Enqueue(ctx, []string{"short1"}, "user1"))
+
+	require.Eventually(t, func() bool { return d.Pending() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Len(t, repo.snapshot(), 1)
+}
+
+func TestDeleter_Close(t *testing.T) {
+	logger.New()
+	ctx := context.Background()
+	repo := &batchRepo{}
+
+	d := New(repo, 2, 10, 100, time.Hour)
+
+	// воркеры еще не запущены — запросы копятся в очереди
+	require.NoError(t, d.Enqueue(ctx, []string{"short1", "short2"}, "user1"))
+	require.NoError(t, d.Enqueue(ctx, []string{"short3"}, "user2"))
+	assert.Equal(t, 3, d.Pending())
+
+	d.Start()
+
+	// при остановке накопленные удаления сохраняются
+	require.NoError(t, d.Close(ctx))
+	assert.Equal(t, 0, d.Pending())
+
+	var deleted int
+	for _, batch := range repo.snapshot() {
+		deleted += len(batch)
+	}
+	assert.Equal(t, 3, deleted)
+
+	assert.ErrorIs(t, d.Enqueue(ctx, []string{"short4"}, "user1"), ErrClosed)
+}
+
+func TestDeleter_QueueFull(t *testing.T) {
+	repo := &batchRepo{}
+
+	d := New(repo, 1, 1, 100, time.Hour)
+	require.NoError(t, d.Enqueue(context.Background(), []string{"short1"}, "user1"))
+
+	// очередь заполнена, а воркеры не запущены — ждем до отмены контекста
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, d.Enqueue(ctx, []string{"short2"}, "user1"), context.DeadlineExceeded)
+	assert.Equal(t, 1, d.Pending())
+}
+
+func TestDeleter_InvalidSettings(t *testing.T) {
+	logger.New()
+	ctx := context.Background()
+	repo := &batchRepo{}
+
+	// неверные настройки заменяются допустимыми, а не роняют сервис
+	d := New(repo, 0, -1, 0, 0)
+	d.Start()
+
+	require.NoError(t, d.Enqueue(ctx, []string{"short1"}, "user1"))
+	require.Eventually(t, func() bool { return len(repo.snapshot()) == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, d.Close(ctx))
+}
@@ -0,0 +1,227 @@
+// модуль health проверяет, жив ли сервис и готов ли он принимать запросы.
+// живость не зависит от хранилищ: сервис, который не может до них достучаться,
+// перезапуск не вылечит. готовность проверяет каждую зависимость
+// и перестает проходить, как только сервис начинает останавливаться, —
+// балансировщик успевает убрать его до закрытия соединений.
+// тот же статус отдается стандартным сервисом grpc.health.v1.Health.
+// /readyz отдает результат последней фоновой проверки и только общий статус —
+// ошибки зависимостей пишутся в лог.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Статусы сервиса и его зависимостей
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// сколько по умолчанию ждем ответа одной зависимости
+const DefaultTimeout = 2 * time.Second
+
+// как часто Watch проверяет готовность, если interval не положительный
+const DefaultInterval = 5 * time.Second
+
+// Ошибка если сервис останавливается
+var ErrShuttingDown = errors.New("shutting down")
+
+// Ошибка если grpc-сервер еще не принимает запросы или уже остановлен
+var ErrNotServing = errors.New("grpc server is not serving")
+
+// Проверка зависимости — nil, если она в порядке
+type Check func(ctx context.Context) error
+
+// Статус зависимости
+type Component struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Статус сервиса и его зависимостей
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// проверка с именем зависимости
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// проверяет живость и готовность сервиса
+type Checker struct {
+	mu      sync.RWMutex
+	checks  []namedCheck
+	timeout time.Duration
+
+	grpc         *health.Server
+	grpcServing  atomic.Bool
+	shuttingDown atomic.Bool
+	// результат последней проверки готовности
+	last atomic.Pointer[Report]
+}
+
+// добавляет проверку зависимости
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// проверка того, что grpc-сервер принимает запросы, — для готовности
+func (c *Checker) GRPCCheck(ctx context.Context) error {
+	if !c.grpcServing.Load() {
+		return ErrNotServing
+	}
+	return nil
+}
+
+// отмечает, принимает ли grpc-сервер запросы
+func (c *Checker) SetGRPCServing(serving bool) {
+	c.grpcServing.Store(serving)
+}
+
+// сервис grpc.health.v1.Health для регистрации на grpc-сервере
+func (c *Checker) GRPCServer() healthpb.HealthServer {
+	return c.grpc
+}
+
+// сервис жив, пока отвечает
+func (c *Checker) Live(ctx context.Context) Report {
+	return Report{Status: StatusUp}
+}
+
+// проверяет все зависимости параллельно — сервис готов, если готова каждая.
+// статус для grpc.health.v1.Health обновляется по результату
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, check.check)
+	}
+	wg.Wait()
+
+	previous := c.last.Load()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(checks))}
+	// сохраняется уже готовый отчет — его читают запросы к /readyz
+	defer c.last.Store(&report)
+
+	for i, check := range checks {
+		component := Component{Status: StatusUp}
+		if errs[i] != nil {
+			component = Component{Status: StatusDown, Error: errs[i].Error()}
+			report.Status = StatusDown
+			// ошибки зависимостей наружу не отдаются — пишем в лог, когда зависимость падает
+			if previous == nil || previous.Components[check.name].Status != StatusDown {
+				logger.Log.WithError(errs[i]).WithField("dependency", check.name).Warn("Dependency is down")
+			}
+		}
+		report.Components[check.name] = component
+	}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusDown
+		report.Components["shutdown"] = Component{Status: StatusDown, Error: ErrShuttingDown.Error()}
+		return report
+	}
+
+	if report.Status == StatusUp {
+		c.grpc.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	} else {
+		c.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	return report
+}
+
+// результат последней проверки готовности без ошибок зависимостей — его отдает /readyz,
+// чтобы запросы к нему не нагружали зависимости. до первой проверки и при остановке сервис не готов
+func (c *Checker) LastReady(ctx context.Context) Report {
+	report := c.last.Load()
+	if report == nil || c.shuttingDown.Load() {
+		return Report{Status: StatusDown}
+	}
+	return Report{Status: report.Status}
+}
+
+// проверяет готовность раз в interval, чтобы статус grpc.health.v1.Health и /readyz не устаревал.
+// блокируется до отмены контекста
+func (c *Checker) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Ready(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// отмечает, что сервис останавливается: готовность перестает проходить,
+// а grpc.health.v1.Health отвечает NOT_SERVING на любой сервис
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+	c.grpc.Shutdown()
+}
+
+// отмечает остановку и еще delay продолжает обслуживать запросы, чтобы балансировщик
+// успел увидеть отказ готовности и перестал слать новые, — но не дольше, чем позволяет ctx
+func (c *Checker) Drain(ctx context.Context, delay time.Duration) {
+	c.Shutdown()
+
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// создает проверку без зависимостей, timeout — сколько ждем ответа каждой.
+// до первой проверки готовности grpc.health.v1.Health отвечает NOT_SERVING
+func New(timeout time.Duration) *Checker {
+	c := &Checker{
+		timeout: timeout,
+		grpc:    health.NewServer(),
+	}
+	c.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return c
+}
@@ -0,0 +1,211 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func ok(ctx context.Context) error {
+	return nil
+}
+
+func failing(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func slow(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// статус сервиса в grpc.health.v1.Health
+func grpcStatus(t *testing.T, c *Checker) healthpb.HealthCheckResponse_ServingStatus {
+	res, err := c.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	return res.Status
+}
+
+func TestChecker_Ready(t *testing.T) {
+	logger.New()
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus string
+		wantDown   []string
+		wantGRPC   healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name:       "no checks",
+			wantStatus: StatusUp,
+			wantGRPC:   healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:       "all up",
+			checks:     map[string]Check{"storage": ok, "file": ok},
+			wantStatus: StatusUp,
+			wantGRPC:   healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:       "storage down",
+			checks:     map[string]Check{"storage": failing, "file": ok},
+			wantStatus: StatusDown,
+			wantDown:   []string{"storage"},
+			wantGRPC:   healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:       "check times out",
+			checks:     map[string]Check{"storage": ok, "file": slow},
+			wantStatus: StatusDown,
+			wantDown:   []string{"file"},
+			wantGRPC:   healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(50 * time.Millisecond)
+			for name, check := range tt.checks {
+				c.Add(name, check)
+			}
+
+			// до первой проверки готовность неизвестна
+			assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, c))
+
+			report := c.Ready(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Len(t, report.Components, len(tt.checks))
+
+			for name, component := range report.Components {
+				if assert.Contains(t, tt.checks, name) && !slices.Contains(tt.wantDown, name) {
+					assert.Equal(t, StatusUp, component.Status, name)
+					assert.Empty(t, component.Error, name)
+				}
+			}
+			for _, name := range tt.wantDown {
+				assert.Equal(t, StatusDown, report.Components[name].Status, name)
+				assert.NotEmpty(t, report.Components[name].Error, name)
+			}
+
+			assert.Equal(t, tt.wantGRPC, grpcStatus(t, c))
+			assert.Equal(t, StatusUp, c.Live(context.Background()).Status)
+		})
+	}
+}
+
+func TestChecker_GRPCCheck(t *testing.T) {
+	logger.New()
+
+	c := New(DefaultTimeout)
+	c.Add("grpc", c.GRPCCheck)
+
+	assert.Equal(t, StatusDown, c.Ready(context.Background()).Status)
+
+	c.SetGRPCServing(true)
+	assert.Equal(t, StatusUp, c.Ready(context.Background()).Status)
+
+	c.SetGRPCServing(false)
+	report := c.Ready(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, ErrNotServing.Error(), report.Components["grpc"].Error)
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	logger.New()
+
+	c := New(DefaultTimeout)
+	c.Add("storage", ok)
+
+	require.Equal(t, StatusUp, c.Ready(context.Background()).Status)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, c))
+
+	c.Shutdown()
+
+	report := c.Ready(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusUp, report.Components["storage"].Status)
+	assert.Equal(t, ErrShuttingDown.Error(), report.Components["shutdown"].Error)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, c))
+
+	// живость от остановки не зависит
+	assert.Equal(t, StatusUp, c.Live(context.Background()).Status)
+}
+
+func TestChecker_Drain(t *testing.T) {
+	logger.New()
+
+	c := New(DefaultTimeout)
+	c.Add("storage", ok)
+	require.Equal(t, StatusUp, c.Ready(context.Background()).Status)
+
+	start := time.Now()
+	c.Drain(context.Background(), 50*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, StatusDown, c.LastReady(context.Background()).Status)
+
+	// ожидание не выходит за время, отведенное на этап остановки
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	c.Drain(ctx, time.Minute)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestChecker_LastReady(t *testing.T) {
+	logger.New()
+
+	checkErr := error(nil)
+	calls := 0
+
+	c := New(DefaultTimeout)
+	c.Add("storage", func(ctx context.Context) error {
+		calls++
+		return checkErr
+	})
+
+	// до первой проверки сервис не готов
+	assert.Equal(t, Report{Status: StatusDown}, c.LastReady(context.Background()))
+
+	c.Ready(context.Background())
+	assert.Equal(t, Report{Status: StatusUp}, c.LastReady(context.Background()))
+
+	// отдается результат последней проверки — сами проверки не запускаются
+	checkErr = errors.New("connection refused")
+	assert.Equal(t, Report{Status: StatusUp}, c.LastReady(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	// ошибки зависимостей наружу не попадают
+	c.Ready(context.Background())
+	assert.Equal(t, Report{Status: StatusDown}, c.LastReady(context.Background()))
+
+	// остановка видна сразу, без новой проверки
+	checkErr = nil
+	c.Ready(context.Background())
+	require.Equal(t, StatusUp, c.LastReady(context.Background()).Status)
+
+	c.Shutdown()
+	assert.Equal(t, Report{Status: StatusDown}, c.LastReady(context.Background()))
+}
+
+func TestChecker_WatchInvalidInterval(t *testing.T) {
+	logger.New()
+
+	c := New(DefaultTimeout)
+	c.Add("storage", ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// нулевой интервал заменяется интервалом по умолчанию, первая проверка выполняется сразу
+	assert.NotPanics(t, func() { c.Watch(ctx, 0) })
+	assert.Equal(t, StatusUp, c.LastReady(context.Background()).Status)
+}
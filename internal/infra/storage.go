@@ -0,0 +1,184 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	accountsInmemory "github.com/augustjourney/urlshrt/internal/accounts/inmemory"
+	accountsPostgres "github.com/augustjourney/urlshrt/internal/accounts/postgres"
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	analyticsInmemory "github.com/augustjourney/urlshrt/internal/analytics/inmemory"
+	analyticsPostgres "github.com/augustjourney/urlshrt/internal/analytics/postgres"
+	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/migrations"
+	"github.com/augustjourney/urlshrt/internal/shortcode"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/augustjourney/urlshrt/internal/storage/infile"
+	"github.com/augustjourney/urlshrt/internal/storage/inmemory"
+	"github.com/augustjourney/urlshrt/internal/storage/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// Хранилища ссылок
+const (
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+	BackendPostgres = "postgres"
+)
+
+// Ошибка если указано неизвестное хранилище
+var ErrUnknownBackend = errors.New("unknown storage backend, expected memory, file or postgres")
+
+// Ошибка если выбран postgres, но не указан DSN базы
+var ErrNoDatabaseDSN = errors.New("postgres storage backend requires database DSN")
+
+// Ошибка если выбран файл, но не указан путь до него
+var ErrNoFileStoragePath = errors.New("file storage backend requires file storage path")
+
+// Хранилища, с которыми работает сервис
+type Storage struct {
+	// выбранное хранилище: memory, file или postgres
+	Backend  string
+	Repo     storage.IRepo
+	Clicks   analytics.IStore
+	Accounts accounts.IStore
+	Counter  shortcode.Counter
+	// журнал в файле — только для file, нужен для фонового сжатия
+	File *infile.Repo
+	// подключение к базе — только для postgres
+	DB *sql.DB
+}
+
+// Определяет хранилище по конфигу и проверяет, что для него хватает настроек.
+// Если хранилище не указано явно: postgres при заданном DSN,
+// иначе file при заданном пути до файла, иначе memory
+func ResolveBackend(config *config.Config) (string, error) {
+	backend := config.StorageBackend
+
+	if backend == "" {
+		switch {
+		case config.DatabaseDSN != "":
+			backend = BackendPostgres
+		case config.FileStoragePath != "":
+			backend = BackendFile
+		default:
+			backend = BackendMemory
+		}
+	}
+
+	switch backend {
+	case BackendMemory:
+	case BackendFile:
+		if config.FileStoragePath == "" {
+			return "", ErrNoFileStoragePath
+		}
+	case BackendPostgres:
+		if config.DatabaseDSN == "" {
+			return "", ErrNoDatabaseDSN
+		}
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownBackend, backend)
+	}
+
+	return backend, nil
+}
+
+// Создает хранилища выбранного типа.
+// Если хранилище недоступно — возвращает ошибку, на другое хранилище не переключается
+func NewStorage(ctx context.Context, config *config.Config) (*Storage, error) {
+	backend, err := ResolveBackend(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Storage{Backend: backend}
+
+	switch backend {
+	case BackendPostgres:
+		db, err := InitPostgres(config)
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.PingContext(ctx)
+		if err == nil {
+			// Схема базы обновляется при каждом запуске,
+			// несколько реплик могут делать это одновременно
+			err = Migrate(ctx, db)
+		}
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("postgres storage: %w", err)
+		}
+
+		s.DB = db
+		s.Repo = postgres.New(db)
+		s.Clicks = analyticsPostgres.New(db)
+		s.Accounts = accountsPostgres.New(db)
+		s.Counter = postgres.NewSequence(db)
+
+		return s, nil
+
+	case BackendFile:
+		file, err := infile.New(config)
+		if err != nil {
+			return nil, fmt.Errorf("file storage: %w", err)
+		}
+
+		s.File = file
+		s.Repo = file
+
+	default:
+		s.Repo = inmemory.New()
+	}
+
+	// Без postgres переходы и API-ключи не переживают перезапуск
+	s.Clicks = analyticsInmemory.New()
+	s.Accounts = accountsInmemory.New()
+
+	// Без postgres счетчик живет в памяти и продолжает отсчет
+	// с количества уже сохраненных ссылок
+	stats, err := s.Repo.GetStats(ctx)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.Counter = shortcode.NewMemoryCounter(int64(stats.UrlsCount))
+
+	return s, nil
+}
+
+// Закрывает файл или подключение к базе
+func (s *Storage) Close() error {
+	if s.File != nil {
+		return s.File.Close()
+	}
+
+	if s.DB != nil {
+		return s.DB.Close()
+	}
+
+	return nil
+}
+
+// Применяет все непримененные миграции и логирует их
+func Migrate(ctx context.Context, db *sql.DB) error {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		logger.Log.WithFields(logrus.Fields{
+			"version": migration.Version,
+			"name":    migration.Name,
+		}).Info("Applied migration")
+	}
+
+	return err
+}
@@ -0,0 +1,188 @@
+package infra
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.Config
+		backend string
+		err     error
+	}{
+		{
+			name:    "postgres when dsn is set",
+			config:  config.Config{DatabaseDSN: "postgres://localhost/db", FileStoragePath: "/tmp/urls.json"},
+			backend: BackendPostgres,
+		},
+		{
+			name:    "file when only path is set",
+			config:  config.Config{FileStoragePath: "/tmp/urls.json"},
+			backend: BackendFile,
+		},
+		{
+			name:    "memory when nothing is set",
+			config:  config.Config{},
+			backend: BackendMemory,
+		},
+		{
+			name:    "explicit memory wins over dsn",
+			config:  config.Config{StorageBackend: BackendMemory, DatabaseDSN: "postgres://localhost/db"},
+			backend: BackendMemory,
+		},
+		{
+			name:   "postgres without dsn",
+			config: config.Config{StorageBackend: BackendPostgres, FileStoragePath: "/tmp/urls.json"},
+			err:    ErrNoDatabaseDSN,
+		},
+		{
+			name:   "file without path",
+			config: config.Config{StorageBackend: BackendFile},
+			err:    ErrNoFileStoragePath,
+		},
+		{
+			name:   "unknown backend",
+			config: config.Config{StorageBackend: "redis"},
+			err:    ErrUnknownBackend,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := ResolveBackend(&tt.config)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.backend, backend)
+		})
+	}
+}
+
+func TestNewStorage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("memory", func(t *testing.T) {
+		s, err := NewStorage(ctx, &config.Config{StorageBackend: BackendMemory})
+		require.NoError(t, err)
+		defer s.Close()
+
+		assert.Equal(t, BackendMemory, s.Backend)
+		assert.Nil(t, s.File)
+		assert.Nil(t, s.DB)
+		assert.NoError(t, s.Repo.Ping(ctx))
+	})
+
+	t.Run("file continues counter from saved urls", func(t *testing.T) {
+		cfg := &config.Config{
+			StorageBackend:  BackendFile,
+			FileStoragePath: filepath.Join(t.TempDir(), "urls.json"),
+			FileSyncPolicy:  "always",
+		}
+
+		s, err := NewStorage(ctx, cfg)
+		require.NoError(t, err)
+		require.NotNil(t, s.File)
+		require.NoError(t, s.Repo.Create(ctx, storage.URL{UUID: "1", Short: "abc", Original: "http://a.com"}))
+		require.NoError(t, s.Close())
+
+		s, err = NewStorage(ctx, cfg)
+		require.NoError(t, err)
+		defer s.Close()
+
+		assert.NoError(t, s.Repo.Ping(ctx))
+
+		next, err := s.Counter.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), next)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewStorage(ctx, &config.Config{StorageBackend: BackendPostgres})
+		assert.ErrorIs(t, err, ErrNoDatabaseDSN)
+	})
+}
+
+// пользователи считаются одинаково во всех хранилищах
+func TestGetStatsAcrossBackends(t *testing.T) {
+	logger.New()
+	ctx := context.Background()
+
+	backends := map[string]func(t *testing.T) *config.Config{
+		BackendMemory: func(t *testing.T) *config.Config {
+			return &config.Config{StorageBackend: BackendMemory}
+		},
+		BackendFile: func(t *testing.T) *config.Config {
+			return &config.Config{
+				StorageBackend:  BackendFile,
+				FileStoragePath: filepath.Join(t.TempDir(), "urls.log"),
+				FileSyncPolicy:  "always",
+			}
+		},
+		BackendPostgres: func(t *testing.T) *config.Config {
+			dsn := os.Getenv("TEST_DATABASE_DSN")
+			if dsn == "" {
+				t.Skip("TEST_DATABASE_DSN is not set")
+			}
+			return &config.Config{StorageBackend: BackendPostgres, DatabaseDSN: dsn}
+		},
+	}
+
+	for backend, newConfig := range backends {
+		t.Run(backend, func(t *testing.T) {
+			s, err := NewStorage(ctx, newConfig(t))
+			require.NoError(t, err)
+			defer s.Close()
+
+			// в postgres могут быть чужие ссылки, поэтому сравнивается прирост
+			before, err := s.Repo.GetStats(ctx)
+			require.NoError(t, err)
+
+			prefix := uuid.NewString()[:8]
+			owner, other, heir := prefix+"-owner", prefix+"-other", prefix+"-heir"
+			urls := []storage.URL{
+				{Short: prefix + "1", UserUUID: owner},
+				{Short: prefix + "2", UserUUID: owner},
+				{Short: prefix + "3", UserUUID: other},
+				// ссылка без владельца
+				{Short: prefix + "4"},
+			}
+			for i := range urls {
+				urls[i].UUID = uuid.NewString()
+				urls[i].Original = "http://example.com/" + urls[i].Short
+				require.NoError(t, s.Repo.Create(ctx, urls[i]))
+			}
+			if s.DB != nil {
+				t.Cleanup(func() {
+					s.DB.ExecContext(context.Background(), `delete from url_changes where short like $1`, prefix+"%")
+					s.DB.ExecContext(context.Background(), `delete from urls where short like $1`, prefix+"%")
+				})
+			}
+
+			// удаленные ссылки по-прежнему хранятся и учитываются
+			require.NoError(t, s.Repo.Delete(ctx, []string{prefix + "3"}, other))
+
+			// владелец передает одну из ссылок — у него остается другая
+			_, err = s.Repo.Update(ctx, storage.URLUpdate{Short: prefix + "2", UserUUID: owner, NewUserUUID: heir, ChangedAt: time.Now()})
+			require.NoError(t, err)
+
+			after, err := s.Repo.GetStats(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 4, after.UrlsCount-before.UrlsCount)
+			assert.Equal(t, 3, after.UsersCount-before.UsersCount)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/augustjourney/urlshrt/internal/accounts"
+	"github.com/augustjourney/urlshrt/internal/auth"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// интерсептор, который проверяет подписанный токен пользователя или API-ключ аккаунта
+// из metadata authorization и сохраняет ID пользователя в контексте запроса.
+// для методов из issueFor пользователю без токена выпускается новый —
+// он возвращается в заголовке ответа authorization
+func Auth(manager *auth.Manager, accountsService *accounts.Service, issueFor ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any,
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		token := tokenFromMetadata(ctx)
+
+		if accounts.IsAPIKey(token) {
+			account, err := accountsService.Authenticate(ctx, token)
+			if errors.Is(err, accounts.ErrInvalidKey) {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			return handler(withUser(ctx, account), req)
+		}
+
+		if token != "" {
+			user, err := manager.Verify(token)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			return handler(withUser(ctx, user), req)
+		}
+
+		if !slices.Contains(issueFor, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		user := uuid.NewString()
+
+		token, err := manager.Issue(user)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		err = grpc.SetHeader(ctx, metadata.Pairs("authorization", token))
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		return handler(withUser(ctx, user), req)
+	}
+}
+
+// сохраняет ID пользователя в контексте запроса и в полях его лога
+func withUser(ctx context.Context, userID string) context.Context {
+	logger.AddFields(ctx, logrus.Fields{"user": userID})
+	return auth.WithUser(ctx, userID)
+}
+
+// получает токен из metadata authorization, префикс Bearer необязателен
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+
+	return strings.TrimPrefix(values[0], "Bearer ")
+}
@@ -0,0 +1,21 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// интерсептор, который учитывает запросы в метриках: количество и длительность по методу и коду ответа
+func Metrics(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any,
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
@@ -0,0 +1,87 @@
+package interceptors
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/augustjourney/urlshrt/internal/auth"
+	pb "github.com/augustjourney/urlshrt/internal/proto"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// интерсептор, который ограничивает частоту создания ссылок и переходов по ним.
+// клиент с действительным токеном ограничивается по ID пользователя, остальные — по ip-адресу.
+// при превышении лимита возвращается ResourceExhausted с RetryInfo и заголовок retry-after в секундах
+func RateLimit(limits ratelimit.Limits, manager *auth.Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any,
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var limiter *ratelimit.Limiter
+
+		switch info.FullMethod {
+		case pb.URLService_Create_FullMethodName, pb.URLService_CreateBatch_FullMethodName:
+			limiter = limits.Create
+		case pb.URLService_Get_FullMethodName:
+			limiter = limits.Redirect
+		default:
+			return handler(ctx, req)
+		}
+
+		ok, retryAfter := limiter.Allow(ratelimit.Key(verifiedUser(ctx, manager), peerIP(ctx)))
+		if ok {
+			return handler(ctx, req)
+		}
+
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+
+		st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(retryAfter),
+		})
+		if err != nil {
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+
+		return nil, st.Err()
+	}
+}
+
+// получает ID пользователя из токена в metadata, если он действителен
+func verifiedUser(ctx context.Context, manager *auth.Manager) string {
+	token := tokenFromMetadata(ctx)
+	if token == "" {
+		return ""
+	}
+
+	user, err := manager.Verify(token)
+	if err != nil {
+		return ""
+	}
+
+	return user
+}
+
+// получает ip-адрес клиента из адреса соединения
+func peerIP(ctx context.Context) string {
+	requestPeer, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	addr := requestPeer.Addr.String()
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
@@ -0,0 +1,59 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/augustjourney/urlshrt/internal/auth"
+	pb "github.com/augustjourney/urlshrt/internal/proto"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit(t *testing.T) {
+	manager, err := auth.New("secret", nil, 0)
+	require.NoError(t, err)
+
+	interceptor := RateLimit(ratelimit.Limits{
+		Create:   ratelimit.New(0.5, 1),
+		Redirect: ratelimit.New(0.5, 2),
+	}, manager)
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000},
+	})
+
+	call := func(method string) error {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	require.NoError(t, call(pb.URLService_Create_FullMethodName))
+
+	err = call(pb.URLService_CreateBatch_FullMethodName)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.InDelta(t, 2, retryInfo.RetryDelay.AsDuration().Seconds(), 0.1)
+
+	// переходы ограничиваются отдельно от создания ссылок
+	require.NoError(t, call(pb.URLService_Get_FullMethodName))
+
+	// остальные методы не ограничиваются
+	for i := 0; i < 5; i++ {
+		require.NoError(t, call(pb.URLService_GetUserURLs_FullMethodName))
+	}
+}
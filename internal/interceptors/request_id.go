@@ -0,0 +1,30 @@
+package interceptors
+
+import (
+	"context"
+	"strings"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ключ метаданных с ID запроса
+var requestIDKey = strings.ToLower(logger.RequestIDHeader)
+
+// интерсептор, который присваивает запросу ID — берет его из метаданных x-request-id или создает новый.
+// ID возвращается в заголовке ответа и попадает в логи запроса
+func RequestID(ctx context.Context, req any,
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var incoming string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(values) > 0 {
+		incoming = values[0]
+	}
+
+	requestID := logger.RequestIDOrNew(incoming)
+
+	// без grpc-потока в контексте — например, в тестах — заголовки отправить некуда
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	return handler(logger.WithRequestID(ctx, requestID), req)
+}
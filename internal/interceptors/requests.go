@@ -2,22 +2,31 @@ package interceptors
 
 import (
 	"context"
-	"fmt"
+	"time"
+
 	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
-	"time"
+	"google.golang.org/grpc/status"
 )
 
+// интерсептор, который логирует запросы.
+// пользователь и ID запроса берутся из контекста — их туда кладут Auth и RequestID
 func LogRequests(ctx context.Context, req any,
 	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 	start := time.Now()
 	resp, err := handler(ctx, req)
-	duration := time.Since(start).Milliseconds()
-	requestLog := fmt.Sprintf("gRPC Request – Method: %s, Duration: %dms", info.FullMethod, duration)
+
+	entry := logger.FromContext(ctx).WithFields(logrus.Fields{
+		"method":      info.FullMethod,
+		"status":      status.Code(err).String(),
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
 	if err != nil {
-		logger.Log.Error(requestLog, err)
+		entry.WithError(err).Error("gRPC request")
 	} else {
-		logger.Log.Info(requestLog)
+		entry.Info("gRPC request")
 	}
 
 	return resp, err
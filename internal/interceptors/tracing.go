@@ -0,0 +1,72 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/augustjourney/urlshrt/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// интерсептор, который начинает спан запроса — дочерний для traceparent из метаданных, если он есть.
+// traceparent спана возвращается в заголовках ответа
+func Tracing(ctx context.Context, req any,
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	propagator := otel.GetTextMapPropagator()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = propagator.Extract(ctx, metadataCarrier(md))
+
+	ctx, span := tracing.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	header := metadata.MD{}
+	propagator.Inject(ctx, metadataCarrier(header))
+	if len(header) > 0 {
+		// без grpc-потока в контексте — например, в тестах — заголовки отправить некуда
+		_ = grpc.SetHeader(ctx, header)
+	}
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	span.SetAttributes(
+		attribute.String("rpc.method", info.FullMethod),
+		attribute.String("rpc.grpc.status_code", code.String()),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	return resp, err
+}
+
+// метаданные grpc, из которых читается и в которые пишется traceparent.
+// в отличие от заголовков http ключи метаданных — в нижнем регистре
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
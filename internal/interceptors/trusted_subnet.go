@@ -34,7 +34,7 @@ func IPInTrustedSubnet(ctx context.Context, req any,
 
 	_, subnet, err := net.ParseCIDR(cfg.TrustedSubnet)
 	if err != nil {
-		logger.Log.Error("could not parse cidr in IPInTrustedSubnet", err.Error())
+		logger.Log.WithError(err).Error("Could not parse trusted subnet")
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+)
+
+// хранилище, журнал которого можно сжать
+type Compactor interface {
+	Compact(ctx context.Context) error
+}
+
+// фоновая задача, которая периодически сжимает журнал хранилища
+type LogCompactor struct {
+	compactor Compactor
+	interval  time.Duration
+}
+
+// запускает задачу — блокируется до отмены контекста
+func (c *LogCompactor) Run(ctx context.Context) {
+	runEvery(ctx, c.interval, func(ctx context.Context) {
+		start := time.Now()
+		err := c.compactor.Compact(ctx)
+		if err != nil {
+			logger.Log.WithError(err).Error("Could not compact storage log")
+			return
+		}
+		logger.Log.WithField("duration_ms", time.Since(start).Milliseconds()).Info("Compacted storage log")
+	})
+}
+
+// создает новый экземпляр задачи по сжатию журнала,
+// неположительный interval заменяется на час
+func NewLogCompactor(compactor Compactor, interval time.Duration) *LogCompactor {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &LogCompactor{
+		compactor: compactor,
+		interval:  interval,
+	}
+}
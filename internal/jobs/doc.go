@@ -0,0 +1,2 @@
+// модуль для фоновых задач, которые периодически обслуживают хранилище ссылок
+package jobs
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+)
+
+// фоновая задача, которая помечает удаленными ссылки с истекшим сроком жизни
+type ExpiredReaper struct {
+	repo     storage.IRepo
+	interval time.Duration
+}
+
+// помечает удаленными ссылки, срок жизни которых уже истек
+func (r *ExpiredReaper) Reap(ctx context.Context) (int, error) {
+	return r.repo.DeleteExpired(ctx, time.Now())
+}
+
+// запускает задачу — блокируется до отмены контекста
+func (r *ExpiredReaper) Run(ctx context.Context) {
+	runEvery(ctx, r.interval, func(ctx context.Context) {
+		deleted, err := r.Reap(ctx)
+		if err != nil {
+			logger.Log.WithError(err).Error("Could not delete expired urls")
+			return
+		}
+		if deleted > 0 {
+			logger.Log.WithField("deleted", deleted).Info("Deleted expired urls")
+		}
+	})
+}
+
+// создает новый экземпляр задачи по удалению ссылок с истекшим сроком жизни,
+// неположительный interval заменяется на минуту
+func NewExpiredReaper(repo storage.IRepo, interval time.Duration) *ExpiredReaper {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &ExpiredReaper{
+		repo:     repo,
+		interval: interval,
+	}
+}
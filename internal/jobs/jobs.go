@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// запускает fn каждые interval до отмены контекста
+func runEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
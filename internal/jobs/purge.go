@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+)
+
+// фоновая задача, которая окончательно удаляет ссылки, удаленные дольше retention назад
+type DeletedPurger struct {
+	repo      storage.IRepo
+	clicks    analytics.IStore
+	retention time.Duration
+	interval  time.Duration
+}
+
+// окончательно удаляет ссылки, срок хранения которых после удаления истек, и переходы по ним.
+// переходы хранятся отдельно от ссылок везде, кроме postgres, где удаляются вместе со ссылками
+func (p *DeletedPurger) Purge(ctx context.Context) (int, error) {
+	purged, err := p.repo.Purge(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	err = p.clicks.DeleteClicks(ctx, purged)
+	if err != nil {
+		return len(purged), err
+	}
+
+	return len(purged), nil
+}
+
+// запускает задачу — блокируется до отмены контекста
+func (p *DeletedPurger) Run(ctx context.Context) {
+	runEvery(ctx, p.interval, func(ctx context.Context) {
+		purged, err := p.Purge(ctx)
+		if err != nil {
+			logger.Log.WithError(err).Error("Could not purge deleted urls")
+			return
+		}
+		if purged > 0 {
+			logger.Log.WithField("purged", purged).Info("Purged deleted urls")
+		}
+	})
+}
+
+// создает новый экземпляр задачи по окончательному удалению ссылок,
+// неположительный interval заменяется на час
+func NewDeletedPurger(repo storage.IRepo, clicks analytics.IStore, retention time.Duration, interval time.Duration) *DeletedPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &DeletedPurger{
+		repo:      repo,
+		clicks:    clicks,
+		retention: retention,
+		interval:  interval,
+	}
+}
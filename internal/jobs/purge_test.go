@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/analytics"
+	analyticsInmemory "github.com/augustjourney/urlshrt/internal/analytics/inmemory"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/augustjourney/urlshrt/internal/storage/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletedPurger_Purge(t *testing.T) {
+	ctx := context.Background()
+	repo := inmemory.New()
+	clicks := analyticsInmemory.New()
+
+	for _, short := range []string{"short1", "short2"} {
+		require.NoError(t, repo.Create(ctx, storage.URL{Short: short, Original: "http://google.com/" + short, UserUUID: "user1"}))
+		require.NoError(t, clicks.SaveClicks(ctx, []analytics.Click{{Short: short, Timestamp: time.Now()}}))
+	}
+	require.NoError(t, repo.Delete(ctx, []string{"short1"}, "user1"))
+
+	purger := NewDeletedPurger(repo, clicks, 0, time.Hour)
+
+	purged, err := purger.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// переходы не достаются ссылке, которая займет тот же адрес
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/new", UserUUID: "user2"}))
+
+	stats, err := clicks.GetLinkStats(ctx, "short1")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Clicks)
+
+	stats, err = clicks.GetLinkStats(ctx, "short2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Clicks)
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// поля, которые попадают в каждую запись лога запроса.
+// поля добавляются по ходу обработки — например, пользователь становится известен после проверки токена
+type requestFields struct {
+	mu     sync.Mutex
+	fields logrus.Fields
+}
+
+// сохраняет ID запроса в контексте — он и поля, добавленные позже, попадают в записи FromContext
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestFields{
+		fields: logrus.Fields{"request_id": requestID},
+	})
+}
+
+// получает ID запроса из контекста — пустая строка, если его нет
+func RequestID(ctx context.Context) string {
+	requestID, _ := fieldsFromContext(ctx)["request_id"].(string)
+	return requestID
+}
+
+// добавляет поля в записи лога запроса; без ID запроса в контексте поля некуда сохранить
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	f, ok := ctx.Value(contextKey{}).(*requestFields)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, value := range fields {
+		f.fields[key] = value
+	}
+}
+
+// получает запись лога с полями запроса из контекста
+func FromContext(ctx context.Context) *logrus.Entry {
+	return Log.WithFields(fieldsFromContext(ctx))
+}
+
+// копия полей запроса
+func fieldsFromContext(ctx context.Context) logrus.Fields {
+	f, ok := ctx.Value(contextKey{}).(*requestFields)
+	if !ok {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fields := make(logrus.Fields, len(f.fields))
+	for key, value := range f.fields {
+		fields[key] = value
+	}
+	return fields
+}
@@ -1,9 +1,21 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 )
 
+// Форматы логов
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Ошибка если указан неизвестный формат логов
+var ErrUnknownFormat = errors.New("unknown log format, expected text or json")
+
 // Логгер
 var Log *logrus.Logger
 
@@ -13,3 +25,23 @@ func New() *logrus.Logger {
 	Log.SetLevel(logrus.InfoLevel)
 	return Log
 }
+
+// Задает уровень и формат логов: text или json
+func Setup(level string, format string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatText:
+		Log.SetFormatter(&logrus.TextFormatter{})
+	case FormatJSON:
+		Log.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	Log.SetLevel(parsed)
+	return nil
+}
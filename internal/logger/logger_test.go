@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     string
+		format    string
+		wantLevel logrus.Level
+		wantErr   bool
+	}{
+		{name: "json debug", level: "debug", format: FormatJSON, wantLevel: logrus.DebugLevel},
+		{name: "text warn", level: "warn", format: FormatText, wantLevel: logrus.WarnLevel},
+		{name: "unknown level", level: "loud", format: FormatJSON, wantLevel: logrus.InfoLevel, wantErr: true},
+		{name: "unknown format", level: "error", format: "xml", wantLevel: logrus.InfoLevel, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			New()
+			err := Setup(tt.level, tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantLevel, Log.GetLevel())
+		})
+	}
+}
+
+func TestRequestIDOrNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "valid", requestID: "req-42", keep: true},
+		{name: "empty", requestID: ""},
+		{name: "with spaces", requestID: "req 42"},
+		{name: "with newline", requestID: "req\n{\"level\":\"error\"}"},
+		{name: "too long", requestID: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestID := RequestIDOrNew(tt.requestID)
+			require.NotEmpty(t, requestID)
+			assert.Equal(t, tt.keep, requestID == tt.requestID)
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	New()
+	require.NoError(t, Setup("debug", FormatJSON))
+
+	var out bytes.Buffer
+	Log.SetOutput(&out)
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	// поля, добавленные позже, видны через тот же контекст
+	AddFields(ctx, logrus.Fields{"user": "user-1"})
+
+	assert.Equal(t, "req-42", RequestID(ctx))
+	FromContext(ctx).Debug("listed")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "req-42", entry["request_id"])
+	assert.Equal(t, "user-1", entry["user"])
+	assert.Equal(t, "listed", entry["msg"])
+	assert.Equal(t, "debug", entry["level"])
+
+	// без ID запроса поля не сохраняются
+	AddFields(context.Background(), logrus.Fields{"user": "user-2"})
+	assert.Empty(t, RequestID(context.Background()))
+}
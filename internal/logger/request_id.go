@@ -0,0 +1,32 @@
+package logger
+
+import "github.com/google/uuid"
+
+// Заголовок http с ID запроса, в метаданных grpc — в нижнем регистре
+const RequestIDHeader = "X-Request-ID"
+
+// самый длинный ID запроса, который принимается от клиента
+const maxRequestIDLength = 128
+
+// возвращает ID запроса от клиента, если он подходит, иначе — новый.
+// подходят непустые ID из печатных ascii-символов без пробелов — иначе ими можно испортить лог
+func RequestIDOrNew(requestID string) string {
+	if validRequestID(requestID) {
+		return requestID
+	}
+	return uuid.NewString()
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
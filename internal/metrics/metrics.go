@@ -0,0 +1,124 @@
+// модуль metrics собирает метрики сервиса и отдает их в формате Prometheus:
+// запросы http и grpc, переходы по ссылкам, операции хранилища и статистику рантайма go.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// префикс всех метрик сервиса
+const namespace = "urlshrt"
+
+// Результат операции хранилища
+const (
+	StorageOK    = "ok"
+	StorageError = "error"
+)
+
+// метрики сервиса
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	redirects    *prometheus.CounterVec
+	storage      *prometheus.HistogramVec
+}
+
+// учитывает http-запрос: route — шаблон маршрута, а не путь, иначе у метрики не будет предела значений
+func (m *Metrics) ObserveHTTP(method string, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// учитывает grpc-запрос: method — полное имя метода, code — код ответа
+func (m *Metrics) ObserveGRPC(method string, code string, duration time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
+
+// учитывает переход по короткой ссылке
+func (m *Metrics) ObserveRedirect(result string) {
+	m.redirects.WithLabelValues(result).Inc()
+}
+
+// учитывает операцию хранилища
+func (m *Metrics) ObserveStorage(operation string, err error, duration time.Duration) {
+	result := StorageOK
+	if err != nil {
+		result = StorageError
+	}
+	m.storage.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// создает метрики в отдельном реестре — вместе со статистикой рантайма go и процесса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "gRPC requests by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "gRPC request latency by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirects_total",
+			Help:      "Short url lookups by result: hit, miss, gone or error.",
+		}, []string{"result"}),
+		storage: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Storage operation latency by operation and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.grpcRequests,
+		m.grpcDuration,
+		m.redirects,
+		m.storage,
+	)
+
+	return m
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+
+	m.ObserveGRPC("/urlshrt.URLService/Get", "OK", 10*time.Millisecond)
+	m.ObserveGRPC("/urlshrt.URLService/Get", "NotFound", time.Millisecond)
+	m.ObserveStorage("Get", nil, time.Millisecond)
+	m.ObserveStorage("Get", errors.New("connection refused"), time.Millisecond)
+	m.ObserveRedirect("gone")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+
+	tests := []string{
+		`urlshrt_grpc_requests_total{code="OK",method="/urlshrt.URLService/Get"} 1`,
+		`urlshrt_grpc_requests_total{code="NotFound",method="/urlshrt.URLService/Get"} 1`,
+		`urlshrt_grpc_request_duration_seconds_count{code="OK",method="/urlshrt.URLService/Get"} 1`,
+		`urlshrt_storage_operation_duration_seconds_count{operation="Get",result="ok"} 1`,
+		`urlshrt_storage_operation_duration_seconds_count{operation="Get",result="error"} 1`,
+		`urlshrt_redirects_total{result="gone"} 1`,
+		"go_memstats_heap_alloc_bytes",
+	}
+	for _, want := range tests {
+		assert.Contains(t, body, want)
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// мидлвар, который учитывает запросы в метриках: количество и длительность по маршруту и статусу.
+// маршрут берется из шаблона, по которому сработал обработчик, — /:short, а не сам короткий адрес
+func Metrics(m *metrics.Metrics) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+
+		m.ObserveHTTP(ctx.Method(), ctx.Route().Path, responseStatus(ctx, err), time.Since(start))
+		return err
+	}
+}
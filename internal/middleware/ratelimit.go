@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/auth"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// мидлвар, который ограничивает частоту запросов клиента.
+// клиент с действительным токеном ограничивается по ID пользователя, остальные — по ip-адресу.
+// при превышении лимита возвращается 429 и заголовок Retry-After в секундах
+func RateLimit(limiter *ratelimit.Limiter, manager *auth.Manager) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ok, retryAfter := limiter.Allow(ratelimit.Key(requestUser(ctx, manager), ClientIP(ctx)))
+		if ok {
+			return ctx.Next()
+		}
+
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfterSeconds(retryAfter)))
+		return ctx.SendStatus(http.StatusTooManyRequests)
+	}
+}
+
+// получает ID пользователя из токена, если он действителен
+func requestUser(ctx *fiber.Ctx, manager *auth.Manager) string {
+	token := strings.TrimPrefix(ctx.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = ctx.Cookies("user")
+	}
+
+	if token == "" {
+		return ""
+	}
+
+	user, err := manager.Verify(token)
+	if err != nil {
+		return ""
+	}
+
+	return user
+}
+
+// Retry-After задается в целых секундах — округляем вверх
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
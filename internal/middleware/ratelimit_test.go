@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/augustjourney/urlshrt/internal/auth"
+	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	manager, err := auth.New("secret", nil, 0)
+	require.NoError(t, err)
+
+	token, err := manager.Issue("user-1")
+	require.NoError(t, err)
+
+	// тестовые запросы приходят с 0.0.0.0 — это доверенный прокси, адрес клиента берется из X-Real-IP
+	trustProxy(t, "0.0.0.0/32")
+
+	app := fiber.New()
+	app.Use(RateLimit(ratelimit.New(0.1, 2), manager))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	doRequest := func(ip string, token string) *http.Response {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Real-IP", ip)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := app.Test(req, 1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, doRequest("192.168.0.1", "").StatusCode)
+	}
+
+	resp := doRequest("192.168.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "10", resp.Header.Get("Retry-After"))
+
+	// другой адрес ограничивается отдельно
+	assert.Equal(t, http.StatusOK, doRequest("192.168.0.2", "").StatusCode)
+
+	// пользователь с токеном ограничивается по ID, а не по адресу
+	assert.Equal(t, http.StatusOK, doRequest("192.168.0.1", token).StatusCode)
+	assert.Equal(t, http.StatusOK, doRequest("192.168.0.3", token).StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest("192.168.0.4", token).StatusCode)
+
+	// поддельный токен не дает отдельного лимита
+	assert.Equal(t, http.StatusTooManyRequests, doRequest("192.168.0.1", "forged").StatusCode)
+}
+
+func TestRateLimitUntrustedRealIP(t *testing.T) {
+	manager, err := auth.New("secret", nil, 0)
+	require.NoError(t, err)
+
+	trustProxy(t, "10.0.0.0/8")
+
+	app := fiber.New()
+	app.Use(RateLimit(ratelimit.New(0.1, 1), manager))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	doRequest := func(ip string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Real-IP", ip)
+		resp, err := app.Test(req, 1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// запрос пришел не от доверенного прокси — новый адрес в заголовке не дает нового лимита
+	assert.Equal(t, http.StatusOK, doRequest("192.168.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, doRequest("192.168.0.2"))
+}
+
+// задает доверенную подсеть прокси на время теста
+func trustProxy(t *testing.T, subnet string) {
+	cfg := config.New()
+	previous := cfg.TrustedSubnet
+	cfg.TrustedSubnet = subnet
+	t.Cleanup(func() { cfg.TrustedSubnet = previous })
+}
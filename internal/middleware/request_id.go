@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// мидлвар, который присваивает запросу ID — берет его из заголовка X-Request-ID или создает новый.
+// ID возвращается в том же заголовке ответа и попадает в логи запроса
+func RequestID(ctx *fiber.Ctx) error {
+	// fiber переиспользует буферы запроса, а ID живет в контексте дольше
+	requestID := logger.RequestIDOrNew(utils.CopyString(ctx.Get(logger.RequestIDHeader)))
+
+	ctx.Set(logger.RequestIDHeader, requestID)
+	ctx.SetUserContext(logger.WithRequestID(ctx.UserContext(), requestID))
+
+	return ctx.Next()
+}
@@ -1,19 +1,47 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/augustjourney/urlshrt/internal/logger"
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
 )
 
-// Middleware — который логгирует входящие http-запросы
+// Middleware — который логгирует входящие http-запросы.
+// пользователь и ID запроса берутся из контекста — их туда кладут контроллер и RequestID
 func RequestLogger(ctx *fiber.Ctx) error {
 	start := time.Now()
-	result := ctx.Next()
-	duration := time.Since(start).Milliseconds()
-	log := fmt.Sprintf("HTTP Request – Method: %s, Path: %s, Duration: %dms, Status: %d, ContentLength: %d", ctx.Method(), ctx.OriginalURL(), duration, ctx.Response().StatusCode(), len(ctx.Response().Body()))
-	logger.Log.Info(log)
-	return result
+	err := ctx.Next()
+
+	status := responseStatus(ctx, err)
+	entry := logger.FromContext(ctx.UserContext()).WithFields(logrus.Fields{
+		"method":         ctx.Method(),
+		"path":           ctx.Path(),
+		"status":         status,
+		"duration_ms":    time.Since(start).Milliseconds(),
+		"content_length": len(ctx.Response().Body()),
+	})
+
+	if status >= fiber.StatusInternalServerError {
+		entry.Error("HTTP request")
+	} else {
+		entry.Info("HTTP request")
+	}
+
+	return err
+}
+
+// статус ответа: ошибку в ответ превратит обработчик ошибок fiber — уже после мидлваров
+func responseStatus(ctx *fiber.Ctx, err error) int {
+	if err == nil {
+		return ctx.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
 }
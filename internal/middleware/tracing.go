@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"github.com/augustjourney/urlshrt/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// мидлвар, который начинает спан запроса — дочерний для traceparent из заголовков, если он есть.
+// спан кладется в контекст запроса, а его traceparent возвращается в заголовках ответа
+func Tracing(ctx *fiber.Ctx) error {
+	propagator := otel.GetTextMapPropagator()
+	parent := propagator.Extract(ctx.UserContext(), requestHeaders{ctx})
+
+	spanCtx, span := tracing.Start(parent, "HTTP "+ctx.Method(), trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	ctx.SetUserContext(spanCtx)
+	propagator.Inject(spanCtx, responseHeaders{ctx})
+
+	err := ctx.Next()
+
+	// маршрут известен только после того, как сработал обработчик
+	route := ctx.Route().Path
+	status := responseStatus(ctx, err)
+
+	span.SetName(ctx.Method() + " " + route)
+	span.SetAttributes(
+		attribute.String("http.request.method", ctx.Method()),
+		attribute.String("http.route", route),
+		attribute.Int("http.response.status_code", status),
+	)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, "")
+	}
+
+	return err
+}
+
+// заголовки запроса, из которых читается traceparent
+type requestHeaders struct {
+	ctx *fiber.Ctx
+}
+
+func (h requestHeaders) Get(key string) string {
+	return h.ctx.Get(key)
+}
+
+func (h requestHeaders) Set(key string, value string) {
+	h.ctx.Request().Header.Set(key, value)
+}
+
+func (h requestHeaders) Keys() []string {
+	var keys []string
+	h.ctx.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// заголовки ответа, в которые пишется traceparent
+type responseHeaders struct {
+	ctx *fiber.Ctx
+}
+
+func (h responseHeaders) Get(key string) string {
+	return h.ctx.GetRespHeader(key)
+}
+
+func (h responseHeaders) Set(key string, value string) {
+	h.ctx.Set(key, value)
+}
+
+func (h responseHeaders) Keys() []string {
+	var keys []string
+	h.ctx.Response().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -10,28 +10,53 @@ import (
 )
 
 // мидлвар, который проверяет находится ли ip-адрес клиента
-// в доверенной подсети из конфига TrustedSubnet
+// в доверенной подсети из конфига TrustedSubnet.
+// адрес берется через ClientIP, без подсети в конфиге служебные методы закрыты для всех
 func IPInTrustedSubnet(ctx *fiber.Ctx) error {
-	ip := ctx.Get("X-Real-IP")
-	if ip == "" {
-		return ctx.SendStatus(http.StatusForbidden)
-	}
-
 	cfg := config.New()
 
 	if cfg.TrustedSubnet == "" {
-		return ctx.Next()
+		return ctx.SendStatus(http.StatusForbidden)
 	}
 
 	_, subnet, err := net.ParseCIDR(cfg.TrustedSubnet)
 	if err != nil {
-		logger.Log.Error("could not parse cidr in IPInTrustedSubnet", err.Error())
+		logger.Log.WithError(err).Error("Could not parse trusted subnet")
 		return ctx.SendStatus(http.StatusInternalServerError)
 	}
 
-	if subnet == nil || !subnet.Contains(net.ParseIP(ip)) {
+	if !subnet.Contains(net.ParseIP(ClientIP(ctx))) {
 		return ctx.SendStatus(http.StatusForbidden)
 	}
 
 	return ctx.Next()
 }
+
+// получает ip-адрес клиента. заголовку X-Real-IP верим, только если запрос пришел
+// от прокси из доверенной подсети TrustedSubnet — иначе клиент подставил бы в него любой адрес
+func ClientIP(ctx *fiber.Ctx) string {
+	ip := ctx.IP()
+
+	realIP := ctx.Get("X-Real-IP")
+	if realIP == "" || !InTrustedSubnet(ip) {
+		return ip
+	}
+
+	return realIP
+}
+
+// проверяет, что адрес в доверенной подсети; без подсети в конфиге не доверяем никому
+func InTrustedSubnet(ip string) bool {
+	cfg := config.New()
+
+	if cfg.TrustedSubnet == "" {
+		return false
+	}
+
+	_, subnet, err := net.ParseCIDR(cfg.TrustedSubnet)
+	if err != nil {
+		return false
+	}
+
+	return subnet.Contains(net.ParseIP(ip))
+}
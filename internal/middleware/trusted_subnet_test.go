@@ -5,66 +5,52 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/logger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// тестовые запросы приходят с 0.0.0.0
 func TestIPInTrustedSubnet(t *testing.T) {
-	app := fiber.New()
-
-	cfg := config.New()
-
-	cfg.TrustedSubnet = "192.168.0.0/24"
-
-	app.Use(IPInTrustedSubnet)
-
-	req := httptest.NewRequest("GET", "/", nil)
-	req.Header.Set("X-Real-IP", "192.168.0.1")
-
-	resp, err := app.Test(req, 1)
-
-	require.NoError(t, err)
-
-	defer resp.Body.Close()
-
-	assert.NotEqual(t, http.StatusForbidden, resp.StatusCode)
-}
-
-func TestIPInTrustedSubnetWithIpNotInSubnet(t *testing.T) {
-	app := fiber.New()
-
-	cfg := config.New()
-
-	cfg.TrustedSubnet = "145.132.0.0/24"
-
-	app.Use(IPInTrustedSubnet)
-
-	req := httptest.NewRequest("GET", "/", nil)
-	req.Header.Set("X-Real-IP", "192.168.0.1")
-
-	resp, err := app.Test(req, 1)
-
-	require.NoError(t, err)
-
-	defer resp.Body.Close()
-
-	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
-}
-
-func TestIPInTrustedSubnetWithEmptyIp(t *testing.T) {
-	app := fiber.New()
-
-	app.Use(IPInTrustedSubnet)
-
-	req := httptest.NewRequest("GET", "/", nil)
-
-	resp, err := app.Test(req, 1)
-
-	require.NoError(t, err)
-
-	defer resp.Body.Close()
-
-	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	logger.New()
+
+	tests := []struct {
+		name   string
+		subnet string
+		realIP string
+		code   int
+	}{
+		{name: "client in subnet", subnet: "0.0.0.0/32", code: http.StatusOK},
+		{name: "client behind trusted proxy", subnet: "0.0.0.0/8", realIP: "0.0.0.1", code: http.StatusOK},
+		{name: "client behind trusted proxy not in subnet", subnet: "0.0.0.0/32", realIP: "192.168.0.1", code: http.StatusForbidden},
+		{name: "forged header from outside subnet", subnet: "192.168.0.0/24", realIP: "192.168.0.1", code: http.StatusForbidden},
+		{name: "ip not in subnet", subnet: "145.132.0.0/24", code: http.StatusForbidden},
+		{name: "no subnet", subnet: "", code: http.StatusForbidden},
+		{name: "no subnet with header", subnet: "", realIP: "192.168.0.1", code: http.StatusForbidden},
+		{name: "invalid subnet", subnet: "not-a-cidr", code: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustProxy(t, tt.subnet)
+
+			app := fiber.New()
+			app.Use(IPInTrustedSubnet)
+			app.Get("/", func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			resp, err := app.Test(req, 1)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.code, resp.StatusCode)
+		})
+	}
 }
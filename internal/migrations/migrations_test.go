@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(files, "sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		// номера идут подряд с единицы
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr bool
+	}{
+		{
+			name: "Sorted by version",
+			files: fstest.MapFS{
+				"sql/0010_b.up.sql":   {Data: []byte("b")},
+				"sql/0010_b.down.sql": {Data: []byte("b")},
+				"sql/0002_a.up.sql":   {Data: []byte("a")},
+				"sql/0002_a.down.sql": {Data: []byte("a")},
+			},
+		},
+		{
+			name: "Missing down",
+			files: fstest.MapFS{
+				"sql/0001_a.up.sql": {Data: []byte("a")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Wrong file name",
+			files: fstest.MapFS{
+				"sql/create_urls.sql": {Data: []byte("a")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Different names for one version",
+			files: fstest.MapFS{
+				"sql/0001_a.up.sql":   {Data: []byte("a")},
+				"sql/0001_b.down.sql": {Data: []byte("b")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files, "sql")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMigration)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, migrations, 2)
+			assert.Equal(t, int64(2), migrations[0].Version)
+			assert.Equal(t, "a", migrations[0].Name)
+			assert.Equal(t, int64(10), migrations[1].Version)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS urls (
+	id SERIAL PRIMARY KEY NOT NULL,
+	uuid VARCHAR(50) NOT NULL,
+	short VARCHAR(50) NOT NULL,
+	original VARCHAR NOT NULL,
+	UNIQUE(short)
+);
+
+CREATE INDEX IF NOT EXISTS short_idx ON urls (short);
+
+CREATE UNIQUE INDEX IF NOT EXISTS original_unique_idx ON urls (original);
@@ -0,0 +1,3 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS is_deleted;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS user_uuid;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS clicks (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	short VARCHAR(64) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	referer VARCHAR NOT NULL DEFAULT '',
+	user_agent VARCHAR NOT NULL DEFAULT '',
+	ip VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS clicks_short_idx ON clicks (short, created_at);
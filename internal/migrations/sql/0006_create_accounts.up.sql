@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS accounts (
+	id VARCHAR(64) PRIMARY KEY NOT NULL,
+	name VARCHAR NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id VARCHAR(64) PRIMARY KEY NOT NULL,
+	account_id VARCHAR(64) NOT NULL REFERENCES accounts (id),
+	prefix VARCHAR(32) NOT NULL,
+	key_hash VARCHAR(64) NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS is_blocked BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS url_changes (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	short VARCHAR(50) NOT NULL,
+	changed_by VARCHAR NOT NULL,
+	old_original VARCHAR,
+	new_original VARCHAR,
+	old_user_uuid VARCHAR,
+	new_user_uuid VARCHAR,
+	changed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS url_changes_short_idx ON url_changes (short);
@@ -0,0 +1,6 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- время удаления уже удаленных ссылок неизвестно — отсчитываем срок хранения с момента миграции
+UPDATE urls SET deleted_at = now() WHERE is_deleted AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS urls_deleted_at_idx ON urls (deleted_at) WHERE is_deleted;
@@ -19,12 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	URLService_Create_FullMethodName      = "/URLService/Create"
-	URLService_Get_FullMethodName         = "/URLService/Get"
-	URLService_CreateBatch_FullMethodName = "/URLService/CreateBatch"
-	URLService_GetUserURLs_FullMethodName = "/URLService/GetUserURLs"
-	URLService_DeleteBatch_FullMethodName = "/URLService/DeleteBatch"
-	URLService_GetStats_FullMethodName    = "/URLService/GetStats"
+	URLService_Create_FullMethodName        = "/URLService/Create"
+	URLService_Get_FullMethodName           = "/URLService/Get"
+	URLService_CreateBatch_FullMethodName   = "/URLService/CreateBatch"
+	URLService_GetUserURLs_FullMethodName   = "/URLService/GetUserURLs"
+	URLService_DeleteBatch_FullMethodName   = "/URLService/DeleteBatch"
+	URLService_GetStats_FullMethodName      = "/URLService/GetStats"
+	URLService_GetURLStats_FullMethodName   = "/URLService/GetURLStats"
+	URLService_Update_FullMethodName        = "/URLService/Update"
+	URLService_GetURLChanges_FullMethodName = "/URLService/GetURLChanges"
+	URLService_RestoreBatch_FullMethodName  = "/URLService/RestoreBatch"
 )
 
 // URLServiceClient is the client API for URLService service.
@@ -37,6 +41,10 @@ type URLServiceClient interface {
 	GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error)
 	DeleteBatch(ctx context.Context, in *DeleteBatchRequest, opts ...grpc.CallOption) (*DeleteBatchResponse, error)
 	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	GetURLStats(ctx context.Context, in *GetURLStatsRequest, opts ...grpc.CallOption) (*GetURLStatsResponse, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+	GetURLChanges(ctx context.Context, in *GetURLChangesRequest, opts ...grpc.CallOption) (*GetURLChangesResponse, error)
+	RestoreBatch(ctx context.Context, in *RestoreBatchRequest, opts ...grpc.CallOption) (*RestoreBatchResponse, error)
 }
 
 type uRLServiceClient struct {
@@ -107,6 +115,46 @@ func (c *uRLServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, op
 	return out, nil
 }
 
+func (c *uRLServiceClient) GetURLStats(ctx context.Context, in *GetURLStatsRequest, opts ...grpc.CallOption) (*GetURLStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetURLStatsResponse)
+	err := c.cc.Invoke(ctx, URLService_GetURLStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateResponse)
+	err := c.cc.Invoke(ctx, URLService_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) GetURLChanges(ctx context.Context, in *GetURLChangesRequest, opts ...grpc.CallOption) (*GetURLChangesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetURLChangesResponse)
+	err := c.cc.Invoke(ctx, URLService_GetURLChanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLServiceClient) RestoreBatch(ctx context.Context, in *RestoreBatchRequest, opts ...grpc.CallOption) (*RestoreBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreBatchResponse)
+	err := c.cc.Invoke(ctx, URLService_RestoreBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLServiceServer is the server API for URLService service.
 // All implementations must embed UnimplementedURLServiceServer
 // for forward compatibility
@@ -117,6 +165,10 @@ type URLServiceServer interface {
 	GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error)
 	DeleteBatch(context.Context, *DeleteBatchRequest) (*DeleteBatchResponse, error)
 	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	GetURLStats(context.Context, *GetURLStatsRequest) (*GetURLStatsResponse, error)
+	Update(context.Context, *UpdateRequest) (*UpdateResponse, error)
+	GetURLChanges(context.Context, *GetURLChangesRequest) (*GetURLChangesResponse, error)
+	RestoreBatch(context.Context, *RestoreBatchRequest) (*RestoreBatchResponse, error)
 	mustEmbedUnimplementedURLServiceServer()
 }
 
@@ -142,6 +194,18 @@ func (UnimplementedURLServiceServer) DeleteBatch(context.Context, *DeleteBatchRe
 func (UnimplementedURLServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
+func (UnimplementedURLServiceServer) GetURLStats(context.Context, *GetURLStatsRequest) (*GetURLStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLStats not implemented")
+}
+func (UnimplementedURLServiceServer) Update(context.Context, *UpdateRequest) (*UpdateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedURLServiceServer) GetURLChanges(context.Context, *GetURLChangesRequest) (*GetURLChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLChanges not implemented")
+}
+func (UnimplementedURLServiceServer) RestoreBatch(context.Context, *RestoreBatchRequest) (*RestoreBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreBatch not implemented")
+}
 func (UnimplementedURLServiceServer) mustEmbedUnimplementedURLServiceServer() {}
 
 // UnsafeURLServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -263,6 +327,78 @@ func _URLService_GetStats_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLService_GetURLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GetURLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GetURLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GetURLStats(ctx, req.(*GetURLStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_GetURLChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).GetURLChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_GetURLChanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).GetURLChanges(ctx, req.(*GetURLChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLService_RestoreBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServiceServer).RestoreBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLService_RestoreBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServiceServer).RestoreBatch(ctx, req.(*RestoreBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // URLService_ServiceDesc is the grpc.ServiceDesc for URLService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -294,6 +430,22 @@ var URLService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetStats",
 			Handler:    _URLService_GetStats_Handler,
 		},
+		{
+			MethodName: "GetURLStats",
+			Handler:    _URLService_GetURLStats_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _URLService_Update_Handler,
+		},
+		{
+			MethodName: "GetURLChanges",
+			Handler:    _URLService_GetURLChanges_Handler,
+		},
+		{
+			MethodName: "RestoreBatch",
+			Handler:    _URLService_RestoreBatch_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "urls.proto",
@@ -0,0 +1,108 @@
+// модуль ratelimit ограничивает частоту запросов клиентов по алгоритму token bucket.
+// у каждого клиента — пользователя или ip-адреса — своя корзина токенов:
+// она пополняется с постоянной скоростью и вмещает не больше burst токенов.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// как часто удаляются корзины клиентов, которые давно не делали запросов
+const sweepInterval = time.Minute
+
+// корзина токенов одного клиента
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// ограничитель частоты запросов
+type Limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// ограничители для разных видов запросов
+type Limits struct {
+	// создание ссылок
+	Create *Limiter
+	// переходы по коротким ссылкам
+	Redirect *Limiter
+}
+
+// проверяет, можно ли выполнить запрос клиента с ключом key.
+// если нельзя — возвращает время, через которое появится следующий токен
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+
+	return false, wait
+}
+
+// удаляет корзины, которые успели наполниться целиком — они ничем не отличаются от новых
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+
+	l.lastSweep = now
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ключ клиента — ID пользователя, если он известен, иначе ip-адрес
+func Key(user string, ip string) string {
+	if user != "" {
+		return "user:" + user
+	}
+	return "ip:" + ip
+}
+
+// создает новый ограничитель: rate — запросов в секунду, burst — сколько запросов можно сделать разом.
+// при rate <= 0 ограничение выключено
+func New(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
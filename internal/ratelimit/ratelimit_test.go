@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter := New(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	// разом можно сделать burst запросов
+	for i := 0; i < 3; i++ {
+		ok, _ := limiter.Allow("user:1")
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter := limiter.Allow("user:1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// у другого клиента своя корзина
+	ok, _ = limiter.Allow("ip:10.0.0.1")
+	assert.True(t, ok)
+
+	// за полсекунды появляется один токен
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = limiter.Allow("user:1")
+	assert.True(t, ok)
+
+	ok, _ = limiter.Allow("user:1")
+	assert.False(t, ok)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter := New(1, 1)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("user:1")
+	now = now.Add(sweepInterval)
+	limiter.Allow("user:2")
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "user:2")
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	var nilLimiter *Limiter
+
+	for _, limiter := range []*Limiter{New(0, 1), nilLimiter} {
+		for i := 0; i < 100; i++ {
+			ok, _ := limiter.Allow("user:1")
+			assert.True(t, ok)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// модуль screening проверяет адреса ссылок по списку заблокированных хостов.
+// список — текстовый файл, одно правило на строку:
+//
+//	evil.com          — точное совпадение хоста
+//	*.evil.com        — любой поддомен evil.com, но не сам evil.com
+//	re:^login-.*\.io$ — регулярное выражение для хоста
+//
+// пустые строки и строки, начинающиеся с #, пропускаются.
+// файл перечитывается, когда меняется, — перезапуск сервиса не нужен.
+package screening
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"golang.org/x/net/idna"
+)
+
+// префикс правила с регулярным выражением
+const regexPrefix = "re:"
+
+// Ошибка если в файле списка есть неверное правило
+var ErrInvalidRule = errors.New("invalid blocklist rule")
+
+// правила списка
+type rules struct {
+	exact    map[string]string
+	suffixes []wildcard
+	regexps  []*regexp.Regexp
+}
+
+// правило для поддоменов: суффикс хоста и исходная запись
+type wildcard struct {
+	suffix string
+	rule   string
+}
+
+// проверяет адреса по списку заблокированных хостов
+type Screener struct {
+	path string
+
+	mu      sync.RWMutex
+	rules   *rules
+	modTime time.Time
+	size    int64
+}
+
+// проверяет, заблокирован ли хост ссылки, и возвращает сработавшее правило
+func (s *Screener) Match(rawURL string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+
+	host, err := normalizeHost(parsed.Hostname())
+	if err != nil || host == "" {
+		return "", false
+	}
+
+	s.mu.RLock()
+	r := s.rules
+	s.mu.RUnlock()
+
+	return r.match(host)
+}
+
+// перечитывает список, если файл изменился с прошлой загрузки.
+// если в новом списке ошибка — остается прежний
+func (s *Screener) Reload() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	changed := !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+	s.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	r, err := load(s.path)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.rules = r
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	s.mu.Unlock()
+
+	return true, nil
+}
+
+// как часто Watch проверяет файл списка, если interval не положительный
+const DefaultReloadInterval = 10 * time.Second
+
+// следит за файлом списка и перечитывает его при изменении — блокируется до отмены контекста
+func (s *Screener) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := s.Reload()
+			if err != nil {
+				logger.Log.WithError(err).WithField("path", s.path).Error("Could not reload blocklist")
+				continue
+			}
+			if reloaded {
+				logger.Log.WithField("path", s.path).Info("Reloaded blocklist")
+			}
+		}
+	}
+}
+
+func (r *rules) match(host string) (string, bool) {
+	if rule, ok := r.exact[host]; ok {
+		return rule, true
+	}
+
+	for _, w := range r.suffixes {
+		if strings.HasSuffix(host, w.suffix) {
+			return w.rule, true
+		}
+	}
+
+	for _, re := range r.regexps {
+		if re.MatchString(host) {
+			return regexPrefix + re.String(), true
+		}
+	}
+
+	return "", false
+}
+
+// читает правила из файла
+func load(path string) (*rules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := &rules{exact: make(map[string]string)}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		rule := strings.TrimSpace(scanner.Text())
+
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+
+		err = r.add(rule)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+
+	return r, scanner.Err()
+}
+
+// разбирает правило и добавляет его в список
+func (r *rules) add(rule string) error {
+	if pattern, ok := strings.CutPrefix(rule, regexPrefix); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%w %q: %s", ErrInvalidRule, rule, err)
+		}
+		r.regexps = append(r.regexps, re)
+		return nil
+	}
+
+	if domain, ok := strings.CutPrefix(rule, "*."); ok {
+		host, err := normalizeHost(domain)
+		if err != nil || host == "" {
+			return fmt.Errorf("%w %q", ErrInvalidRule, rule)
+		}
+		r.suffixes = append(r.suffixes, wildcard{suffix: "." + host, rule: rule})
+		return nil
+	}
+
+	host, err := normalizeHost(rule)
+	if err != nil || host == "" || strings.ContainsAny(host, "*/") {
+		return fmt.Errorf("%w %q", ErrInvalidRule, rule)
+	}
+	r.exact[host] = rule
+
+	return nil
+}
+
+// приводит хост к тому же виду, что и хосты сокращаемых ссылок
+func normalizeHost(host string) (string, error) {
+	return idna.Lookup.ToASCII(strings.TrimSuffix(strings.ToLower(host), "."))
+}
+
+// создает проверку по списку из файла
+func New(path string) (*Screener, error) {
+	s := &Screener{
+		path:  path,
+		rules: &rules{exact: make(map[string]string)},
+	}
+
+	_, err := s.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
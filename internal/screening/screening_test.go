@@ -0,0 +1,131 @@
+package screening
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBlocklist(t *testing.T, path string, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0666))
+}
+
+func TestScreener_Match(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeBlocklist(t, path, `
+# фишинг
+evil.com
+*.bad.org
+Пример.РФ
+re:^login-[a-z]+\.io$
+`)
+
+	s, err := New(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		url  string
+		rule string
+	}{
+		{name: "exact", url: "http://evil.com/path", rule: "evil.com"},
+		{name: "exact is case insensitive", url: "https://EVIL.com", rule: "evil.com"},
+		{name: "exact with port", url: "https://evil.com:8443/a", rule: "evil.com"},
+		{name: "exact does not match subdomain", url: "http://www.evil.com"},
+		{name: "wildcard subdomain", url: "http://login.bad.org", rule: "*.bad.org"},
+		{name: "wildcard nested subdomain", url: "http://a.b.bad.org", rule: "*.bad.org"},
+		{name: "wildcard does not match domain", url: "http://bad.org"},
+		{name: "wildcard does not match suffix", url: "http://notbad.org"},
+		{name: "idn rule matches punycode", url: "http://xn--e1afmkfd.xn--p1ai", rule: "Пример.РФ"},
+		{name: "regex", url: "https://login-bank.io/x", rule: `re:^login-[a-z]+\.io$`},
+		{name: "regex no match", url: "https://login-42.io"},
+		{name: "allowed host", url: "https://example.com"},
+		{name: "not a url", url: "::"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := s.Match(tt.url)
+			assert.Equal(t, tt.rule != "", ok)
+			assert.Equal(t, tt.rule, rule)
+		})
+	}
+}
+
+func TestNew_InvalidRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "bad regex", content: "re:(unclosed"},
+		{name: "empty wildcard", content: "*."},
+		{name: "wildcard in the middle", content: "a.*.com"},
+		{name: "url instead of host", content: "http://evil.com/path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "blocklist.txt")
+			writeBlocklist(t, path, tt.content)
+
+			_, err := New(path)
+			assert.ErrorIs(t, err, ErrInvalidRule)
+		})
+	}
+
+	_, err := New(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestScreener_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeBlocklist(t, path, "evil.com\n")
+
+	s, err := New(path)
+	require.NoError(t, err)
+
+	// файл не менялся — перечитывать нечего
+	reloaded, err := s.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeBlocklist(t, path, "other.com\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+
+	reloaded, err = s.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	_, ok := s.Match("http://evil.com")
+	assert.False(t, ok)
+	_, ok = s.Match("http://other.com")
+	assert.True(t, ok)
+
+	// в новом списке ошибка — прежние правила остаются
+	writeBlocklist(t, path, "re:(\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+
+	_, err = s.Reload()
+	assert.ErrorIs(t, err, ErrInvalidRule)
+	_, ok = s.Match("http://other.com")
+	assert.True(t, ok)
+}
+
+func TestScreener_WatchInvalidInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeBlocklist(t, path, "evil.com\n")
+
+	s, err := New(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// нулевой интервал заменяется интервалом по умолчанию
+	assert.NotPanics(t, func() { s.Watch(ctx, 0) })
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+// допустимые символы и длина псевдонима короткой ссылки
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,64}$`)
+
+// зарезервированные псевдонимы — совпадают с адресами самого сервиса
+var reservedAliases = map[string]bool{
+	"api":     true,
+	"ping":    true,
+	"healthz": true,
+	"readyz":  true,
+}
+
+// проверяет, что псевдоним можно использовать как короткую ссылку
+func validateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return ErrInvalidAlias
+	}
+
+	if isReservedAlias(alias) {
+		return ErrInvalidAlias
+	}
+
+	return nil
+}
+
+// проверяет, совпадает ли короткий код с адресом самого сервиса
+func isReservedAlias(short string) bool {
+	return reservedAliases[strings.ToLower(short)]
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"time"
+)
+
+// вычисляет момент истечения срока жизни ссылки
+// из абсолютного времени expiresAt или относительного ttl — указать можно что-то одно
+func resolveExpiration(expiresAt *time.Time, ttl time.Duration, now time.Time) (*time.Time, error) {
+	if expiresAt != nil && ttl != 0 {
+		return nil, ErrInvalidExpiration
+	}
+
+	if ttl < 0 {
+		return nil, ErrInvalidExpiration
+	}
+
+	if ttl > 0 {
+		result := now.Add(ttl)
+		return &result, nil
+	}
+
+	if expiresAt == nil {
+		return nil, nil
+	}
+
+	if !expiresAt.After(now) {
+		return nil, ErrInvalidExpiration
+	}
+
+	return expiresAt, nil
+}
+
+// проверяет, истек ли срок жизни ссылки
+func isExpired(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt != nil && !now.Before(*expiresAt)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/augustjourney/urlshrt/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// Размер страницы ссылок пользователя
+const (
+	// если указан только ключ страницы
+	DefaultPageSize = 100
+	// больше не отдается за раз
+	MaxPageSize = 1000
+)
+
+// Порядок ссылок пользователя по времени создания
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// Ошибка если ключ страницы поврежден или получен не от сервиса
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Ошибка если размер страницы, порядок или фильтр указаны неверно
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// Параметры получения ссылок пользователя
+type ListOptions struct {
+	// Ключ страницы из NextCursor предыдущей, пустой — первая страница
+	Cursor string
+	// Сколько ссылок на странице, 0 — все ссылки, а вместе с Cursor — DefaultPageSize
+	Limit int
+	// OrderAsc — сначала старые, OrderDesc — сначала новые, пустой — OrderAsc
+	Order string
+	// Подстрока оригинального адреса, без учета регистра
+	Contains string
+	// Хост оригинального адреса
+	Host string
+}
+
+// Страница ссылок пользователя
+type UserURLsPage struct {
+	URLs []UserURLResult
+	// Ключ следующей страницы, пустой — страница последняя
+	NextCursor string
+}
+
+// кодирует ключ страницы: время создания и короткий адрес последней ссылки
+func encodeCursor(url storage.URL) string {
+	raw := url.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + url.Short
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*storage.ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, short, ok := strings.Cut(string(raw), "|")
+	if !ok || short == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &storage.ListCursor{CreatedAt: t, Short: short}, nil
+}
+
+// проверяет параметры и переводит их в параметры хранилища
+func (opts ListOptions) storageOptions() (storage.ListOptions, error) {
+	result := storage.ListOptions{
+		Limit:    opts.Limit,
+		Contains: opts.Contains,
+	}
+
+	if result.Limit < 0 {
+		return result, ErrInvalidListOptions
+	}
+	if result.Limit == 0 && opts.Cursor != "" {
+		result.Limit = DefaultPageSize
+	}
+	if result.Limit > MaxPageSize {
+		result.Limit = MaxPageSize
+	}
+
+	switch opts.Order {
+	case "", OrderAsc:
+	case OrderDesc:
+		result.Desc = true
+	default:
+		return result, ErrInvalidListOptions
+	}
+
+	if opts.Host != "" {
+		host, err := normalizeHost(strings.TrimSpace(opts.Host))
+		if err != nil {
+			return result, ErrInvalidListOptions
+		}
+		result.Host = strings.Trim(host, "[]")
+	}
+
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return result, err
+		}
+		result.After = after
+	}
+
+	return result, nil
+}
+
+// получает ссылки пользователя постранично
+func (s *Service) GetUserURLs(ctx context.Context, userUUID string, opts ListOptions) (_ UserURLsPage, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetUserURLs")
+	defer func() { tracing.End(span, err) }()
+
+	var page UserURLsPage
+
+	listOpts, err := opts.storageOptions()
+	if err != nil {
+		return page, err
+	}
+
+	// Без размера страницы и ключа отдаются все ссылки, как до появления страниц.
+	// Одна лишняя ссылка показывает, есть ли следующая страница
+	limit := listOpts.Limit
+	if limit > 0 {
+		listOpts.Limit++
+	}
+
+	urls, err := s.repo.ListByUser(ctx, userUUID, listOpts)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not list user urls")
+		return page, ErrInternalError
+	}
+
+	if limit > 0 && len(urls) > limit {
+		urls = urls[:limit]
+		page.NextCursor = encodeCursor(urls[limit-1])
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"user":     userUUID,
+		"count":    len(urls),
+		"has_next": page.NextCursor != "",
+	}).Debug("Listed user urls")
+
+	page.URLs = make([]UserURLResult, 0, len(urls))
+
+	for _, url := range urls {
+		result := UserURLResult{
+			ShortURL:    s.buildShortURL(url.Short),
+			OriginalURL: url.Original,
+		}
+		// У ссылок, сохраненных до появления времени создания, его нет
+		if !url.CreatedAt.IsZero() {
+			createdAt := url.CreatedAt
+			result.CreatedAt = &createdAt
+		}
+		page.URLs = append(page.URLs, result)
+	}
+
+	return page, nil
+}
@@ -3,15 +3,18 @@ package service
 
 import (
 	"context"
-	"crypto/sha256"
 	"errors"
-	"fmt"
-	"io"
+	"time"
 
+	"github.com/augustjourney/urlshrt/internal/analytics"
 	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/deleter"
 	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/shortcode"
 	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/augustjourney/urlshrt/internal/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Ошибка если ссылка не найдена
@@ -20,24 +23,169 @@ var ErrNotFound = errors.New("url not found")
 // Ошибка если ссылка удалена
 var ErrIsDeleted = errors.New("url is deleted")
 
+// Ошибка если срок жизни ссылки истек
+var ErrExpired = errors.New("url is expired")
+
+// Ошибка если срок жизни ссылки указан неверно
+var ErrInvalidExpiration = errors.New("invalid expiration")
+
 // Ошибка если произошла какая-то внутренняя ошибка
 var ErrInternalError = errors.New("internal error")
 
+// Ошибка если псевдоним не подходит по формату или зарезервирован
+var ErrInvalidAlias = errors.New("invalid alias")
+
+// Ошибка если псевдоним уже занят другой ссылкой
+var ErrAliasTaken = errors.New("alias is already taken")
+
+// Ошибка если ссылка не похожа на адрес
+var ErrInvalidURL = errors.New("invalid url")
+
+// Ошибка если ссылка отключена: ее адрес попал в список заблокированных
+var ErrIsBlocked = errors.New("url is blocked")
+
+// Ошибка если список заблокированных адресов не подключен
+var ErrNoBlocklist = errors.New("blocklist is not configured")
+
+// Ошибка если новый адрес ссылки уже сокращен в другую ссылку
+var ErrURLConflict = errors.New("url is already shortened")
+
+// Ошибка если при изменении ссылки не указано, что менять
+var ErrNothingToUpdate = errors.New("nothing to update")
+
+// Ошибка если пользователь, которому передается ссылка, неизвестен
+var ErrUnknownUser = errors.New("unknown user")
+
+// Ошибка если у ссылки из пачки нет correlation_id
+var ErrNoCorrelationID = errors.New("correlation_id is required")
+
+// сколько раз пробуем сохранить ссылку с новым кодом, если сгенерированный код уже занят
+const maxGenerateAttempts = 5
+
+// Генератор коротких кодов для ссылок.
+// attempt — номер попытки: при коллизии код генерируется заново с увеличенным attempt
+type ShortCodeGenerator interface {
+	Generate(ctx context.Context, original string, attempt int) (string, error)
+}
+
+// Проверка адреса по списку заблокированных — возвращает сработавшее правило
+type Screener interface {
+	Match(rawURL string) (rule string, ok bool)
+}
+
+// Результаты перехода по короткой ссылке
+const (
+	// ссылка найдена
+	RedirectHit = "hit"
+	// ссылки нет
+	RedirectMiss = "miss"
+	// ссылка удалена, истекла или отключена
+	RedirectGone = "gone"
+	// не удалось получить ссылку
+	RedirectError = "error"
+)
+
+// Справочник пользователей, которых нет среди владельцев ссылок, — например, аккаунтов
+type UserDirectory interface {
+	Exists(ctx context.Context, userID string) (bool, error)
+}
+
+// Получатель результатов переходов по коротким ссылкам
+type RedirectObserver interface {
+	ObserveRedirect(result string)
+}
+
 // сервис с методами по работе с ссылками
 type Service struct {
-	repo   storage.IRepo
-	config *config.Config
+	repo      storage.IRepo
+	config    *config.Config
+	analytics *analytics.Tracker
+	generator ShortCodeGenerator
+	deleter   *deleter.Deleter
+	screener  Screener
+	redirects RedirectObserver
+	users     UserDirectory
+}
+
+// Дополнительная настройка сервиса при создании
+type Option func(s *Service)
+
+// Задает генератор коротких кодов — по умолчанию код строится из хэша ссылки
+func WithGenerator(generator ShortCodeGenerator) Option {
+	return func(s *Service) {
+		s.generator = generator
+	}
+}
+
+// Подключает сбор статистики переходов по ссылкам
+func WithAnalytics(tracker *analytics.Tracker) Option {
+	return func(s *Service) {
+		s.analytics = tracker
+	}
+}
+
+// Удаляет ссылки в фоне — без него ссылки удаляются прямо во время запроса
+func WithDeleter(d *deleter.Deleter) Option {
+	return func(s *Service) {
+		s.deleter = d
+	}
+}
+
+// Не дает сокращать ссылки на заблокированные адреса
+func WithScreener(screener Screener) Option {
+	return func(s *Service) {
+		s.screener = screener
+	}
+}
+
+// Передает результаты переходов по коротким ссылкам — например, в метрики
+func WithRedirectObserver(observer RedirectObserver) Option {
+	return func(s *Service) {
+		s.redirects = observer
+	}
+}
+
+// Задает справочник пользователей, которым можно передавать ссылки, —
+// без него ссылку можно передать только тому, у кого уже есть ссылки
+func WithUserDirectory(users UserDirectory) Option {
+	return func(s *Service) {
+		s.users = users
+	}
 }
 
 // Интерфейс — который описывает методы сервиса
 type IService interface {
-	Shorten(originalURL string, userUUID string) (*ShortenResult, error)
-	FindOriginal(short string) (string, error)
-	ShortenBatch(batchURLs []BatchURL, userUUID string) ([]BatchResultURL, error)
+	Shorten(ctx context.Context, originalURL string, userUUID string, opts ShortenOptions) (*ShortenResult, error)
+	FindOriginal(ctx context.Context, short string) (string, error)
+	ShortenBatch(ctx context.Context, batchURLs []BatchURL, userUUID string) ([]BatchResultURL, error)
 	GenerateID() (string, error)
-	GetUserURLs(ctx context.Context, userUUID string) ([]UserURLResult, error)
+	GetUserURLs(ctx context.Context, userUUID string, opts ListOptions) (UserURLsPage, error)
 	DeleteBatch(ctx context.Context, shortIds []string, userID string) error
+	RestoreBatch(ctx context.Context, shortIds []string, userID string) ([]string, error)
 	GetStats(ctx context.Context) (GetStatsResult, error)
+	RecordClick(click analytics.Click)
+	GetURLStats(ctx context.Context, short string, userUUID string) (analytics.LinkStats, error)
+	DisableBlocked(ctx context.Context) ([]string, error)
+	UpdateURL(ctx context.Context, short string, userUUID string, opts UpdateOptions) (*UserURLResult, error)
+	GetURLChanges(ctx context.Context, short string, userUUID string) ([]storage.URLChange, error)
+}
+
+// Дополнительные параметры сокращения ссылки
+type ShortenOptions struct {
+	// Псевдоним, который будет использован вместо сгенерированной короткой ссылки
+	Alias string
+	// Момент, после которого ссылка перестает работать
+	ExpiresAt *time.Time
+	// Срок жизни ссылки с момента создания — альтернатива ExpiresAt
+	TTL time.Duration
+}
+
+// Что меняется в ссылке — пустые поля не меняются
+type UpdateOptions struct {
+	// Новый оригинальный адрес
+	OriginalURL string
+	// Пользователь, которому передается ссылка
+	UserUUID string
 }
 
 // Результат сокращения ссылки
@@ -48,41 +196,72 @@ type ShortenResult struct {
 
 // Структура ссылки при создании множества ссылок
 type BatchURL struct {
-	OriginalURL   string `json:"original_url"`
-	CorrelationID string `json:"correlation_id"`
+	OriginalURL   string     `json:"original_url"`
+	CorrelationID string     `json:"correlation_id"`
+	Alias         string     `json:"alias,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	// Срок жизни ссылки в секундах
+	TTL int64 `json:"ttl,omitempty"`
 }
 
+// Статусы ссылок в результате сокращения множества ссылок
+const (
+	// ссылка сокращена
+	BatchStatusCreated = "created"
+	// ссылка уже была сокращена раньше — ShortURL указывает на существующую
+	BatchStatusExisted = "existed"
+	// ссылка, псевдоним или срок жизни указаны неверно
+	BatchStatusInvalid = "invalid"
+	// ссылка не обработана
+	BatchStatusSkipped = "skipped"
+	// ссылку не удалось сохранить из-за внутренней ошибки
+	BatchStatusFailed = "failed"
+)
+
 // Результат сокращения множества ссылок
 type BatchResultURL struct {
-	ShortURL      string `json:"short_url"`
+	ShortURL      string `json:"short_url,omitempty"`
 	CorrelationID string `json:"correlation_id"`
+	Status        string `json:"status"`
+	// Почему ссылка не сокращена — для статусов invalid, skipped и failed
+	Error string `json:"error,omitempty"`
 }
 
 // Результат получения сокращенных ссылок конкретного пользователя
 type UserURLResult struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
 }
 
 // Результат получения внутренней статистики: количество ссылок, количество пользователей
+// и количество ссылок, которые ждут удаления
 type GetStatsResult struct {
-	Urls  int `json:"urls"`
-	Users int `json:"users"`
+	Urls             int `json:"urls"`
+	Users            int `json:"users"`
+	PendingDeletions int `json:"pending_deletions"`
 }
 
 // получает внутреннюю статистику: кол-во ссылок и пользователей
-func (s *Service) GetStats(ctx context.Context) (GetStatsResult, error) {
+func (s *Service) GetStats(ctx context.Context) (_ GetStatsResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetStats")
+	defer func() { tracing.End(span, err) }()
+
 	var result GetStatsResult
 	stats, err := s.repo.GetStats(ctx)
 
 	if err != nil {
-		logger.Log.Error("Could not get stats ", err)
+		logger.FromContext(ctx).WithError(err).Error("Could not get stats")
 		return result, err
 	}
 
 	result.Urls = stats.UrlsCount
 	result.Users = stats.UsersCount
 
+	if s.deleter != nil {
+		result.PendingDeletions = s.deleter.Pending()
+	}
+
 	return result, nil
 }
 
@@ -91,41 +270,129 @@ func (s *Service) GenerateID() (string, error) {
 	uuid, err := uuid.NewRandom()
 
 	if err != nil {
-		logger.Log.Error("Could not create uuid ", err)
+		logger.Log.WithError(err).Error("Could not create uuid")
 		return "", err
 	}
 
 	return uuid.String(), nil
 }
 
-func (s *Service) hashURL(url string) string {
-	hash := sha256.New()
-	io.WriteString(hash, url)
-	return fmt.Sprintf("%x", hash.Sum(nil))[:10]
-}
-
 func (s *Service) buildShortURL(short string) string {
 	return s.config.BaseURL + "/" + short
 }
 
+// генерирует короткий код, который не зарезервирован и которого нет среди taken
+// attempt — номер попытки сохранения: на каждой следующей генерируются другие коды
+func (s *Service) generateShort(ctx context.Context, originalURL string, attempt int, taken map[string]bool) (string, error) {
+	for i := 0; i < maxGenerateAttempts; i++ {
+		short, err := s.generator.Generate(ctx, originalURL, attempt*maxGenerateAttempts+i)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Could not generate short code")
+			return "", ErrInternalError
+		}
+
+		if isReservedAlias(short) || taken[short] {
+			continue
+		}
+
+		return short, nil
+	}
+
+	logger.FromContext(ctx).WithField("original_url", originalURL).Error("Could not generate free short code")
+	return "", ErrInternalError
+}
+
+// проверяет, занят ли короткий код какой-то ссылкой
+func (s *Service) isShortTaken(ctx context.Context, short string) bool {
+	url, err := s.repo.Get(ctx, short)
+	return err == nil && url != nil && url.Original != ""
+}
+
+// проверяет, сокращена ли уже ссылка с таким оригинальным адресом
+func (s *Service) isOriginalStored(ctx context.Context, original string) bool {
+	url, err := s.repo.GetByOriginal(ctx, original)
+	return err == nil && url != nil && url.Short != ""
+}
+
+// нормализует ссылку и проверяет, что ее адрес не заблокирован
+func (s *Service) screenURL(raw string) (string, error) {
+	originalURL, err := normalizeURL(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if s.screener == nil {
+		return originalURL, nil
+	}
+
+	if rule, ok := s.screener.Match(originalURL); ok {
+		return "", invalidURL(ReasonBlocked, "host is blocked by rule %q", rule)
+	}
+
+	return originalURL, nil
+}
+
 // сокращает оригинальную ссылку в короткую
-func (s *Service) Shorten(originalURL string, userUUID string) (*ShortenResult, error) {
-	short := s.hashURL(originalURL)
-	uuid, err := s.GenerateID()
+func (s *Service) Shorten(ctx context.Context, originalURL string, userUUID string, opts ShortenOptions) (_ *ShortenResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.Shorten")
+	defer func() { tracing.End(span, err) }()
+
 	result := ShortenResult{
 		ResultURL:     "",
 		AlreadyExists: false,
 	}
+
+	originalURL, err = s.screenURL(originalURL)
+	if err != nil {
+		return &result, err
+	}
+
+	if opts.Alias != "" {
+		if err := validateAlias(opts.Alias); err != nil {
+			return &result, err
+		}
+	}
+
+	expiresAt, err := resolveExpiration(opts.ExpiresAt, opts.TTL, time.Now())
+	if err != nil {
+		return &result, err
+	}
+
+	uuid, err := s.GenerateID()
 	if err != nil {
 		return &result, ErrInternalError
 	}
-	ctx := context.TODO()
-	err = s.repo.Create(ctx, storage.URL{
-		UUID:     uuid,
-		Short:    short,
-		Original: originalURL,
-		UserUUID: userUUID,
-	})
+
+	url := storage.URL{
+		UUID:      uuid,
+		Short:     opts.Alias,
+		Original:  originalURL,
+		UserUUID:  userUUID,
+		ExpiresAt: expiresAt,
+	}
+
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		if opts.Alias == "" {
+			url.Short, err = s.generateShort(ctx, originalURL, attempt, nil)
+			if err != nil {
+				return &result, err
+			}
+		}
+
+		err = s.repo.Create(ctx, url)
+
+		// Сгенерированный код занят: хэш той же самой ссылки дает тот же код —
+		// тогда она уже сокращена, иначе код совпал с другой ссылкой и пробуем следующий
+		if errors.Is(err, storage.ErrShortAlreadyExists) && opts.Alias == "" {
+			if s.isOriginalStored(ctx, originalURL) {
+				err = storage.ErrAlreadyExists
+				break
+			}
+			continue
+		}
+
+		break
+	}
 
 	if err != nil {
 		// Если приходит ошибка — уже есть такой url
@@ -143,108 +410,462 @@ func (s *Service) Shorten(originalURL string, userUUID string) (*ShortenResult,
 
 			return &result, err
 		}
+
+		// Псевдоним занят — если той же самой ссылкой,
+		// то это повторное сокращение, иначе конфликт псевдонима
+		if errors.Is(err, storage.ErrShortAlreadyExists) && opts.Alias != "" {
+			url, err := s.repo.Get(ctx, opts.Alias)
+			if err != nil || url == nil {
+				return &result, ErrInternalError
+			}
+
+			if url.Original != originalURL {
+				return &result, ErrAliasTaken
+			}
+
+			result.AlreadyExists = true
+			result.ResultURL = s.buildShortURL(url.Short)
+
+			return &result, nil
+		}
 		return &result, ErrInternalError
 	}
 
-	result.ResultURL = s.buildShortURL(short)
+	result.ResultURL = s.buildShortURL(url.Short)
 
 	return &result, nil
 }
 
-// сокращает массив оригинальных ссылок в короткие
-func (s *Service) ShortenBatch(batchURLs []BatchURL, userUUID string) ([]BatchResultURL, error) {
+// сокращает массив оригинальных ссылок в короткие.
+// результат на каждую ссылку — в том же порядке и со своим статусом:
+// неверные и пропущенные ссылки не мешают сократить остальные
+func (s *Service) ShortenBatch(ctx context.Context, batchURLs []BatchURL, userUUID string) (_ []BatchResultURL, err error) {
+	ctx, span := tracing.Start(ctx, "service.ShortenBatch")
+	defer func() { tracing.End(span, err) }()
+
+	result := make([]BatchResultURL, len(batchURLs))
+
+	// ссылки, которые будут сохранены, и их номера в batchURLs
 	var urls []storage.URL
-	var result []BatchResultURL
+	var positions []int
 
-	for _, url := range batchURLs {
+	aliases := make(map[string]bool)
+	now := time.Now()
+
+	fail := func(i int, status string, err error) {
+		result[i].Status = status
+		result[i].Error = err.Error()
+	}
+
+	for i, url := range batchURLs {
+		result[i].CorrelationID = url.CorrelationID
 
-		// Если url пришел без correlation_id
-		// То не обрабатываем
-		// Хотя наподумать — можно будет какой-то рандомный ID присваивать тогда
 		if url.CorrelationID == "" {
+			fail(i, BatchStatusSkipped, ErrNoCorrelationID)
 			continue
 		}
 
-		short := s.hashURL(url.OriginalURL)
+		originalURL, err := s.screenURL(url.OriginalURL)
+		if err != nil {
+			fail(i, BatchStatusInvalid, err)
+			continue
+		}
 
-		uuid, err := s.GenerateID()
+		expiresAt, err := resolveExpiration(url.ExpiresAt, time.Duration(url.TTL)*time.Second, now)
+		if err != nil {
+			fail(i, BatchStatusInvalid, err)
+			continue
+		}
 
+		if url.Alias != "" {
+			if err = validateAlias(url.Alias); err != nil {
+				fail(i, BatchStatusInvalid, err)
+				continue
+			}
+			if aliases[url.Alias] {
+				fail(i, BatchStatusInvalid, ErrAliasTaken)
+				continue
+			}
+			aliases[url.Alias] = true
+		}
+
+		uuid, err := s.GenerateID()
 		if err != nil {
-			return nil, err
+			return nil, ErrInternalError
 		}
 
 		urls = append(urls, storage.URL{
-			Short:    short,
-			Original: url.OriginalURL,
-			UUID:     uuid,
-			UserUUID: userUUID,
+			Short:     url.Alias,
+			Original:  originalURL,
+			UUID:      uuid,
+			UserUUID:  userUUID,
+			ExpiresAt: expiresAt,
 		})
+		positions = append(positions, i)
+	}
 
-		result = append(result, BatchResultURL{
-			CorrelationID: url.CorrelationID,
-			ShortURL:      s.config.BaseURL + "/" + short,
-		})
+	// ссылки, которые еще не сохранены
+	pending := make([]int, len(urls))
+	for j := range urls {
+		pending[j] = j
 	}
 
-	err := s.repo.CreateBatch(context.TODO(), urls)
+	for attempt := 0; attempt < maxGenerateAttempts && len(pending) > 0; attempt++ {
+		// Коды генерируются заново на каждой попытке,
+		// при этом не должны совпадать друг с другом и с псевдонимами
+		taken := make(map[string]bool, len(pending))
+		for alias := range aliases {
+			taken[alias] = true
+		}
 
-	if err != nil {
-		logger.Log.Error(err)
-		return result, ErrInternalError
+		batch := make([]storage.URL, 0, len(pending))
+
+		for _, j := range pending {
+			if !aliases[urls[j].Short] {
+				short, err := s.generateShort(ctx, urls[j].Original, attempt, taken)
+				if err != nil {
+					return nil, err
+				}
+				urls[j].Short = short
+				taken[short] = true
+			}
+			batch = append(batch, urls[j])
+		}
+
+		saved, err := s.repo.CreateBatch(ctx, batch)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Could not save batch")
+			return nil, ErrInternalError
+		}
+
+		var retry []int
+
+		for k, j := range pending {
+			i := positions[j]
+
+			switch saved[k].Status {
+			case storage.BatchCreated:
+				result[i].Status = BatchStatusCreated
+				result[i].ShortURL = s.buildShortURL(saved[k].Short)
+			case storage.BatchExisted:
+				result[i].Status = BatchStatusExisted
+				result[i].ShortURL = s.buildShortURL(saved[k].Short)
+			default:
+				// Занят псевдоним — повторять бессмысленно
+				if aliases[urls[j].Short] {
+					fail(i, BatchStatusInvalid, ErrAliasTaken)
+					continue
+				}
+				retry = append(retry, j)
+			}
+		}
+
+		pending = retry
+	}
+
+	for _, j := range pending {
+		logger.FromContext(ctx).WithField("original_url", urls[j].Original).Error("Could not find free short code")
+		fail(positions[j], BatchStatusFailed, ErrInternalError)
 	}
 
 	return result, nil
 }
 
+// находит оригинальную ссылку по короткому адресу и передает результат перехода наблюдателю
+func (s *Service) FindOriginal(ctx context.Context, short string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "service.FindOriginal")
+	defer func() { tracing.End(span, err) }()
+
+	original, err := s.findOriginal(ctx, short)
+
+	result := redirectResult(err)
+	span.SetAttributes(attribute.String("redirect.result", result))
+	if s.redirects != nil {
+		s.redirects.ObserveRedirect(result)
+	}
+	return original, err
+}
+
+// результат перехода по ошибке поиска ссылки
+func redirectResult(err error) string {
+	switch {
+	case err == nil:
+		return RedirectHit
+	case errors.Is(err, ErrNotFound):
+		return RedirectMiss
+	case errors.Is(err, ErrExpired), errors.Is(err, ErrIsDeleted), errors.Is(err, ErrIsBlocked):
+		return RedirectGone
+	}
+	return RedirectError
+}
+
 // находит оригинальную ссылку по короткому адресу
-func (s *Service) FindOriginal(short string) (string, error) {
-	url, err := s.repo.Get(context.TODO(), short)
+func (s *Service) findOriginal(ctx context.Context, short string) (string, error) {
+	url, err := s.repo.Get(ctx, short)
 	if err != nil {
 		return "", ErrInternalError
 	}
 	if url.Original == "" {
 		return "", ErrNotFound
 	}
+	// Проверяем срок жизни раньше удаления —
+	// истекшие ссылки фоновая задача помечает удаленными
+	if isExpired(url.ExpiresAt, time.Now()) {
+		return "", ErrExpired
+	}
 	if url.IsDeleted {
 		return "", ErrIsDeleted
 	}
+	if url.IsBlocked {
+		return "", ErrIsBlocked
+	}
 	return url.Original, nil
 }
 
-// удаляет массив ссылок
-func (s *Service) DeleteBatch(ctx context.Context, shortURLs []string, userID string) error {
+// удаляет массив ссылок — ставит в очередь, если удаление идет в фоне
+func (s *Service) DeleteBatch(ctx context.Context, shortURLs []string, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.DeleteBatch")
+	defer func() { tracing.End(span, err) }()
+
+	if s.deleter != nil {
+		err = s.deleter.Enqueue(ctx, shortURLs, userID)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Could not enqueue batch deletion")
+		}
+		return err
+	}
 
-	err := s.repo.Delete(ctx, shortURLs, userID)
+	err = s.repo.Delete(ctx, shortURLs, userID)
 	if err != nil {
-		logger.Log.Error("Could not delete batch: ", err)
+		logger.FromContext(ctx).WithError(err).Error("Could not delete batch")
 		return err
 	}
 
 	return nil
 }
 
-// получает ссылки для конкретного пользователя
-func (s *Service) GetUserURLs(ctx context.Context, userUUID string) ([]UserURLResult, error) {
-	urls, err := s.repo.GetByUserUUID(ctx, userUUID)
+// отключает уже сокращенные ссылки, адреса которых попали в список заблокированных,
+// и возвращает их короткие адреса
+func (s *Service) DisableBlocked(ctx context.Context) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "service.DisableBlocked")
+	defer func() { tracing.End(span, err) }()
+
+	if s.screener == nil {
+		return nil, ErrNoBlocklist
+	}
+
+	shorts, err := s.repo.BlockMatching(ctx, func(original string) bool {
+		_, ok := s.screener.Match(original)
+		return ok
+	})
 	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not disable blocked urls")
 		return nil, ErrInternalError
 	}
 
-	var result []UserURLResult
+	result := make([]string, 0, len(shorts))
+	for _, short := range shorts {
+		result = append(result, s.buildShortURL(short))
+	}
+
+	return result, nil
+}
 
-	for _, url := range *urls {
-		result = append(result, UserURLResult{
-			ShortURL:    s.buildShortURL(url.Short),
-			OriginalURL: url.Original,
-		})
+// меняет адрес ссылки или передает ее другому пользователю — только для владельца.
+// короткий адрес остается прежним, каждое изменение попадает в журнал хранилища
+func (s *Service) UpdateURL(ctx context.Context, short string, userUUID string, opts UpdateOptions) (_ *UserURLResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.UpdateURL")
+	defer func() { tracing.End(span, err) }()
+
+	if opts.OriginalURL == "" && opts.UserUUID == "" {
+		return nil, ErrNothingToUpdate
+	}
+
+	update := storage.URLUpdate{
+		Short:       short,
+		UserUUID:    userUUID,
+		NewUserUUID: opts.UserUUID,
+		ChangedAt:   time.Now(),
+	}
+
+	if opts.OriginalURL != "" {
+		originalURL, err := s.screenURL(opts.OriginalURL)
+		if err != nil {
+			return nil, err
+		}
+		update.Original = originalURL
+	}
+
+	current, err := s.repo.Get(ctx, short)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not get url")
+		return nil, ErrInternalError
+	}
+
+	// Чужие и удаленные ссылки не отличаем от несуществующих
+	if current.Original == "" || current.UserUUID != userUUID || current.IsDeleted {
+		return nil, ErrNotFound
+	}
+
+	// Отключенную ссылку нельзя вернуть, поменяв адрес
+	if current.IsBlocked {
+		return nil, ErrIsBlocked
+	}
+
+	if opts.UserUUID != "" && opts.UserUUID != userUUID {
+		exists, err := s.userExists(ctx, opts.UserUUID)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Could not check user")
+			return nil, ErrInternalError
+		}
+		if !exists {
+			return nil, ErrUnknownUser
+		}
+	}
+
+	url, err := s.repo.Update(ctx, update)
+
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return nil, ErrURLConflict
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not update url")
+		return nil, ErrInternalError
+	}
+
+	return &UserURLResult{
+		ShortURL:    s.buildShortURL(url.Short),
+		OriginalURL: url.Original,
+	}, nil
+}
+
+// проверяет, известен ли пользователь: есть в справочнике или владеет хотя бы одной ссылкой
+func (s *Service) userExists(ctx context.Context, userUUID string) (bool, error) {
+	if s.users != nil {
+		exists, err := s.users.Exists(ctx, userUUID)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+
+	urls, err := s.repo.ListByUser(ctx, userUUID, storage.ListOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+
+	return len(urls) > 0, nil
+}
+
+// получает журнал изменений ссылки — только для ее текущего владельца
+func (s *Service) GetURLChanges(ctx context.Context, short string, userUUID string) (_ []storage.URLChange, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetURLChanges")
+	defer func() { tracing.End(span, err) }()
+
+	url, err := s.repo.Get(ctx, short)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not get url")
+		return nil, ErrInternalError
+	}
+
+	// Чужие и удаленные ссылки не отличаем от несуществующих
+	if url.Original == "" || url.UserUUID != userUUID || url.IsDeleted {
+		return nil, ErrNotFound
+	}
+
+	changes, err := s.repo.GetChanges(ctx, short)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not get url changes")
+		return nil, ErrInternalError
+	}
+
+	if changes == nil {
+		changes = []storage.URLChange{}
+	}
+
+	return changes, nil
+}
+
+// восстанавливает удаленные ссылки пользователя, если срок хранения после удаления еще не истек,
+// и возвращает восстановленные короткие ссылки. чужие и уже не восстановимые ссылки пропускаются
+func (s *Service) RestoreBatch(ctx context.Context, shortURLs []string, userID string) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "service.RestoreBatch")
+	defer func() { tracing.End(span, err) }()
+
+	var deletedAfter time.Time
+	if s.config.DeletedRetention.Duration > 0 {
+		deletedAfter = time.Now().Add(-s.config.DeletedRetention.Duration)
+	}
+
+	shorts, err := s.repo.Restore(ctx, shortURLs, userID, deletedAfter)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not restore batch")
+		return nil, ErrInternalError
+	}
+
+	result := make([]string, 0, len(shorts))
+	for _, short := range shorts {
+		result = append(result, s.buildShortURL(short))
 	}
+
 	return result, nil
 }
 
+// сохраняет переход по короткой ссылке — асинхронно, не замедляя редирект
+func (s *Service) RecordClick(click analytics.Click) {
+	if s.analytics == nil {
+		return
+	}
+	s.analytics.Record(click)
+}
+
+// получает статистику переходов по ссылке — только для ее владельца
+func (s *Service) GetURLStats(ctx context.Context, short string, userUUID string) (_ analytics.LinkStats, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetURLStats")
+	defer func() { tracing.End(span, err) }()
+
+	stats := analytics.LinkStats{
+		Referers: []analytics.RefererStats{},
+	}
+
+	url, err := s.repo.Get(ctx, short)
+	if err != nil || url == nil || url.Original == "" {
+		return stats, ErrNotFound
+	}
+
+	// Чужие ссылки не отличаем от несуществующих
+	if url.UserUUID != userUUID {
+		return stats, ErrNotFound
+	}
+
+	if s.analytics == nil {
+		return stats, nil
+	}
+
+	stats, err = s.analytics.Stats(ctx, short)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Could not get url stats")
+		return stats, ErrInternalError
+	}
+
+	return stats, nil
+}
+
 // создает новый экземпляр модуля
-func New(repo storage.IRepo, config *config.Config) Service {
-	return Service{
+func New(repo storage.IRepo, config *config.Config, opts ...Option) Service {
+	s := Service{
 		repo:   repo,
 		config: config,
 	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.generator == nil {
+		s.generator, _ = shortcode.NewHash(shortcode.DefaultHashLength)
+	}
+	return s
 }
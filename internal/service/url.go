@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// максимальная длина ссылки до и после нормализации
+const maxURLLength = 2048
+
+// схемы, ссылки с которыми можно сокращать
+var allowedSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+// порты по умолчанию — убираются из ссылки
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Причины, по которым ссылка не прошла проверку
+const (
+	ReasonEmpty            = "empty"
+	ReasonTooLong          = "too_long"
+	ReasonMalformed        = "malformed"
+	ReasonSchemeNotAllowed = "scheme_not_allowed"
+	ReasonHostRequired     = "host_required"
+	ReasonInvalidHost      = "invalid_host"
+	ReasonInvalidPort      = "invalid_port"
+	ReasonBlocked          = "blocked"
+)
+
+// Ошибка проверки ссылки — errors.Is(err, ErrInvalidURL) для нее истинно
+type ValidationError struct {
+	// Поле запроса, в котором ошибка
+	Field string
+	// Машиночитаемая причина: ReasonEmpty, ReasonTooLong и т.д.
+	Reason  string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidURL, e.Message)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidURL
+}
+
+func invalidURL(reason string, format string, args ...any) *ValidationError {
+	return &ValidationError{
+		Field:   "original_url",
+		Reason:  reason,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// проверяет ссылку и приводит ее к единому виду, чтобы одинаковые по смыслу ссылки
+// сокращались в одну: схема и хост в нижнем регистре, хост в punycode,
+// без порта по умолчанию и без слэша на месте пустого пути
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	if raw == "" {
+		return "", invalidURL(ReasonEmpty, "url is empty")
+	}
+
+	if len(raw) > maxURLLength {
+		return "", invalidURL(ReasonTooLong, "url is longer than %d characters", maxURLLength)
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", invalidURL(ReasonMalformed, "url could not be parsed")
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+
+	if !allowedSchemes[parsed.Scheme] {
+		return "", invalidURL(ReasonSchemeNotAllowed, "scheme %q is not allowed, use http or https", parsed.Scheme)
+	}
+
+	if parsed.Opaque != "" || parsed.Hostname() == "" {
+		return "", invalidURL(ReasonHostRequired, "url has no host")
+	}
+
+	host, err := normalizeHost(parsed.Hostname())
+	if err != nil {
+		return "", invalidURL(ReasonInvalidHost, "host %q is invalid", parsed.Hostname())
+	}
+
+	port := parsed.Port()
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", invalidURL(ReasonInvalidPort, "port %q is invalid", port)
+		}
+	}
+
+	if port == "" || port == defaultPorts[parsed.Scheme] {
+		parsed.Host = host
+	} else {
+		parsed.Host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+
+	if parsed.Path == "/" {
+		parsed.Path = ""
+		parsed.RawPath = ""
+	}
+
+	normalized := parsed.String()
+
+	if len(normalized) > maxURLLength {
+		return "", invalidURL(ReasonTooLong, "url is longer than %d characters", maxURLLength)
+	}
+
+	return normalized, nil
+}
+
+// переводит хост в нижний регистр и punycode, ip-адреса оставляет как есть
+func normalizeHost(host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return "[" + ip.String() + "]", nil
+		}
+		return ip.String(), nil
+	}
+
+	return idna.Lookup.ToASCII(strings.ToLower(host))
+}
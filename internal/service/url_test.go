@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		want   string
+		reason string
+	}{
+		{name: "already normal", raw: "https://example.com/path?q=1", want: "https://example.com/path?q=1"},
+		{name: "scheme and host case", raw: "HTTP://Example.COM/Path", want: "http://example.com/Path"},
+		{name: "root trailing slash", raw: "http://example.com/", want: "http://example.com"},
+		{name: "root slash before query", raw: "http://example.com/?q=1", want: "http://example.com?q=1"},
+		{name: "path trailing slash kept", raw: "http://example.com/docs/", want: "http://example.com/docs/"},
+		{name: "default http port", raw: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "default https port", raw: "https://example.com:443", want: "https://example.com"},
+		{name: "custom port kept", raw: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "idn host", raw: "http://пример.рф/путь", want: "http://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C"},
+		{name: "ipv6 host", raw: "http://[::1]:8080/", want: "http://[::1]:8080"},
+		{name: "surrounding spaces", raw: "  http://example.com  ", want: "http://example.com"},
+		{name: "empty", raw: " ", reason: ReasonEmpty},
+		{name: "too long", raw: "http://example.com/" + strings.Repeat("a", maxURLLength), reason: ReasonTooLong},
+		{name: "javascript", raw: "javascript:alert(1)", reason: ReasonSchemeNotAllowed},
+		{name: "ftp", raw: "ftp://example.com/file", reason: ReasonSchemeNotAllowed},
+		{name: "garbage", raw: "not a url", reason: ReasonSchemeNotAllowed},
+		{name: "no host", raw: "http:///path", reason: ReasonHostRequired},
+		{name: "malformed", raw: "http://exa mple.com/%zz", reason: ReasonMalformed},
+		{name: "invalid port", raw: "http://example.com:99999", reason: ReasonInvalidPort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeURL(tt.raw)
+
+			if tt.reason == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrInvalidURL)
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.reason, validationErr.Reason)
+			assert.Equal(t, "original_url", validationErr.Field)
+		})
+	}
+}
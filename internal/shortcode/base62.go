@@ -0,0 +1,30 @@
+package shortcode
+
+// алфавит base62: цифры, строчные и прописные латинские буквы
+const base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// кодирует неотрицательное число в base62
+// и дополняет результат нулями слева до minLength
+func encodeBase62(n int64, minLength int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	var buf []byte
+
+	for n > 0 {
+		buf = append(buf, base62Alphabet[n%62])
+		n /= 62
+	}
+
+	for len(buf) < minLength || len(buf) == 0 {
+		buf = append(buf, base62Alphabet[0])
+	}
+
+	// цифры получены от младшей к старшей — разворачиваем
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+
+	return string(buf)
+}
@@ -0,0 +1,34 @@
+package shortcode
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// генерирует код из первых символов sha256 оригинальной ссылки в hex
+type Hash struct {
+	length int
+}
+
+// Генерирует код; при повторной попытке к ссылке добавляется номер попытки
+func (g *Hash) Generate(ctx context.Context, original string, attempt int) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, original)
+	if attempt > 0 {
+		io.WriteString(hash, "#"+strconv.Itoa(attempt))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:g.length], nil
+}
+
+// создает генератор кодов из хэша ссылки
+func NewHash(length int) (*Hash, error) {
+	if length <= 0 || length > sha256.Size*2 {
+		return nil, ErrInvalidLength
+	}
+	return &Hash{
+		length: length,
+	}, nil
+}
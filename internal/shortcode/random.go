@@ -0,0 +1,38 @@
+package shortcode
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+)
+
+// генерирует случайный код из символов base62
+type Random struct {
+	length int
+}
+
+// Генерирует код — оригинальная ссылка и номер попытки не используются
+func (g *Random) Generate(ctx context.Context, original string, attempt int) (string, error) {
+	max := big.NewInt(int64(len(base62Alphabet)))
+	buf := make([]byte, g.length)
+
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = base62Alphabet[n.Int64()]
+	}
+
+	return string(buf), nil
+}
+
+// создает генератор случайных кодов
+func NewRandom(length int) (*Random, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+	return &Random{
+		length: length,
+	}, nil
+}
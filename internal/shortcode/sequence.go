@@ -0,0 +1,46 @@
+package shortcode
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// генерирует код из следующего значения счетчика в base62
+type Sequence struct {
+	counter   Counter
+	minLength int
+}
+
+// Генерирует код — каждая попытка берет новое значение счетчика
+func (g *Sequence) Generate(ctx context.Context, original string, attempt int) (string, error) {
+	n, err := g.counter.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return encodeBase62(n, g.minLength), nil
+}
+
+// создает генератор последовательных кодов
+func NewSequence(counter Counter, minLength int) *Sequence {
+	return &Sequence{
+		counter:   counter,
+		minLength: minLength,
+	}
+}
+
+// счетчик в памяти процесса — для хранилищ без собственной последовательности
+type MemoryCounter struct {
+	value atomic.Int64
+}
+
+// Возвращает следующее значение счетчика
+func (c *MemoryCounter) Next(ctx context.Context) (int64, error) {
+	return c.value.Add(1), nil
+}
+
+// создает счетчик в памяти, который продолжит отсчет после start
+func NewMemoryCounter(start int64) *MemoryCounter {
+	counter := MemoryCounter{}
+	counter.value.Store(start)
+	return &counter
+}
@@ -0,0 +1,84 @@
+// модуль shortcode отвечает за генерацию коротких кодов для ссылок.
+// поддерживаются стратегии: хэш оригинальной ссылки, случайная строка
+// и последовательный счетчик в base62.
+package shortcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Стратегии генерации коротких кодов
+const (
+	StrategyHash     = "hash"
+	StrategyRandom   = "random"
+	StrategySequence = "sequence"
+)
+
+// Длина кода по умолчанию для каждой стратегии
+const (
+	DefaultHashLength     = 10
+	DefaultRandomLength   = 8
+	DefaultSequenceLength = 1
+)
+
+// Ошибка если указана неизвестная стратегия генерации
+var ErrUnknownStrategy = errors.New("unknown short code strategy")
+
+// Ошибка если указана неподходящая длина кода
+var ErrInvalidLength = errors.New("invalid short code length")
+
+// Генератор выбранной стратегии в виде функции — подходит туда же, куда и сами генераторы
+type Func func(ctx context.Context, original string, attempt int) (string, error)
+
+// Генерирует код для оригинальной ссылки.
+// attempt — номер попытки: при коллизии код генерируется заново с увеличенным attempt
+func (f Func) Generate(ctx context.Context, original string, attempt int) (string, error) {
+	return f(ctx, original, attempt)
+}
+
+// Источник последовательных чисел для генератора StrategySequence
+type Counter interface {
+	Next(ctx context.Context) (int64, error)
+}
+
+// создает генератор по названию стратегии
+// length — длина кода, для StrategySequence — минимальная длина; 0 — длина по умолчанию
+// counter нужен только для StrategySequence
+func New(strategy string, length int, counter Counter) (Func, error) {
+	if length < 0 {
+		return nil, ErrInvalidLength
+	}
+
+	switch strategy {
+	case "", StrategyHash:
+		if length == 0 {
+			length = DefaultHashLength
+		}
+		g, err := NewHash(length)
+		if err != nil {
+			return nil, err
+		}
+		return g.Generate, nil
+	case StrategyRandom:
+		if length == 0 {
+			length = DefaultRandomLength
+		}
+		g, err := NewRandom(length)
+		if err != nil {
+			return nil, err
+		}
+		return g.Generate, nil
+	case StrategySequence:
+		if length == 0 {
+			length = DefaultSequenceLength
+		}
+		if counter == nil {
+			return nil, fmt.Errorf("%w: %s requires a counter", ErrUnknownStrategy, strategy)
+		}
+		return NewSequence(counter, length).Generate, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
+}
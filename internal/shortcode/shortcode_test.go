@@ -0,0 +1,106 @@
+package shortcode
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBase62(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int64
+		minLength int
+		want      string
+	}{
+		{name: "Zero", n: 0, minLength: 1, want: "0"},
+		{name: "Single digit", n: 61, minLength: 1, want: "Z"},
+		{name: "Two digits", n: 62, minLength: 1, want: "10"},
+		{name: "Padded", n: 62, minLength: 4, want: "0010"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, encodeBase62(tt.n, tt.minLength))
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	g, err := NewHash(DefaultHashLength)
+	require.NoError(t, err)
+
+	first, err := g.Generate(context.Background(), "http://yandex.ru", 0)
+	require.NoError(t, err)
+
+	// код первой попытки совпадает с кодами, которые генерировались до появления стратегий
+	assert.Equal(t, "7aa257ae64", first)
+
+	again, err := g.Generate(context.Background(), "http://yandex.ru", 0)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	retry, err := g.Generate(context.Background(), "http://yandex.ru", 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, retry)
+	assert.Len(t, retry, DefaultHashLength)
+}
+
+func TestRandom(t *testing.T) {
+	g, err := NewRandom(12)
+	require.NoError(t, err)
+
+	first, err := g.Generate(context.Background(), "http://yandex.ru", 0)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-zA-Z]{12}$`), first)
+
+	second, err := g.Generate(context.Background(), "http://yandex.ru", 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestSequence(t *testing.T) {
+	g := NewSequence(NewMemoryCounter(61), 2)
+
+	first, err := g.Generate(context.Background(), "http://yandex.ru", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "10", first)
+
+	second, err := g.Generate(context.Background(), "http://yandex.ru", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "11", second)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		length   int
+		counter  Counter
+		wantErr  error
+	}{
+		{name: "Default strategy", strategy: ""},
+		{name: "Hash", strategy: StrategyHash, length: 16},
+		{name: "Random", strategy: StrategyRandom},
+		{name: "Sequence", strategy: StrategySequence, counter: NewMemoryCounter(0)},
+		{name: "Sequence without counter", strategy: StrategySequence, wantErr: ErrUnknownStrategy},
+		{name: "Unknown strategy", strategy: "uuid", wantErr: ErrUnknownStrategy},
+		{name: "Hash too long", strategy: StrategyHash, length: 100, wantErr: ErrInvalidLength},
+		{name: "Negative length", strategy: StrategyRandom, length: -1, wantErr: ErrInvalidLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.strategy, tt.length, tt.counter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, g)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// модуль cache — кэширующая обертка над любым хранилищем ссылок.
+// результаты Get, в том числе отсутствие ссылки, хранятся в памяти ограниченное время
+// и сбрасываются при изменении ссылок через эту же обертку.
+// изменения, сделанные другими экземплярами сервиса, становятся видны по истечении ttl.
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/storage"
+)
+
+// хранилище с кэшем коротких ссылок
+type Repo struct {
+	repo        storage.IRepo
+	lru         *lru
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+// получает ссылку по короткому адресу — сначала из кэша
+func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
+	now := r.now()
+
+	if e, ok := r.lru.get(short, now); ok {
+		if e.url == nil {
+			return &storage.URL{}, nil
+		}
+		url := *e.url
+		return &url, nil
+	}
+
+	generation := r.lru.currentGeneration()
+
+	url, err := r.repo.Get(ctx, short)
+	if err != nil {
+		return url, err
+	}
+
+	// адрес может указывать в буфер запроса, который fiber переиспользует, —
+	// ключ кэша храним отдельной копией
+	key := strings.Clone(short)
+
+	// Хранилища возвращают пустую ссылку, если ее нет — запоминаем и это
+	if url == nil || url.Original == "" {
+		r.lru.set(entry{short: key, expiresAt: now.Add(r.negativeTTL)}, generation)
+		return &storage.URL{}, nil
+	}
+
+	cached := *url
+	r.lru.set(entry{short: key, url: &cached, expiresAt: now.Add(r.ttl)}, generation)
+
+	return url, nil
+}
+
+// сохраняет ссылку — и сбрасывает запись о ее отсутствии
+func (r *Repo) Create(ctx context.Context, url storage.URL) error {
+	err := r.repo.Create(ctx, url)
+	r.lru.delete(url.Short)
+	return err
+}
+
+// сохраняет несколько ссылок
+func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	results, err := r.repo.CreateBatch(ctx, urls)
+	for _, url := range urls {
+		r.lru.delete(url.Short)
+	}
+	return results, err
+}
+
+// помечает ссылки удаленными и убирает их из кэша
+func (r *Repo) Delete(ctx context.Context, shorts []string, userID string) error {
+	err := r.repo.Delete(ctx, shorts, userID)
+	for _, short := range shorts {
+		r.lru.delete(short)
+	}
+	return err
+}
+
+// помечает удаленными ссылки разных пользователей и убирает их из кэша
+func (r *Repo) DeleteBatch(ctx context.Context, deletions []storage.Deletion) error {
+	err := r.repo.DeleteBatch(ctx, deletions)
+	for _, deletion := range deletions {
+		r.lru.delete(deletion.Short)
+	}
+	return err
+}
+
+// помечает истекшие ссылки удаленными — какие именно, неизвестно, поэтому кэш сбрасывается целиком
+func (r *Repo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	deleted, err := r.repo.DeleteExpired(ctx, now)
+	if deleted > 0 {
+		r.lru.clear()
+	}
+	return deleted, err
+}
+
+// восстанавливает удаленные ссылки и убирает их из кэша
+func (r *Repo) Restore(ctx context.Context, shorts []string, userUUID string, deletedAfter time.Time) ([]string, error) {
+	restored, err := r.repo.Restore(ctx, shorts, userUUID, deletedAfter)
+	for _, short := range restored {
+		r.lru.delete(short)
+	}
+	return restored, err
+}
+
+// окончательно удаляет ссылки и убирает их из кэша
+func (r *Repo) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	purged, err := r.repo.Purge(ctx, deletedBefore)
+	for _, short := range purged {
+		r.lru.delete(short)
+	}
+	return purged, err
+}
+
+// отключает ссылки по адресу и убирает их из кэша
+func (r *Repo) BlockMatching(ctx context.Context, match func(original string) bool) ([]string, error) {
+	blocked, err := r.repo.BlockMatching(ctx, match)
+	for _, short := range blocked {
+		r.lru.delete(short)
+	}
+	return blocked, err
+}
+
+// меняет ссылку и убирает ее из кэша
+func (r *Repo) Update(ctx context.Context, update storage.URLUpdate) (*storage.URL, error) {
+	url, err := r.repo.Update(ctx, update)
+	r.lru.delete(update.Short)
+	return url, err
+}
+
+// получает журнал изменений ссылки
+func (r *Repo) GetChanges(ctx context.Context, short string) ([]storage.URLChange, error) {
+	return r.repo.GetChanges(ctx, short)
+}
+
+// получает ссылку по оригинальному адресу
+func (r *Repo) GetByOriginal(ctx context.Context, original string) (*storage.URL, error) {
+	return r.repo.GetByOriginal(ctx, original)
+}
+
+// получает страницу ссылок пользователя
+func (r *Repo) ListByUser(ctx context.Context, userUUID string, opts storage.ListOptions) ([]storage.URL, error) {
+	return r.repo.ListByUser(ctx, userUUID, opts)
+}
+
+// получает статистику хранилища
+func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
+	return r.repo.GetStats(ctx)
+}
+
+// проверяет доступность хранилища
+func (r *Repo) Ping(ctx context.Context) error {
+	return r.repo.Ping(ctx)
+}
+
+// создает кэширующую обертку над хранилищем
+// size — сколько ссылок хранится в кэше, ttl — сколько хранится найденная ссылка,
+// negativeTTL — сколько хранится отсутствие ссылки
+func New(repo storage.IRepo, size int, ttl time.Duration, negativeTTL time.Duration) *Repo {
+	return &Repo{
+		repo:        repo,
+		lru:         newLRU(size),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+	}
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// хранилище, которое считает обращения к Get
+type countingRepo struct {
+	storage.IRepo
+	urls  map[string]storage.URL
+	calls int
+}
+
+func (r *countingRepo) Get(ctx context.Context, short string) (*storage.URL, error) {
+	r.calls++
+	url := r.urls[short]
+	return &url, nil
+}
+
+func (r *countingRepo) Create(ctx context.Context, url storage.URL) error {
+	r.urls[url.Short] = url
+	return nil
+}
+
+func (r *countingRepo) Delete(ctx context.Context, shorts []string, userID string) error {
+	for _, short := range shorts {
+		url := r.urls[short]
+		url.IsDeleted = true
+		r.urls[short] = url
+	}
+	return nil
+}
+
+func newTestRepo(size int) (*Repo, *countingRepo, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	inner := &countingRepo{
+		urls: map[string]storage.URL{
+			"short1": {Short: "short1", Original: "http://google.com/1"},
+			"short2": {Short: "short2", Original: "http://google.com/2"},
+		},
+	}
+	repo := New(inner, size, time.Minute, 10*time.Second)
+	repo.now = func() time.Time { return now }
+	return repo, inner, &now
+}
+
+func TestRepo_Get(t *testing.T) {
+	repo, inner, now := newTestRepo(10)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		url, err := repo.Get(ctx, "short1")
+		require.NoError(t, err)
+		assert.Equal(t, "http://google.com/1", url.Original)
+	}
+	assert.Equal(t, 1, inner.calls)
+
+	// по истечении ttl ссылка снова читается из хранилища
+	*now = now.Add(time.Minute)
+	_, err := repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestRepo_GetKeyBufferReused(t *testing.T) {
+	repo, inner, _ := newTestRepo(10)
+	ctx := context.Background()
+
+	// адрес — представление буфера, как у параметров запроса в fiber
+	buf := []byte("short1")
+	short := unsafe.String(&buf[0], len(buf))
+
+	url, err := repo.Get(ctx, short)
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/1", url.Original)
+
+	// буфер переиспользуется следующим запросом
+	copy(buf, "short2")
+
+	url, err = repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/1", url.Original)
+
+	url, err = repo.Get(ctx, "short2")
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/2", url.Original)
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestRepo_NegativeCache(t *testing.T) {
+	repo, inner, now := newTestRepo(10)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		url, err := repo.Get(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Empty(t, url.Original)
+	}
+	assert.Equal(t, 1, inner.calls)
+
+	*now = now.Add(10 * time.Second)
+	_, err := repo.Get(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+
+	// созданная ссылка сразу видна, несмотря на запись об ее отсутствии
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "unknown", Original: "http://google.com/new"}))
+	url, err := repo.Get(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/new", url.Original)
+}
+
+func TestRepo_Delete(t *testing.T) {
+	repo, _, _ := newTestRepo(10)
+	ctx := context.Background()
+
+	url, err := repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	require.False(t, url.IsDeleted)
+
+	require.NoError(t, repo.Delete(ctx, []string{"short1"}, "user"))
+
+	url, err = repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	assert.True(t, url.IsDeleted)
+}
+
+func TestRepo_Evict(t *testing.T) {
+	repo, inner, _ := newTestRepo(1)
+	ctx := context.Background()
+
+	repo.Get(ctx, "short1")
+	repo.Get(ctx, "short2")
+	repo.Get(ctx, "short1")
+
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestRepo_StaleSet(t *testing.T) {
+	repo, _, _ := newTestRepo(10)
+
+	// ссылку прочитали из хранилища, но до записи в кэш ее удалили
+	generation := repo.lru.currentGeneration()
+	repo.lru.delete("short1")
+	repo.lru.set(entry{short: "short1", url: &storage.URL{Original: "stale"}, expiresAt: repo.now().Add(time.Minute)}, generation)
+
+	_, ok := repo.lru.get("short1", repo.now())
+	assert.False(t, ok)
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/storage"
+)
+
+// запись кэша — url равен nil, если ссылки нет в хранилище
+type entry struct {
+	short     string
+	url       *storage.URL
+	expiresAt time.Time
+}
+
+// ограниченный по размеру кэш, который вытесняет давно не использованные записи
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+	// растет при каждом сбросе записей — по нему set отличает устаревшие данные
+	generation uint64
+}
+
+// получает запись, если она есть и не устарела
+func (c *lru) get(short string, now time.Time) (entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[short]
+	if !ok {
+		return entry{}, false
+	}
+
+	e := element.Value.(entry)
+	if !now.Before(e.expiresAt) {
+		c.remove(element)
+		return entry{}, false
+	}
+
+	c.order.MoveToFront(element)
+
+	return e, true
+}
+
+// текущее поколение записей
+func (c *lru) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// сохраняет запись, вытесняя самую давнюю при переполнении.
+// запись не сохраняется, если после чтения из хранилища кэш сбрасывался —
+// иначе в кэш могла бы попасть только что удаленная ссылка
+func (c *lru) set(e entry, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.items[e.short]; ok {
+		element.Value = e
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[e.short] = c.order.PushFront(e)
+
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// удаляет запись
+func (c *lru) delete(short string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	if element, ok := c.items[short]; ok {
+		c.remove(element)
+	}
+}
+
+// удаляет все записи
+func (c *lru) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *lru) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(entry).short)
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
@@ -1,251 +1,871 @@
 // модуль отвечает за сохранение данных о ссылках в файле.
+// файл — журнал событий в формате JSON lines: каждая строка — создание, удаление или изменение ссылок.
+// при запуске журнал проигрывается в индекс в памяти, все чтения идут из индекса,
+// а запись только дописывает событие в конец файла.
+// журнал периодически сжимается — переписывается текущим состоянием ссылок.
 package infile
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
-	"strings"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/augustjourney/urlshrt/internal/config"
 	"github.com/augustjourney/urlshrt/internal/logger"
 	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/sirupsen/logrus"
 )
 
+// Политики сброса журнала на диск
+const (
+	// после каждой записи — медленнее всего, но записанное не теряется
+	SyncAlways = "always"
+	// раз в FileSyncInterval — при падении теряются записи последнего интервала
+	SyncInterval = "interval"
+	// сброс на диск остается на усмотрение операционной системы
+	SyncNever = "never"
+)
+
+// виды событий журнала
+const (
+	opCreate = "create"
+	opDelete = "delete"
+	// снятие пометки об удалении
+	opRestore = "restore"
+	// окончательное удаление
+	opPurge  = "purge"
+	opBlock  = "block"
+	opUpdate = "update"
+	// журнал изменений без применения к ссылкам — так он переносится при сжатии
+	opHistory = "history"
+)
+
+// как часто журнал сбрасывается на диск при политике interval, если интервал не задан
+const DefaultSyncInterval = time.Second
+
+// Ошибка если указана неизвестная политика сброса журнала
+var ErrUnknownSyncPolicy = errors.New("unknown file sync policy")
+
+// событие журнала
+type event struct {
+	Op     string        `json:"op"`
+	URLs   []storage.URL `json:"urls,omitempty"`
+	Shorts []string      `json:"shorts,omitempty"`
+	// изменения ссылок для событий update и history
+	Changes []storage.URLChange `json:"changes,omitempty"`
+	// время удаления для событий delete
+	At *time.Time `json:"at,omitempty"`
+}
+
 // репозиторий с методами хранилища
 type Repo struct {
-	fileStoragePath string
+	mu         sync.RWMutex
+	path       string
+	file       *os.File
+	syncPolicy string
+	// есть ли записи, которые еще не сброшены на диск
+	dirty bool
+
+	// ссылки в порядке создания и индексы по ним
+	urls       []storage.URL
+	byShort    map[string]int
+	byOriginal map[string]int
+	// журнал изменений по короткому адресу
+	changes map[string][]storage.URLChange
+
+	stop chan struct{}
+	done chan struct{}
 }
 
 // сохраняет ссылку в файл
 func (r *Repo) Create(ctx context.Context, url storage.URL) error {
-
-	foundURL, err := r.GetByOriginal(ctx, url.Original)
+	results, err := r.CreateBatch(ctx, []storage.URL{url})
 	if err != nil {
 		return err
 	}
-	if foundURL.Short != "" {
-		return storage.ErrAlreadyExists
+	return results[0].Err()
+}
+
+// сохраняет множество ссылок в файл одним событием журнала.
+// ссылки, которые конфликтуют с уже сохраненными, пропускаются
+func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]storage.BatchResult, len(urls))
+	created := make([]storage.URL, 0, len(urls))
+
+	// ссылки пачки, которые попадут в журнал: оригинальный адрес -> короткий
+	originals := make(map[string]string)
+	shorts := make(map[string]bool)
+	now := time.Now()
+
+	for i, url := range urls {
+		if j, ok := r.byOriginal[url.Original]; ok {
+			results[i] = storage.BatchResult{Status: storage.BatchExisted, Short: r.urls[j].Short}
+			continue
+		}
+		if short, ok := originals[url.Original]; ok {
+			results[i] = storage.BatchResult{Status: storage.BatchExisted, Short: short}
+			continue
+		}
+		if _, ok := r.byShort[url.Short]; ok || shorts[url.Short] {
+			results[i] = storage.BatchResult{Status: storage.BatchShortTaken, Short: url.Short}
+			continue
+		}
+
+		if url.CreatedAt.IsZero() {
+			url.CreatedAt = now
+		}
+
+		originals[url.Original] = url.Short
+		shorts[url.Short] = true
+		created = append(created, url)
+
+		results[i] = storage.BatchResult{Status: storage.BatchCreated, Short: url.Short}
+	}
+
+	if len(created) == 0 {
+		return results, nil
 	}
 
-	urls, err := r.GetAll(ctx)
+	e := event{Op: opCreate, URLs: created}
+
+	err := r.append(e)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	r.apply(e)
+
+	return results, nil
+}
+
+// получает страницу неудаленных ссылок пользователя
+func (r *Repo) ListByUser(ctx context.Context, userUUID string, opts storage.ListOptions) ([]storage.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var urls []storage.URL
+
+	for _, url := range r.urls {
+		if url.UserUUID == userUUID && !url.IsDeleted {
+			urls = append(urls, url)
+		}
+	}
+
+	return storage.Page(urls, opts), nil
+}
+
+// помечает удаленными ссылки пользователя
+func (r *Repo) Delete(ctx context.Context, shortURLs []string, userUUID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shorts []string
+
+	for _, short := range shortURLs {
+		if r.isOwned(short, userUUID) {
+			shorts = append(shorts, short)
+		}
+	}
+
+	return r.deleteShorts(shorts, time.Now())
+}
+
+// помечает удаленными ссылки разных пользователей одним событием журнала
+func (r *Repo) DeleteBatch(ctx context.Context, deletions []storage.Deletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shorts []string
+
+	for _, deletion := range deletions {
+		if r.isOwned(deletion.Short, deletion.UserUUID) {
+			shorts = append(shorts, deletion.Short)
+		}
 	}
 
-	urls = append(urls, url)
+	return r.deleteShorts(shorts, time.Now())
+}
+
+// проверяет, что ссылка есть, не удалена и принадлежит пользователю — вызывается под блокировкой
+func (r *Repo) isOwned(short string, userUUID string) bool {
+	i, ok := r.byShort[short]
+	if !ok {
+		return false
+	}
+	url := r.urls[i]
+	return url.UserUUID == userUUID && !url.IsDeleted
+}
+
+// помечает удаленными ссылки, срок жизни которых истек к моменту now
+func (r *Repo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	data, err := json.Marshal(&urls)
+	var shorts []string
+
+	for _, url := range r.urls {
+		if url.IsDeleted || url.ExpiresAt == nil || url.ExpiresAt.After(now) {
+			continue
+		}
+		shorts = append(shorts, url.Short)
+	}
+
+	err := r.deleteShorts(shorts, now)
 	if err != nil {
-		logger.Log.Error("Could not marshal json urls ", err)
-		return err
+		return 0, err
 	}
 
-	file, err := os.OpenFile(r.fileStoragePath, os.O_WRONLY|os.O_CREATE, 0666)
+	return len(shorts), nil
+}
+
+// записывает в журнал удаление ссылок — вызывается под блокировкой
+func (r *Repo) deleteShorts(shorts []string, at time.Time) error {
+	if len(shorts) == 0 {
+		return nil
+	}
+
+	e := event{Op: opDelete, Shorts: shorts, At: &at}
+
+	err := r.append(e)
 	if err != nil {
-		logger.Log.Error("Could not open file to write urls ", err)
 		return err
 	}
 
-	defer file.Close()
-
-	file.Write(data)
+	r.apply(e)
 
 	return nil
 }
 
-// сохраняет множество ссылок в файл
-func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) error {
+// отключает ссылки, оригинальный адрес которых подходит под match, одним событием журнала
+func (r *Repo) BlockMatching(ctx context.Context, match func(original string) bool) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shorts []string
+
+	for _, url := range r.urls {
+		if url.IsBlocked || url.IsDeleted || !match(url.Original) {
+			continue
+		}
+		shorts = append(shorts, url.Short)
+	}
+
+	if len(shorts) == 0 {
+		return nil, nil
+	}
+
+	e := event{Op: opBlock, Shorts: shorts}
 
-	currentURLs, err := r.GetAll(ctx)
+	err := r.append(e)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	r.apply(e)
+
+	return shorts, nil
+}
+
+// снимает пометку об удалении со ссылок пользователя, удаленных не раньше deletedAfter
+func (r *Repo) Restore(ctx context.Context, shortURLs []string, userUUID string, deletedAfter time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shorts []string
+
+	for _, short := range shortURLs {
+		i, ok := r.byShort[short]
+		if !ok {
+			continue
+		}
+		url := r.urls[i]
+		if url.UserUUID == userUUID && url.IsDeleted && storage.DeletedSince(url, deletedAfter) {
+			shorts = append(shorts, short)
+		}
 	}
 
-	currentURLs = append(currentURLs, urls...)
+	if len(shorts) == 0 {
+		return nil, nil
+	}
 
-	data, err := json.Marshal(currentURLs)
+	e := event{Op: opRestore, Shorts: shorts}
+
+	err := r.append(e)
 	if err != nil {
-		logger.Log.Error("Could not marshal json urls ", err)
-		return err
+		return nil, err
 	}
 
-	file, err := os.OpenFile(r.fileStoragePath, os.O_WRONLY|os.O_CREATE, 0666)
+	r.apply(e)
+
+	return shorts, nil
+}
+
+// окончательно удаляет ссылки, удаленные раньше deletedBefore.
+// из журнала они пропадут при следующем сжатии
+func (r *Repo) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shorts []string
+
+	for _, url := range r.urls {
+		if url.IsDeleted && !storage.DeletedSince(url, deletedBefore) {
+			shorts = append(shorts, url.Short)
+		}
+	}
+
+	if len(shorts) == 0 {
+		return nil, nil
+	}
+
+	e := event{Op: opPurge, Shorts: shorts}
+
+	err := r.append(e)
 	if err != nil {
-		logger.Log.Error("Could not open file to write urls ", err)
-		return err
+		return nil, err
 	}
 
-	defer file.Close()
+	r.apply(e)
 
-	file.Write(data)
+	return shorts, nil
+}
 
-	return nil
+// убирает ссылки и их журнал изменений из индекса и перестраивает его — вызывается под блокировкой
+func (r *Repo) purge(shorts []string) {
+	purged := make(map[string]bool, len(shorts))
+	for _, short := range shorts {
+		purged[short] = true
+		delete(r.changes, short)
+	}
+
+	urls := r.urls[:0]
+	for _, url := range r.urls {
+		if !purged[url.Short] {
+			urls = append(urls, url)
+		}
+	}
+	r.urls = urls
+
+	r.byShort = make(map[string]int, len(urls))
+	r.byOriginal = make(map[string]int, len(urls))
+	for i, url := range urls {
+		r.byShort[url.Short] = i
+		r.byOriginal[url.Original] = i
+	}
 }
 
-// получает все сохраненные ссылки
-func (r *Repo) GetAll(ctx context.Context) ([]storage.URL, error) {
-	file, err := os.OpenFile(r.fileStoragePath, os.O_RDONLY|os.O_CREATE, 0666)
-	var urls []storage.URL
+// получает внутренню статистику: количество сохранненых ссылок и количество пользователей
+func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make(map[string]bool)
+
+	for _, url := range r.urls {
+		if url.UserUUID != "" {
+			users[url.UserUUID] = true
+		}
+	}
+
+	return storage.Stats{
+		UrlsCount:  len(r.urls),
+		UsersCount: len(users),
+	}, nil
+}
+
+// получает экземпляр ссылки по короткой
+func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var url storage.URL
+
+	if i, ok := r.byShort[short]; ok {
+		url = r.urls[i]
+	}
+
+	return &url, nil
+}
+
+// получает экземпляр ссылки по оригинальной
+func (r *Repo) GetByOriginal(ctx context.Context, original string) (*storage.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var url storage.URL
+
+	if i, ok := r.byOriginal[original]; ok {
+		url = r.urls[i]
+	}
+
+	return &url, nil
+}
+
+// меняет адрес или владельца ссылки — изменение и есть событие журнала
+func (r *Repo) Update(ctx context.Context, update storage.URLUpdate) (*storage.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, ok := r.byShort[update.Short]
+	if !ok || !r.isOwned(update.Short, update.UserUUID) {
+		return nil, storage.ErrNotFound
+	}
+
+	change, changed := update.Change(r.urls[i])
+	if !changed {
+		url := r.urls[i]
+		return &url, nil
+	}
+
+	if change.NewOriginal != "" {
+		if _, ok := r.byOriginal[change.NewOriginal]; ok {
+			return nil, storage.ErrAlreadyExists
+		}
+	}
+
+	e := event{Op: opUpdate, Changes: []storage.URLChange{change}}
+
+	err := r.append(e)
 	if err != nil {
-		logger.Log.Error("Could not open file to read all urls ", err)
-		return urls, err
+		return nil, err
 	}
 
-	defer file.Close()
+	r.apply(e)
 
-	data, err := io.ReadAll(file)
+	url := r.urls[i]
+	return &url, nil
+}
+
+// получает журнал изменений ссылки
+func (r *Repo) GetChanges(ctx context.Context, short string) ([]storage.URLChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]storage.URLChange(nil), r.changes[short]...), nil
+}
+
+// проверяет, что файл журнала на месте и открыт на запись
+func (r *Repo) Ping(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, err := os.Stat(r.path)
 	if err != nil {
-		logger.Log.Error("Could not read all urls ", err)
-		return urls, err
+		return err
 	}
 
-	err = json.Unmarshal(data, &urls)
+	_, err = r.file.Stat()
+
+	return err
+}
+
+// проверяет, что в журнал можно дописывать, а рядом с ним — создавать файлы:
+// без этого не получится сжать журнал
+func (r *Repo) CheckWritable(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
-		// if err is unexpected end of JSON input
-		// it means json file is empty
-		if !strings.Contains(err.Error(), "unexpected end of JSON input") {
-			logger.Log.Error("Could not unmarshal all urls ", err)
-			return urls, err
-		}
+		return err
+	}
+	file.Close()
+
+	probe, err := os.CreateTemp(filepath.Dir(r.path), ".healthcheck-*")
+	if err != nil {
+		return err
 	}
+	probe.Close()
 
-	return urls, nil
+	return os.Remove(probe.Name())
 }
 
-// получает ссылки конкретного пользователя
-func (r *Repo) GetByUserUUID(ctx context.Context, userUUID string) (*[]storage.URL, error) {
-	var urls []storage.URL
+// сжимает журнал: переписывает его текущим состоянием ссылок.
+// новый журнал пишется во временный файл и атомарно подменяет старый
+func (r *Repo) Compact(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	allURLs, err := r.GetAll(ctx)
+	tmpPath := r.path + ".compact"
 
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
-		return &urls, nil
+		return err
 	}
 
-	for i := 0; i < len(allURLs); i++ {
-		if allURLs[i].UserUUID == userUUID && !allURLs[i].IsDeleted {
-			urls = append(urls, allURLs[i])
+	writer := bufio.NewWriter(tmp)
+
+	for _, url := range r.urls {
+		err = writeEvent(writer, event{Op: opCreate, URLs: []storage.URL{url}})
+		if err != nil {
+			break
 		}
 	}
 
-	logger.Log.Info(allURLs)
+	// ссылки уже записаны в измененном виде — переносится только журнал изменений
+	for _, url := range r.urls {
+		if err != nil || len(r.changes[url.Short]) == 0 {
+			continue
+		}
+		err = writeEvent(writer, event{Op: opHistory, Changes: r.changes[url.Short]})
+	}
 
-	return &urls, nil
-}
+	if err == nil {
+		err = writer.Flush()
+	}
 
-// удаляет ссылку
-func (r *Repo) Delete(ctx context.Context, shortURLs []string, userUUID string) error {
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	err = os.Rename(tmpPath, r.path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
 
-	allURLs, err := r.GetAll(ctx)
+	syncDir(filepath.Dir(r.path))
 
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
+		return err
+	}
+
+	r.file.Close()
+	r.file = file
+	r.dirty = false
+
+	return nil
+}
+
+// сбрасывает на диск записи, которые еще не сброшены
+func (r *Repo) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.dirty {
 		return nil
 	}
 
-	shortUrlsMap := make(map[string]bool)
+	r.dirty = false
 
-	for _, short := range shortURLs {
-		shortUrlsMap[short] = true
+	return r.file.Sync()
+}
+
+// останавливает фоновый сброс, сбрасывает журнал на диск и закрывает файл
+func (r *Repo) Close() error {
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
 	}
 
-	for i := 0; i < len(allURLs); i++ {
-		url := allURLs[i]
-		_, ok := shortUrlsMap[url.Short]
-		if userUUID == "" {
-			allURLs[i].IsDeleted = true
-		} else if url.UserUUID == userUUID && ok {
-			allURLs[i].IsDeleted = true
-		}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.file.Sync()
+	closeErr := r.file.Close()
+	if err == nil {
+		err = closeErr
 	}
 
-	data, err := json.Marshal(&allURLs)
+	return err
+}
+
+// дописывает событие в журнал — вызывается под блокировкой
+func (r *Repo) append(e event) error {
+	var buf bytes.Buffer
+
+	err := writeEvent(&buf, e)
 	if err != nil {
-		logger.Log.Error("Could not marshal json urls ", err)
 		return err
 	}
 
-	file, err := os.OpenFile(r.fileStoragePath, os.O_WRONLY|os.O_CREATE, 0666)
+	// событие пишется одним вызовом — при падении в журнале остается
+	// либо вся строка, либо ее обрывок, который отбрасывается при загрузке
+	_, err = r.file.Write(buf.Bytes())
 	if err != nil {
-		logger.Log.Error("Could not open file to write urls ", err)
+		logger.Log.WithError(err).WithField("path", r.path).Error("Could not write event to file")
 		return err
 	}
 
-	defer file.Close()
+	if r.syncPolicy == SyncAlways {
+		return r.file.Sync()
+	}
 
-	file.Write(data)
+	r.dirty = true
 
 	return nil
 }
 
-// получает внутренню статистику: количество сохранненых ссылок и количество пользователей
-func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
-	result := storage.Stats{}
-	allURLs, err := r.GetAll(ctx)
+// применяет событие к индексу
+func (r *Repo) apply(e event) {
+	switch e.Op {
+	case opCreate:
+		for _, url := range e.URLs {
+			r.byShort[url.Short] = len(r.urls)
+			r.byOriginal[url.Original] = len(r.urls)
+			r.urls = append(r.urls, url)
+		}
+	case opDelete:
+		for _, short := range e.Shorts {
+			if i, ok := r.byShort[short]; ok {
+				r.urls[i].IsDeleted = true
+				r.urls[i].DeletedAt = e.At
+			}
+		}
+	case opRestore:
+		for _, short := range e.Shorts {
+			if i, ok := r.byShort[short]; ok {
+				r.urls[i].IsDeleted = false
+				r.urls[i].DeletedAt = nil
+			}
+		}
+	case opPurge:
+		r.purge(e.Shorts)
+	case opBlock:
+		for _, short := range e.Shorts {
+			if i, ok := r.byShort[short]; ok {
+				r.urls[i].IsBlocked = true
+			}
+		}
+	case opUpdate:
+		for _, change := range e.Changes {
+			i, ok := r.byShort[change.Short]
+			if !ok {
+				continue
+			}
+			if change.NewOriginal != "" {
+				if j, ok := r.byOriginal[change.OldOriginal]; ok && j == i {
+					delete(r.byOriginal, change.OldOriginal)
+				}
+				r.byOriginal[change.NewOriginal] = i
+			}
+			change.Apply(&r.urls[i])
+			r.changes[change.Short] = append(r.changes[change.Short], change)
+		}
+	case opHistory:
+		for _, change := range e.Changes {
+			r.changes[change.Short] = append(r.changes[change.Short], change)
+		}
+	}
+}
+
+// загружает журнал в индекс.
+// оборванная последняя строка — след падения во время записи — отрезается.
+// испорченное событие в середине журнала пропускается с предупреждением,
+// чтобы не блокировать запуск, — при следующем сжатии оно пропадает из файла
+func (r *Repo) load(file *os.File) error {
+	reader := bufio.NewReader(file)
+
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				logger.Log.WithField("path", r.path).Warn("Dropping incomplete event at the end of the log")
+				return file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e event
+			if err = json.Unmarshal(line, &e); err != nil {
+				logger.Log.WithError(err).WithFields(logrus.Fields{
+					"path":   r.path,
+					"offset": offset,
+				}).Warn("Skipping corrupted event")
+			} else {
+				r.apply(e)
+			}
+		}
 
+		offset += int64(len(line))
+	}
+}
+
+// ссылкам, удаленным до того, как в журнал стало попадать время удаления,
+// проставляет время загрузки — срок хранения удаленных отсчитывается от него
+func (r *Repo) stampDeleted(now time.Time) {
+	for i := range r.urls {
+		if r.urls[i].IsDeleted && r.urls[i].DeletedAt == nil {
+			r.urls[i].DeletedAt = &now
+		}
+	}
+}
+
+// загружает файл старого формата — json-массив ссылок
+func (r *Repo) loadLegacy(file *os.File) error {
+	data, err := io.ReadAll(file)
 	if err != nil {
-		return result, nil
+		return err
 	}
 
-	users := make(map[string]bool)
+	var urls []storage.URL
 
-	for i := 0; i < len(allURLs); i++ {
-		users[allURLs[i].UserUUID] = true
+	err = json.Unmarshal(data, &urls)
+	if err != nil {
+		return err
 	}
 
-	result.UrlsCount = len(allURLs)
-	result.UsersCount = len(users)
+	r.apply(event{Op: opCreate, URLs: urls})
 
-	return result, nil
+	return nil
 }
 
-// получает экземпляр ссылки по короткой
-func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
+func writeEvent(w io.Writer, e event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
 
-	var url storage.URL
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
 
-	urls, err := r.GetAll(ctx)
+// проверяет, что файл — json-массив старого формата
+func isLegacyFormat(file *os.File) (bool, error) {
+	reader := bufio.NewReader(file)
 
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if b == ' ' || b == '\n' || b == '\r' || b == '\t' {
+			continue
+		}
+		return b == '[', nil
+	}
+}
+
+// сбрасывает на диск запись о переименовании файла в директории
+func syncDir(dir string) {
+	d, err := os.Open(dir)
 	if err != nil {
-		return &url, nil
+		return
 	}
+	defer d.Close()
+	d.Sync()
+}
 
-	for i := 0; i < len(urls); i++ {
-		if urls[i].Short == short {
-			url = urls[i]
-			break
+// периодически сбрасывает журнал на диск
+func (r *Repo) syncEvery(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.Sync(); err != nil {
+				logger.Log.WithError(err).WithField("path", r.path).Error("Could not sync file")
+			}
 		}
 	}
-
-	return &url, nil
 }
 
-// получает экземпляр ссылки по оригинальной
-func (r *Repo) GetByOriginal(ctx context.Context, original string) (*storage.URL, error) {
+// создает новый экземпляр infile-репозитория и загружает в него журнал
+func New(config *config.Config) (*Repo, error) {
+	syncPolicy := config.FileSyncPolicy
+	if syncPolicy == "" {
+		syncPolicy = SyncAlways
+	}
 
-	var url storage.URL
+	if syncPolicy != SyncAlways && syncPolicy != SyncInterval && syncPolicy != SyncNever {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSyncPolicy, syncPolicy)
+	}
 
-	urls, err := r.GetAll(ctx)
+	repo := Repo{
+		path:       config.FileStoragePath,
+		syncPolicy: syncPolicy,
+		byShort:    make(map[string]int),
+		byOriginal: make(map[string]int),
+		changes:    make(map[string][]storage.URLChange),
+	}
 
+	file, err := os.OpenFile(repo.path, os.O_RDWR|os.O_CREATE, 0666)
 	if err != nil {
-		return &url, nil
+		return nil, err
 	}
 
-	for i := 0; i < len(urls); i++ {
-		if urls[i].Original == original {
-			url = urls[i]
-			break
+	legacy, err := isLegacyFormat(file)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+
+	if err == nil && legacy {
+		err = repo.loadLegacy(file)
+	} else if err == nil {
+		err = repo.load(file)
+	}
+
+	file.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.stampDeleted(time.Now())
+
+	repo.file, err = os.OpenFile(repo.path, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	// файл старого формата сразу переписывается журналом
+	if legacy {
+		logger.Log.WithField("path", repo.path).Info("Converting file to the event log format")
+		err = repo.Compact(context.Background())
+		if err != nil {
+			repo.file.Close()
+			return nil, err
 		}
 	}
 
-	return &url, nil
-}
+	if syncPolicy == SyncInterval {
+		// без интервала журнал не сбрасывался бы на диск никогда
+		interval := config.FileSyncInterval.Duration
+		if interval <= 0 {
+			interval = DefaultSyncInterval
+		}
 
-// создает новый экземпляр infile-репозитория
-func New(config *config.Config) *Repo {
-	repo := Repo{
-		fileStoragePath: config.FileStoragePath,
+		repo.stop = make(chan struct{})
+		repo.done = make(chan struct{})
+		go repo.syncEvery(interval)
 	}
-	return &repo
+
+	return &repo, nil
 }
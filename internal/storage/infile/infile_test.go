@@ -0,0 +1,502 @@
+package infile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/logger"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig(t *testing.T) *config.Config {
+	logger.New()
+	return &config.Config{
+		FileStoragePath: filepath.Join(t.TempDir(), "urls.log"),
+		FileSyncPolicy:  SyncAlways,
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
+
+func TestRepo_Reopen(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(ctx, storage.URL{UUID: "1", Short: "short1", Original: "http://google.com/1", UserUUID: "user1"}))
+	mustCreateBatch(t, repo, []storage.URL{
+		{UUID: "2", Short: "short2", Original: "http://google.com/2", UserUUID: "user1"},
+		{UUID: "3", Short: "short3", Original: "http://google.com/3", UserUUID: "user2"},
+	})
+	require.NoError(t, repo.Delete(ctx, []string{"short2", "short3"}, "user1"))
+
+	assert.ErrorIs(t, repo.Create(ctx, storage.URL{Short: "short4", Original: "http://google.com/1"}), storage.ErrAlreadyExists)
+	assert.ErrorIs(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/4"}), storage.ErrShortAlreadyExists)
+	require.NoError(t, repo.Close())
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	url, err := repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/1", url.Original)
+
+	// чужую ссылку удалить нельзя
+	url, err = repo.Get(ctx, "short3")
+	require.NoError(t, err)
+	assert.False(t, url.IsDeleted)
+
+	url, err = repo.Get(ctx, "short2")
+	require.NoError(t, err)
+	assert.True(t, url.IsDeleted)
+
+	urls, err := repo.ListByUser(ctx, "user1", storage.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Stats{UrlsCount: 3, UsersCount: 2}, stats)
+}
+
+func TestRepo_CreateBatchConflicts(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(ctx, storage.URL{UUID: "1", Short: "short1", Original: "http://google.com/1"}))
+
+	results, err := repo.CreateBatch(ctx, []storage.URL{
+		{UUID: "2", Short: "short2", Original: "http://google.com/2"},
+		{UUID: "3", Short: "short3", Original: "http://google.com/2"},
+		{UUID: "4", Short: "short4", Original: "http://google.com/1"},
+		{UUID: "5", Short: "short1", Original: "http://google.com/5"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.BatchResult{
+		{Status: storage.BatchCreated, Short: "short2"},
+		{Status: storage.BatchExisted, Short: "short2"},
+		{Status: storage.BatchExisted, Short: "short1"},
+		{Status: storage.BatchShortTaken, Short: "short1"},
+	}, results)
+	require.NoError(t, repo.Close())
+
+	// в журнал попадают только сохраненные ссылки
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.UrlsCount)
+}
+
+func TestRepo_DeleteBatch(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{UUID: "1", Short: "short1", Original: "http://google.com/1", UserUUID: "user1"},
+		{UUID: "2", Short: "short2", Original: "http://google.com/2", UserUUID: "user2"},
+		{UUID: "3", Short: "short3", Original: "http://google.com/3", UserUUID: "user2"},
+	})
+
+	require.NoError(t, repo.DeleteBatch(ctx, []storage.Deletion{
+		{Short: "short1", UserUUID: "user1"},
+		{Short: "short2", UserUUID: "user2"},
+		{Short: "short3", UserUUID: "user1"},
+	}))
+	require.NoError(t, repo.Close())
+
+	// удаление пачки — одно событие журнала
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	for short, deleted := range map[string]bool{"short1": true, "short2": true, "short3": false} {
+		url, err := repo.Get(ctx, short)
+		require.NoError(t, err)
+		assert.Equal(t, deleted, url.IsDeleted, short)
+	}
+}
+
+func TestRepo_BlockMatching(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{UUID: "1", Short: "short1", Original: "http://evil.com/1"},
+		{UUID: "2", Short: "short2", Original: "http://google.com/2"},
+		{UUID: "3", Short: "short3", Original: "http://evil.com/3"},
+	})
+
+	isEvil := func(original string) bool { return strings.HasPrefix(original, "http://evil.com") }
+
+	blocked, err := repo.BlockMatching(ctx, isEvil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"short1", "short3"}, blocked)
+
+	// уже отключенные ссылки повторно не отключаются и в журнал не пишутся
+	blocked, err = repo.BlockMatching(ctx, isEvil)
+	require.NoError(t, err)
+	assert.Empty(t, blocked)
+	require.NoError(t, repo.Close())
+
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	for short, isBlocked := range map[string]bool{"short1": true, "short2": false, "short3": true} {
+		url, err := repo.Get(ctx, short)
+		require.NoError(t, err)
+		assert.Equal(t, isBlocked, url.IsBlocked, short)
+	}
+}
+
+func TestRepo_Update(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	changedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{UUID: "1", Short: "short1", Original: "http://google.com/1", UserUUID: "user1"},
+	})
+
+	_, err = repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user1", Original: "http://google.com/new", ChangedAt: changedAt})
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user1", NewUserUUID: "user2", ChangedAt: changedAt})
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	want := []storage.URLChange{
+		{Short: "short1", ChangedBy: "user1", OldOriginal: "http://google.com/1", NewOriginal: "http://google.com/new", ChangedAt: changedAt},
+		{Short: "short1", ChangedBy: "user1", OldUserUUID: "user1", NewUserUUID: "user2", ChangedAt: changedAt},
+	}
+
+	check := func(repo *Repo) {
+		url, err := repo.Get(ctx, "short1")
+		require.NoError(t, err)
+		assert.Equal(t, "http://google.com/new", url.Original)
+		assert.Equal(t, "user2", url.UserUUID)
+
+		old, err := repo.GetByOriginal(ctx, "http://google.com/1")
+		require.NoError(t, err)
+		assert.Empty(t, old.Short)
+
+		changes, err := repo.GetChanges(ctx, "short1")
+		require.NoError(t, err)
+		assert.Equal(t, want, changes)
+	}
+
+	// изменения проигрываются из журнала
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	check(repo)
+
+	// и переживают сжатие
+	require.NoError(t, repo.Compact(ctx))
+	require.NoError(t, repo.Close())
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+	check(repo)
+}
+
+func TestRepo_RestoreAndPurge(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+	since := time.Now().Add(-time.Hour)
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{UUID: "1", Short: "short1", Original: "http://google.com/1", UserUUID: "user1"},
+		{UUID: "2", Short: "short2", Original: "http://google.com/2", UserUUID: "user1", ExpiresAt: &longAgo},
+		{UUID: "3", Short: "short3", Original: "http://google.com/3", UserUUID: "user1"},
+	})
+
+	_, err = repo.Update(ctx, storage.URLUpdate{Short: "short2", UserUUID: "user1", Original: "http://google.com/2-moved", ChangedAt: time.Now()})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, []string{"short1", "short3"}, "user1"))
+	_, err = repo.DeleteExpired(ctx, longAgo)
+	require.NoError(t, err)
+
+	restored, err := repo.Restore(ctx, []string{"short1", "short2"}, "user1", since)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short1"}, restored)
+
+	purged, err := repo.Purge(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short2"}, purged)
+	require.NoError(t, repo.Close())
+
+	check := func(repo *Repo) {
+		for short, want := range map[string]struct{ exists, deleted bool }{
+			"short1": {exists: true},
+			"short2": {},
+			"short3": {exists: true, deleted: true},
+		} {
+			url, err := repo.Get(ctx, short)
+			require.NoError(t, err)
+			assert.Equal(t, want.exists, url.Original != "", short)
+			assert.Equal(t, want.deleted, url.IsDeleted, short)
+			assert.Equal(t, want.deleted, url.DeletedAt != nil, short)
+		}
+
+		// журнал изменений удаляется вместе со ссылкой
+		changes, err := repo.GetChanges(ctx, "short2")
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	}
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	check(repo)
+
+	// при сжатии окончательно удаленные ссылки пропадают из журнала
+	require.NoError(t, repo.Compact(ctx))
+	require.NoError(t, repo.Close())
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+	check(repo)
+}
+
+func TestRepo_PurgeUnknownDeletionTime(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	// журнал, записанный до того, как в него стало попадать время удаления
+	log := `{"op":"create","urls":[{"uuid":"1","short_url":"short1","original_url":"http://google.com/1","user_uuid":"user1"}]}` + "\n" +
+		`{"op":"delete","shorts":["short1"]}` + "\n"
+	require.NoError(t, os.WriteFile(cfg.FileStoragePath, []byte(log), 0666))
+
+	loadedAt := time.Now()
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	url, err := repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	require.NotNil(t, url.DeletedAt)
+	assert.False(t, url.DeletedAt.Before(loadedAt))
+
+	// срок хранения отсчитывается от загрузки
+	purged, err := repo.Purge(ctx, loadedAt.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short1"}, purged)
+}
+
+func TestRepo_Compact(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+
+	expired := time.Now().Add(-time.Minute)
+
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/1", UserUUID: "user1"}))
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short2", Original: "http://google.com/2", UserUUID: "user1", ExpiresAt: &expired}))
+	require.NoError(t, repo.Delete(ctx, []string{"short1"}, "user1"))
+
+	deleted, err := repo.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, 4, countLines(t, cfg.FileStoragePath))
+
+	require.NoError(t, repo.Compact(ctx))
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+
+	// после сжатия запись продолжается в новый файл
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short3", Original: "http://google.com/3"}))
+	require.NoError(t, repo.Close())
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	for short, isDeleted := range map[string]bool{"short1": true, "short2": true, "short3": false} {
+		url, err := repo.Get(ctx, short)
+		require.NoError(t, err)
+		assert.NotEmpty(t, url.Original)
+		assert.Equal(t, isDeleted, url.IsDeleted, short)
+	}
+}
+
+func TestRepo_TornWrite(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/1"}))
+	require.NoError(t, repo.Close())
+
+	// процесс упал посреди записи события
+	file, err := os.OpenFile(cfg.FileStoragePath, os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"op":"create","urls":[{"short_url":"sho`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short2", Original: "http://google.com/2"}))
+	require.NoError(t, repo.Close())
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.UrlsCount)
+}
+
+func TestRepo_CorruptedEvent(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/1"}))
+	require.NoError(t, repo.Close())
+
+	// испорченная строка в середине журнала
+	file, err := os.OpenFile(cfg.FileStoragePath, os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	_, err = file.WriteString("{\"op\":\"create\",\"urls\":[{\"sho\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short2", Original: "http://google.com/2"}))
+	require.NoError(t, repo.Close())
+
+	repo, err = New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.UrlsCount)
+
+	// после сжатия испорченной строки в файле нет
+	require.NoError(t, repo.Compact(ctx))
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+}
+
+func TestRepo_LegacyFormat(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	legacy := `[{"uuid":"1","short_url":"short1","original_url":"http://google.com/1","user_uuid":"user1","IsDeleted":false},` +
+		`{"uuid":"2","short_url":"short2","original_url":"http://google.com/2","user_uuid":"user1","IsDeleted":true}]`
+	require.NoError(t, os.WriteFile(cfg.FileStoragePath, []byte(legacy), 0666))
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	assert.Equal(t, 2, countLines(t, cfg.FileStoragePath))
+
+	url, err := repo.Get(ctx, "short2")
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/2", url.Original)
+	assert.True(t, url.IsDeleted)
+}
+
+func TestNew_UnknownSyncPolicy(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.FileSyncPolicy = "sometimes"
+
+	_, err := New(cfg)
+	assert.ErrorIs(t, err, ErrUnknownSyncPolicy)
+}
+
+func TestNew_SyncIntervalFallback(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.FileSyncPolicy = SyncInterval
+	cfg.FileSyncInterval = config.Duration{Duration: 0}
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	// журнал сбрасывается на диск и без заданного интервала
+	assert.NotNil(t, repo.stop)
+}
+
+func mustCreateBatch(t *testing.T, repo *Repo, urls []storage.URL) {
+	results, err := repo.CreateBatch(context.Background(), urls)
+	require.NoError(t, err)
+	for _, result := range results {
+		require.Equal(t, storage.BatchCreated, result.Status)
+	}
+}
+
+func TestRepo_CheckWritable(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+
+	repo, err := New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	require.NoError(t, repo.CheckWritable(ctx))
+
+	// проверка не оставляет после себя файлов
+	entries, err := os.ReadDir(filepath.Dir(cfg.FileStoragePath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// журнал удалили — записи уходят в никуда
+	require.NoError(t, os.Remove(cfg.FileStoragePath))
+	assert.Error(t, repo.CheckWritable(ctx))
+}
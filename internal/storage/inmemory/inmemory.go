@@ -1,45 +1,80 @@
 // модуль отвечает за сохранение данных о ссылках в оперативной памяти.
+// ссылки проиндексированы по короткому адресу, оригинальной ссылке и пользователю,
+// доступ к ним защищен RWMutex — хранилище можно использовать из нескольких горутин.
 package inmemory
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/augustjourney/urlshrt/internal/storage"
 )
 
 // репозиторий с методами хранилища
-type Repo struct{}
-
-// слайс для хранения ссылок в памяти
-var UrlsInMemory []storage.URL
+type Repo struct {
+	mu sync.RWMutex
+	// ссылки по короткому адресу
+	byShort map[string]*storage.URL
+	// короткий адрес по оригинальной ссылке
+	byOriginal map[string]string
+	// короткие адреса пользователя в порядке создания
+	byUser map[string][]string
+	// журнал изменений по короткому адресу
+	changes map[string][]storage.URLChange
+}
 
 // сохраняет ссылку в хранилище
 func (r *Repo) Create(ctx context.Context, url storage.URL) error {
-	foundURL, err := r.GetByOriginal(ctx, url.Original)
+	results, err := r.CreateBatch(ctx, []storage.URL{url})
 	if err != nil {
 		return err
 	}
-	if foundURL.Short != "" {
-		return storage.ErrAlreadyExists
-	}
-	UrlsInMemory = append(UrlsInMemory, url)
-	return nil
+	return results[0].Err()
 }
 
 // сохраняет множество ссылок в хранилище
-func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) error {
-	UrlsInMemory = append(UrlsInMemory, urls...)
-	return nil
+// ссылки, которые конфликтуют с уже сохраненными, пропускаются
+func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]storage.BatchResult, len(urls))
+	now := time.Now()
+
+	for i, url := range urls {
+		if short, ok := r.byOriginal[url.Original]; ok {
+			results[i] = storage.BatchResult{Status: storage.BatchExisted, Short: short}
+			continue
+		}
+		if _, ok := r.byShort[url.Short]; ok {
+			results[i] = storage.BatchResult{Status: storage.BatchShortTaken, Short: url.Short}
+			continue
+		}
+
+		stored := url
+		if stored.CreatedAt.IsZero() {
+			stored.CreatedAt = now
+		}
+		r.byShort[url.Short] = &stored
+		r.byOriginal[url.Original] = url.Short
+		r.byUser[url.UserUUID] = append(r.byUser[url.UserUUID], url.Short)
+
+		results[i] = storage.BatchResult{Status: storage.BatchCreated, Short: url.Short}
+	}
+
+	return results, nil
 }
 
 // получает экземпляр ссылки по короткой
 func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	var url storage.URL
-	for i := 0; i < len(UrlsInMemory); i++ {
-		if UrlsInMemory[i].Short == short {
-			url = UrlsInMemory[i]
-			break
-		}
+
+	if stored, ok := r.byShort[short]; ok {
+		url = *stored
 	}
 
 	return &url, nil
@@ -47,74 +82,241 @@ func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
 
 // получает внутренню статистику: количество сохранненых ссылок и количество пользователей
 func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
-	var urlsCount int
-	users := make(map[string]bool)
-	for i := 0; i < len(UrlsInMemory); i++ {
-		if UrlsInMemory[i].UserUUID != "" {
-			users[UrlsInMemory[i].UserUUID] = true
-		}
-		if UrlsInMemory[i].Short != "" {
-			urlsCount++
-		}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	usersCount := len(r.byUser)
+	if _, ok := r.byUser[""]; ok {
+		usersCount--
 	}
-	usersCount := len(users)
+
 	return storage.Stats{
-		UrlsCount:  urlsCount,
+		UrlsCount:  len(r.byShort),
 		UsersCount: usersCount,
 	}, nil
 }
 
-// получает ссылки пользователя
-func (r *Repo) GetByUserUUID(ctx context.Context, userUUID string) (*[]storage.URL, error) {
-	var urls []storage.URL
+// получает страницу неудаленных ссылок пользователя
+func (r *Repo) ListByUser(ctx context.Context, userUUID string, opts storage.ListOptions) ([]storage.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	urls := make([]storage.URL, 0, len(r.byUser[userUUID]))
 
-	for i := 0; i < len(UrlsInMemory); i++ {
-		if UrlsInMemory[i].UserUUID == userUUID && !UrlsInMemory[i].IsDeleted {
-			urls = append(urls, UrlsInMemory[i])
+	for _, short := range r.byUser[userUUID] {
+		url := r.byShort[short]
+		if !url.IsDeleted {
+			urls = append(urls, *url)
 		}
 	}
 
-	return &urls, nil
+	return storage.Page(urls, opts), nil
 }
 
-// удаляет ссылку
+// помечает удаленными ссылки пользователя
 func (r *Repo) Delete(ctx context.Context, shortURLs []string, userUUID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	shortUrlsMap := make(map[string]bool)
+	now := time.Now()
 
 	for _, short := range shortURLs {
-		shortUrlsMap[short] = true
+		r.deleteOwned(short, userUUID, now)
 	}
 
-	for i := 0; i < len(UrlsInMemory); i++ {
-		url := UrlsInMemory[i]
-		_, ok := shortUrlsMap[url.Short]
+	return nil
+}
 
-		if userUUID == "" {
-			UrlsInMemory[i].IsDeleted = true
-		} else if url.UserUUID == userUUID && ok {
-			UrlsInMemory[i].IsDeleted = true
-		}
+// помечает удаленными ссылки разных пользователей
+func (r *Repo) DeleteBatch(ctx context.Context, deletions []storage.Deletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for _, deletion := range deletions {
+		r.deleteOwned(deletion.Short, deletion.UserUUID, now)
 	}
 
 	return nil
 }
 
+// помечает ссылку удаленной, если ею владеет пользователь — вызывается под блокировкой
+func (r *Repo) deleteOwned(short string, userUUID string, now time.Time) {
+	url, ok := r.byShort[short]
+	if ok && url.UserUUID == userUUID && !url.IsDeleted {
+		url.IsDeleted = true
+		url.DeletedAt = &now
+	}
+}
+
+// помечает удаленными ссылки, срок жизни которых истек к моменту now
+func (r *Repo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int
+
+	for _, url := range r.byShort {
+		if url.IsDeleted || url.ExpiresAt == nil || url.ExpiresAt.After(now) {
+			continue
+		}
+		url.IsDeleted = true
+		url.DeletedAt = &now
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// снимает пометку об удалении со ссылок пользователя, удаленных не раньше deletedAfter
+func (r *Repo) Restore(ctx context.Context, shortURLs []string, userUUID string, deletedAfter time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var restored []string
+
+	for _, short := range shortURLs {
+		url, ok := r.byShort[short]
+		if !ok || url.UserUUID != userUUID || !url.IsDeleted || !storage.DeletedSince(*url, deletedAfter) {
+			continue
+		}
+		url.IsDeleted = false
+		url.DeletedAt = nil
+		restored = append(restored, short)
+	}
+
+	return restored, nil
+}
+
+// окончательно удаляет ссылки, удаленные раньше deletedBefore
+func (r *Repo) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []string
+
+	for short, url := range r.byShort {
+		if !url.IsDeleted || storage.DeletedSince(*url, deletedBefore) {
+			continue
+		}
+
+		delete(r.byShort, short)
+		if r.byOriginal[url.Original] == short {
+			delete(r.byOriginal, url.Original)
+		}
+		r.removeFromUser(url.UserUUID, short)
+		delete(r.changes, short)
+		purged = append(purged, short)
+	}
+
+	return purged, nil
+}
+
+// отключает ссылки, оригинальный адрес которых подходит под match
+func (r *Repo) BlockMatching(ctx context.Context, match func(original string) bool) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var blocked []string
+
+	for short, url := range r.byShort {
+		if url.IsBlocked || url.IsDeleted || !match(url.Original) {
+			continue
+		}
+		url.IsBlocked = true
+		blocked = append(blocked, short)
+	}
+
+	return blocked, nil
+}
+
 // получает экземпляр ссылки по оригинальной
 func (r *Repo) GetByOriginal(ctx context.Context, original string) (*storage.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	var url storage.URL
-	for i := 0; i < len(UrlsInMemory); i++ {
-		if UrlsInMemory[i].Original == original {
-			url = UrlsInMemory[i]
+
+	if short, ok := r.byOriginal[original]; ok {
+		url = *r.byShort[short]
+	}
+
+	return &url, nil
+}
+
+// меняет адрес или владельца ссылки и запоминает изменение
+func (r *Repo) Update(ctx context.Context, update storage.URLUpdate) (*storage.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, ok := r.byShort[update.Short]
+	if !ok || url.IsDeleted || url.UserUUID != update.UserUUID {
+		return nil, storage.ErrNotFound
+	}
+
+	change, changed := update.Change(*url)
+	if !changed {
+		updated := *url
+		return &updated, nil
+	}
+
+	if change.NewOriginal != "" {
+		if _, ok := r.byOriginal[change.NewOriginal]; ok {
+			return nil, storage.ErrAlreadyExists
+		}
+		delete(r.byOriginal, change.OldOriginal)
+		r.byOriginal[change.NewOriginal] = url.Short
+	}
+
+	if change.NewUserUUID != "" {
+		r.removeFromUser(change.OldUserUUID, url.Short)
+		r.byUser[change.NewUserUUID] = append(r.byUser[change.NewUserUUID], url.Short)
+	}
+
+	change.Apply(url)
+	r.changes[url.Short] = append(r.changes[url.Short], change)
+
+	updated := *url
+	return &updated, nil
+}
+
+// убирает короткий адрес из ссылок пользователя — вызывается под блокировкой
+func (r *Repo) removeFromUser(userUUID string, short string) {
+	shorts := r.byUser[userUUID]
+	for i, s := range shorts {
+		if s == short {
+			shorts = append(shorts[:i:i], shorts[i+1:]...)
 			break
 		}
 	}
 
-	return &url, nil
+	if len(shorts) == 0 {
+		delete(r.byUser, userUUID)
+		return
+	}
+	r.byUser[userUUID] = shorts
+}
+
+// получает журнал изменений ссылки
+func (r *Repo) GetChanges(ctx context.Context, short string) ([]storage.URLChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]storage.URLChange(nil), r.changes[short]...), nil
+}
+
+// хранилище в памяти всегда доступно
+func (r *Repo) Ping(ctx context.Context) error {
+	return nil
 }
 
 // создает новый экземпляр inmemory-репозитория
 func New() *Repo {
-	UrlsInMemory = make([]storage.URL, 100000)
-	return &Repo{}
+	return &Repo{
+		byShort:    make(map[string]*storage.URL),
+		byOriginal: make(map[string]string),
+		byUser:     make(map[string][]string),
+		changes:    make(map[string][]storage.URLChange),
+	}
 }
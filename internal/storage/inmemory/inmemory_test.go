@@ -0,0 +1,339 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/1", UserUUID: "user1"}))
+	mustCreateBatch(t, repo, []storage.URL{
+		{Short: "short2", Original: "http://google.com/2", UserUUID: "user1"},
+		{Short: "short3", Original: "http://google.com/3", UserUUID: "user2"},
+	})
+
+	assert.ErrorIs(t, repo.Create(ctx, storage.URL{Short: "short4", Original: "http://google.com/1"}), storage.ErrAlreadyExists)
+	assert.ErrorIs(t, repo.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/4"}), storage.ErrShortAlreadyExists)
+
+	// конфликт одной ссылки не мешает сохранить остальные
+	results, err := repo.CreateBatch(ctx, []storage.URL{
+		{Short: "short5", Original: "http://google.com/5"},
+		{Short: "short5", Original: "http://google.com/6"},
+		{Short: "short6", Original: "http://google.com/2"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.BatchResult{
+		{Status: storage.BatchCreated, Short: "short5"},
+		{Status: storage.BatchShortTaken, Short: "short5"},
+		{Status: storage.BatchExisted, Short: "short2"},
+	}, results)
+
+	url, err := repo.Get(ctx, "short5")
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/5", url.Original)
+
+	url, err = repo.GetByOriginal(ctx, "http://google.com/2")
+	require.NoError(t, err)
+	assert.Equal(t, "short2", url.Short)
+
+	// изменение полученной ссылки не меняет хранилище
+	url.IsDeleted = true
+	url, err = repo.Get(ctx, "short2")
+	require.NoError(t, err)
+	assert.False(t, url.IsDeleted)
+
+	// удаляются только ссылки пользователя
+	require.NoError(t, repo.Delete(ctx, []string{"short2", "short3"}, "user1"))
+
+	urls, err := repo.ListByUser(ctx, "user1", storage.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, "short1", urls[0].Short)
+
+	urls, err = repo.ListByUser(ctx, "user2", storage.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Stats{UrlsCount: 4, UsersCount: 2}, stats)
+}
+
+func TestRepo_DeleteBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{Short: "short1", Original: "http://google.com/1", UserUUID: "user1"},
+		{Short: "short2", Original: "http://google.com/2", UserUUID: "user2"},
+		{Short: "short3", Original: "http://google.com/3", UserUUID: "user2"},
+	})
+
+	// ссылки разных пользователей удаляются за раз, чужие пропускаются
+	require.NoError(t, repo.DeleteBatch(ctx, []storage.Deletion{
+		{Short: "short1", UserUUID: "user1"},
+		{Short: "short2", UserUUID: "user2"},
+		{Short: "short3", UserUUID: "user1"},
+		{Short: "unknown", UserUUID: "user1"},
+	}))
+
+	for short, deleted := range map[string]bool{"short1": true, "short2": true, "short3": false} {
+		url, err := repo.Get(ctx, short)
+		require.NoError(t, err)
+		assert.Equal(t, deleted, url.IsDeleted, short)
+	}
+}
+
+func TestRepo_Update(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	now := time.Now()
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{Short: "short1", Original: "http://google.com/1", UserUUID: "user1"},
+		{Short: "short2", Original: "http://google.com/2", UserUUID: "user1"},
+	})
+
+	// чужую ссылку изменить нельзя
+	_, err := repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user2", Original: "http://google.com/new"})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user1", Original: "http://google.com/2"})
+	assert.ErrorIs(t, err, storage.ErrAlreadyExists)
+
+	url, err := repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user1", Original: "http://google.com/new", ChangedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, "http://google.com/new", url.Original)
+
+	// старый адрес освобождается
+	old, err := repo.GetByOriginal(ctx, "http://google.com/1")
+	require.NoError(t, err)
+	assert.Empty(t, old.Short)
+
+	url, err = repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user1", NewUserUUID: "user2", ChangedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, "user2", url.UserUUID)
+
+	urls, err := repo.ListByUser(ctx, "user1", storage.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+
+	urls, err = repo.ListByUser(ctx, "user2", storage.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+
+	// изменение без изменений в журнал не попадает
+	_, err = repo.Update(ctx, storage.URLUpdate{Short: "short1", UserUUID: "user2", NewUserUUID: "user2"})
+	require.NoError(t, err)
+
+	changes, err := repo.GetChanges(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, []storage.URLChange{
+		{Short: "short1", ChangedBy: "user1", OldOriginal: "http://google.com/1", NewOriginal: "http://google.com/new", ChangedAt: now},
+		{Short: "short1", ChangedBy: "user1", OldUserUUID: "user1", NewUserUUID: "user2", ChangedAt: now},
+	}, changes)
+}
+
+func TestRepo_RestoreAndPurge(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{Short: "short1", Original: "http://google.com/1", UserUUID: "user1"},
+		{Short: "short2", Original: "http://google.com/2", UserUUID: "user1"},
+		{Short: "short3", Original: "http://google.com/3", UserUUID: "user1", ExpiresAt: &longAgo},
+		{Short: "short4", Original: "http://google.com/4", UserUUID: "user1"},
+	})
+
+	_, err := repo.Update(ctx, storage.URLUpdate{Short: "short3", UserUUID: "user1", Original: "http://google.com/3-moved", ChangedAt: time.Now()})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, []string{"short1", "short2"}, "user1"))
+
+	// short3 удалена давно — срок хранения прошел
+	deleted, err := repo.DeleteExpired(ctx, longAgo)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	url, err := repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	require.NotNil(t, url.DeletedAt)
+
+	since := time.Now().Add(-time.Hour)
+
+	restored, err := repo.Restore(ctx, []string{"short1", "short3", "short4"}, "user2", since)
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+
+	restored, err = repo.Restore(ctx, []string{"short1", "short3", "short4"}, "user1", since)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short1"}, restored)
+
+	url, err = repo.Get(ctx, "short1")
+	require.NoError(t, err)
+	assert.False(t, url.IsDeleted)
+	assert.Nil(t, url.DeletedAt)
+
+	purged, err := repo.Purge(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short3"}, purged)
+
+	for short, exists := range map[string]bool{"short1": true, "short2": true, "short3": false, "short4": true} {
+		url, err := repo.Get(ctx, short)
+		require.NoError(t, err)
+		assert.Equal(t, exists, url.Original != "", short)
+	}
+
+	// журнал изменений удаляется вместе со ссылкой
+	changes, err := repo.GetChanges(ctx, "short3")
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// адрес окончательно удаленной ссылки можно сократить заново
+	require.NoError(t, repo.Create(ctx, storage.URL{Short: "short5", Original: "http://google.com/3", UserUUID: "user2"}))
+}
+
+func TestRepo_ListByUser(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	mustCreateBatch(t, repo, []storage.URL{
+		{Short: "c", Original: "https://docs.example.com/guide", UserUUID: "user1", CreatedAt: at(2)},
+		{Short: "a", Original: "https://example.com/Pricing", UserUUID: "user1", CreatedAt: at(1)},
+		{Short: "b", Original: "http://example.com:8080/blog", UserUUID: "user1", CreatedAt: at(1)},
+		{Short: "d", Original: "https://other.org/example", UserUUID: "user1", CreatedAt: at(3)},
+		{Short: "e", Original: "https://example.com/deleted", UserUUID: "user1", CreatedAt: at(4)},
+		{Short: "f", Original: "https://example.com/foreign", UserUUID: "user2", CreatedAt: at(0)},
+	})
+	require.NoError(t, repo.Delete(ctx, []string{"e"}, "user1"))
+
+	tests := []struct {
+		name string
+		opts storage.ListOptions
+		want []string
+	}{
+		{name: "all by creation time", want: []string{"a", "b", "c", "d"}},
+		{name: "newest first", opts: storage.ListOptions{Desc: true}, want: []string{"d", "c", "b", "a"}},
+		{name: "limit", opts: storage.ListOptions{Limit: 2}, want: []string{"a", "b"}},
+		{name: "after cursor with same time", opts: storage.ListOptions{After: &storage.ListCursor{CreatedAt: at(1), Short: "a"}}, want: []string{"b", "c", "d"}},
+		{name: "after cursor newest first", opts: storage.ListOptions{Desc: true, After: &storage.ListCursor{CreatedAt: at(2), Short: "c"}}, want: []string{"b", "a"}},
+		{name: "contains ignores case", opts: storage.ListOptions{Contains: "PRICING"}, want: []string{"a"}},
+		{name: "contains matches path too", opts: storage.ListOptions{Contains: "example"}, want: []string{"a", "b", "c", "d"}},
+		{name: "host", opts: storage.ListOptions{Host: "example.com"}, want: []string{"a", "b"}},
+		{name: "host and contains", opts: storage.ListOptions{Host: "example.com", Contains: "blog"}, want: []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls, err := repo.ListByUser(ctx, "user1", tt.opts)
+			require.NoError(t, err)
+
+			shorts := make([]string, 0, len(urls))
+			for _, url := range urls {
+				shorts = append(shorts, url.Short)
+			}
+			assert.Equal(t, tt.want, shorts)
+		})
+	}
+}
+
+func TestRepo_Instances(t *testing.T) {
+	ctx := context.Background()
+
+	first := New()
+	second := New()
+
+	require.NoError(t, first.Create(ctx, storage.URL{Short: "short1", Original: "http://google.com/1"}))
+
+	url, err := second.Get(ctx, "short1")
+	require.NoError(t, err)
+	assert.Empty(t, url.Original)
+}
+
+func TestRepo_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	const workers = 8
+	const perWorker = 200
+
+	expiresAt := time.Now().Add(-time.Minute)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			user := fmt.Sprintf("user%d", w)
+
+			for i := 0; i < perWorker; i++ {
+				short := fmt.Sprintf("w%d-%d", w, i)
+				url := storage.URL{Short: short, Original: "http://google.com/" + short, UserUUID: user}
+				if i%10 == 0 {
+					url.ExpiresAt = &expiresAt
+				}
+
+				if i%2 == 0 {
+					assert.NoError(t, repo.Create(ctx, url))
+				} else {
+					_, err := repo.CreateBatch(ctx, []storage.URL{url})
+					assert.NoError(t, err)
+				}
+
+				// все горутины пытаются занять один и тот же адрес
+				repo.Create(ctx, storage.URL{Short: "shared", Original: "http://google.com/shared/" + short})
+
+				got, err := repo.Get(ctx, short)
+				assert.NoError(t, err)
+				assert.Equal(t, url.Original, got.Original)
+
+				repo.GetByOriginal(ctx, url.Original)
+				repo.ListByUser(ctx, user, storage.ListOptions{})
+				repo.GetStats(ctx)
+
+				if i%3 == 0 {
+					assert.NoError(t, repo.Delete(ctx, []string{short}, user))
+				}
+				if i%50 == 0 {
+					repo.DeleteExpired(ctx, time.Now())
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, workers*perWorker+1, stats.UrlsCount)
+	assert.Equal(t, workers, stats.UsersCount)
+
+	url, err := repo.Get(ctx, "shared")
+	require.NoError(t, err)
+	assert.NotEmpty(t, url.Original)
+}
+
+func mustCreateBatch(t *testing.T, repo *Repo, urls []storage.URL) {
+	results, err := repo.CreateBatch(context.Background(), urls)
+	require.NoError(t, err)
+	for _, result := range results {
+		require.Equal(t, storage.BatchCreated, result.Status)
+	}
+}
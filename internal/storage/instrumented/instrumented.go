@@ -0,0 +1,163 @@
+// модуль instrumented — обертка над любым хранилищем ссылок,
+// которая пишет спан на каждую операцию и замеряет ее длительность и результат.
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/augustjourney/urlshrt/internal/tracing"
+)
+
+// Получатель замеров — operation совпадает с именем метода хранилища
+type Observer interface {
+	ObserveStorage(operation string, err error, duration time.Duration)
+}
+
+// хранилище с замерами операций
+type Repo struct {
+	repo     storage.IRepo
+	observer Observer
+}
+
+// начинает спан операции и замер ее длительности — их завершает возвращенная функция
+func (r *Repo) start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "storage."+operation)
+
+	return ctx, func(err error) {
+		if r.observer != nil {
+			r.observer.ObserveStorage(operation, err, time.Since(start))
+		}
+		tracing.End(span, err)
+	}
+}
+
+// сохраняет ссылку
+func (r *Repo) Create(ctx context.Context, url storage.URL) error {
+	ctx, done := r.start(ctx, "Create")
+	err := r.repo.Create(ctx, url)
+	done(err)
+	return err
+}
+
+// получает ссылку по короткому адресу
+func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
+	ctx, done := r.start(ctx, "Get")
+	url, err := r.repo.Get(ctx, short)
+	done(err)
+	return url, err
+}
+
+// получает ссылку по оригинальному адресу
+func (r *Repo) GetByOriginal(ctx context.Context, original string) (*storage.URL, error) {
+	ctx, done := r.start(ctx, "GetByOriginal")
+	url, err := r.repo.GetByOriginal(ctx, original)
+	done(err)
+	return url, err
+}
+
+// сохраняет несколько ссылок
+func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	ctx, done := r.start(ctx, "CreateBatch")
+	results, err := r.repo.CreateBatch(ctx, urls)
+	done(err)
+	return results, err
+}
+
+// получает страницу ссылок пользователя
+func (r *Repo) ListByUser(ctx context.Context, userUUID string, opts storage.ListOptions) ([]storage.URL, error) {
+	ctx, done := r.start(ctx, "ListByUser")
+	urls, err := r.repo.ListByUser(ctx, userUUID, opts)
+	done(err)
+	return urls, err
+}
+
+// помечает ссылки удаленными
+func (r *Repo) Delete(ctx context.Context, shorts []string, userID string) error {
+	ctx, done := r.start(ctx, "Delete")
+	err := r.repo.Delete(ctx, shorts, userID)
+	done(err)
+	return err
+}
+
+// помечает удаленными ссылки разных пользователей
+func (r *Repo) DeleteBatch(ctx context.Context, deletions []storage.Deletion) error {
+	ctx, done := r.start(ctx, "DeleteBatch")
+	err := r.repo.DeleteBatch(ctx, deletions)
+	done(err)
+	return err
+}
+
+// получает статистику хранилища
+func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
+	ctx, done := r.start(ctx, "GetStats")
+	stats, err := r.repo.GetStats(ctx)
+	done(err)
+	return stats, err
+}
+
+// помечает истекшие ссылки удаленными
+func (r *Repo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	ctx, done := r.start(ctx, "DeleteExpired")
+	deleted, err := r.repo.DeleteExpired(ctx, now)
+	done(err)
+	return deleted, err
+}
+
+// восстанавливает удаленные ссылки
+func (r *Repo) Restore(ctx context.Context, shorts []string, userUUID string, deletedAfter time.Time) ([]string, error) {
+	ctx, done := r.start(ctx, "Restore")
+	restored, err := r.repo.Restore(ctx, shorts, userUUID, deletedAfter)
+	done(err)
+	return restored, err
+}
+
+// окончательно удаляет ссылки
+func (r *Repo) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	ctx, done := r.start(ctx, "Purge")
+	purged, err := r.repo.Purge(ctx, deletedBefore)
+	done(err)
+	return purged, err
+}
+
+// отключает ссылки по адресу
+func (r *Repo) BlockMatching(ctx context.Context, match func(original string) bool) ([]string, error) {
+	ctx, done := r.start(ctx, "BlockMatching")
+	blocked, err := r.repo.BlockMatching(ctx, match)
+	done(err)
+	return blocked, err
+}
+
+// меняет ссылку
+func (r *Repo) Update(ctx context.Context, update storage.URLUpdate) (*storage.URL, error) {
+	ctx, done := r.start(ctx, "Update")
+	url, err := r.repo.Update(ctx, update)
+	done(err)
+	return url, err
+}
+
+// получает журнал изменений ссылки
+func (r *Repo) GetChanges(ctx context.Context, short string) ([]storage.URLChange, error) {
+	ctx, done := r.start(ctx, "GetChanges")
+	changes, err := r.repo.GetChanges(ctx, short)
+	done(err)
+	return changes, err
+}
+
+// проверяет доступность хранилища
+func (r *Repo) Ping(ctx context.Context) error {
+	ctx, done := r.start(ctx, "Ping")
+	err := r.repo.Ping(ctx)
+	done(err)
+	return err
+}
+
+// создает обертку, которая передает замеры операций хранилища observer, observer == nil — только спаны
+func New(repo storage.IRepo, observer Observer) *Repo {
+	return &Repo{
+		repo:     repo,
+		observer: observer,
+	}
+}
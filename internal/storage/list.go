@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ключ ссылки, после которой начинается следующая страница.
+// ссылки упорядочены по времени создания, при равном времени — по короткому адресу
+type ListCursor struct {
+	CreatedAt time.Time
+	Short     string
+}
+
+// параметры получения ссылок пользователя постранично
+type ListOptions struct {
+	// последняя ссылка предыдущей страницы, nil — первая страница
+	After *ListCursor
+	// сколько ссылок вернуть, 0 — все
+	Limit int
+	// сначала новые
+	Desc bool
+	// подстрока оригинального адреса, без учета регистра
+	Contains string
+	// хост оригинального адреса в нижнем регистре и punycode, без порта
+	Host string
+}
+
+// проверяет, подходит ли ссылка под фильтры
+func (o ListOptions) Match(u URL) bool {
+	if o.Contains != "" && !strings.Contains(strings.ToLower(u.Original), strings.ToLower(o.Contains)) {
+		return false
+	}
+
+	if o.Host != "" {
+		parsed, err := url.Parse(u.Original)
+		if err != nil || parsed.Hostname() != o.Host {
+			return false
+		}
+	}
+
+	return true
+}
+
+// сравнивает ссылку с ключом в порядке списка: -1 — раньше ключа, 1 — позже
+func compareCursor(u URL, c ListCursor) int {
+	if !u.CreatedAt.Equal(c.CreatedAt) {
+		if u.CreatedAt.Before(c.CreatedAt) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(u.Short, c.Short)
+}
+
+// выбирает страницу из ссылок пользователя: фильтрует, сортирует и обрезает по ключу и лимиту.
+// для хранилищ, которые держат ссылки в памяти
+func Page(urls []URL, opts ListOptions) []URL {
+	page := make([]URL, 0, len(urls))
+
+	for _, u := range urls {
+		if !opts.Match(u) {
+			continue
+		}
+		if opts.After != nil {
+			cmp := compareCursor(u, *opts.After)
+			if (!opts.Desc && cmp <= 0) || (opts.Desc && cmp >= 0) {
+				continue
+			}
+		}
+		page = append(page, u)
+	}
+
+	sort.Slice(page, func(i, j int) bool {
+		cmp := compareCursor(page[i], ListCursor{CreatedAt: page[j].CreatedAt, Short: page[j].Short})
+		if opts.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	if opts.Limit > 0 && len(page) > opts.Limit {
+		page = page[:opts.Limit]
+	}
+
+	return page
+}
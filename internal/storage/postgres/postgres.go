@@ -5,130 +5,166 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/augustjourney/urlshrt/internal/storage"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// имя ограничения уникальности короткой ссылки, которое создает UNIQUE(short)
+const shortUniqueConstraint = "urls_short_key"
+
 // репозиторий с методами хранилища
 type Repo struct {
 	db *sql.DB
 }
 
-// создает изначальные таблицы и индексы в базе — если их нет
-func (r *Repo) Init(ctx context.Context) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	_, err = tx.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS urls (
-			id SERIAL PRIMARY KEY NOT NULL,
-			uuid VARCHAR(50) NOT NULL,
-			short VARCHAR(50) NOT NULL,
-			original VARCHAR NOT NULL,
-			UNIQUE(short)
-		)
-	`)
+// создает ссылку в бд
+func (r *Repo) Create(ctx context.Context, url storage.URL) error {
+
+	_, err := r.db.ExecContext(ctx, `
+		insert into urls (uuid, short, original, user_uuid, expires_at, created_at)
+		values ($1, $2, $3, $4, $5, $6)
+	`, url.UUID, url.Short, url.Original, url.UserUUID, url.ExpiresAt, createdAt(url))
 
 	if err != nil {
-		tx.Rollback()
-		return err
+		return mapUniqueViolation(err)
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		CREATE INDEX IF NOT EXISTS short_idx ON urls (short);
-	`)
+	return err
+}
 
-	if err != nil {
-		tx.Rollback()
-		return err
+// время создания ссылки — текущее, если не задано
+func createdAt(url storage.URL) time.Time {
+	if url.CreatedAt.IsZero() {
+		return time.Now()
 	}
+	return url.CreatedAt
+}
 
-	_, err = tx.ExecContext(ctx, `
-		CREATE UNIQUE INDEX IF NOT EXISTS original_unique_idx ON urls (original);
-	`)
-
-	if err != nil {
-		tx.Rollback()
+// переводит ошибку уникальности postgres в ошибки хранилища:
+// занятая короткая ссылка — ErrShortAlreadyExists, остальное — ErrAlreadyExists
+func mapUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
 		return err
 	}
+	if pgErr.ConstraintName == shortUniqueConstraint {
+		return storage.ErrShortAlreadyExists
+	}
+	return storage.ErrAlreadyExists
+}
 
-	_, err = tx.ExecContext(ctx, `
-		ALTER TABLE urls ADD COLUMN IF NOT EXISTS user_uuid VARCHAR;
-	`)
+// создает множество ссылок в бд одним запросом.
+// ссылки, которые конфликтуют с уже сохраненными, пропускаются:
+// для них по оригинальному адресу ищется уже существующая ссылка,
+// а если ее нет — значит, занят короткий адрес
+func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(urls))
+	if len(urls) == 0 {
+		return results, nil
+	}
 
-	if err != nil {
-		tx.Rollback()
-		return err
+	uuids := make([]string, len(urls))
+	shorts := make([]string, len(urls))
+	originals := make([]string, len(urls))
+	users := make([]string, len(urls))
+	expires := make([]*time.Time, len(urls))
+	createdAts := make([]time.Time, len(urls))
+
+	for i, url := range urls {
+		uuids[i] = url.UUID
+		shorts[i] = url.Short
+		originals[i] = url.Original
+		users[i] = url.UserUUID
+		expires[i] = url.ExpiresAt
+		createdAts[i] = createdAt(url)
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		ALTER TABLE urls ADD COLUMN IF NOT EXISTS is_deleted BOOLEAN DEFAULT false;
-	`)
+	rows, err := r.db.QueryContext(ctx, `
+		insert into urls (uuid, short, original, user_uuid, expires_at, created_at)
+		select * from unnest($1::varchar[], $2::varchar[], $3::varchar[], $4::varchar[], $5::timestamptz[], $6::timestamptz[])
+		on conflict do nothing
+		returning short, original
+	`, uuids, shorts, originals, users, expires, createdAts)
+	if err != nil {
+		return nil, err
+	}
 
+	// сохраненные ссылки: оригинальный адрес -> короткий
+	created, err := scanShorts(rows)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, err
 	}
 
-	return tx.Commit()
-}
+	var skipped []string
 
-// создает ссылку в бд
-func (r *Repo) Create(ctx context.Context, url storage.URL) error {
+	for i, url := range urls {
+		if short, ok := created[url.Original]; ok && short == url.Short {
+			results[i] = storage.BatchResult{Status: storage.BatchCreated, Short: url.Short}
+			continue
+		}
+		skipped = append(skipped, url.Original)
+	}
 
-	_, err := r.db.ExecContext(ctx, `
-		insert into urls (uuid, short, original, user_uuid)
-		values ($1, $2, $3, $4)
-	`, url.UUID, url.Short, url.Original, url.UserUUID)
+	if len(skipped) == 0 {
+		return results, nil
+	}
 
+	rows, err = r.db.QueryContext(ctx, `
+		select short, original from urls where original = any($1)
+	`, skipped)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			// Если такой url уже есть
-			// Возвращаем ошибку ErrAlreadyExists
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				return storage.ErrAlreadyExists
-			}
+		return nil, err
+	}
+
+	existing, err := scanShorts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, url := range urls {
+		if results[i].Status != "" {
+			continue
+		}
+		if short, ok := existing[url.Original]; ok {
+			results[i] = storage.BatchResult{Status: storage.BatchExisted, Short: short}
+		} else {
+			results[i] = storage.BatchResult{Status: storage.BatchShortTaken, Short: url.Short}
 		}
-		return err
 	}
 
-	return err
+	return results, nil
 }
 
-// создает множество ссылок в бд
-func (r *Repo) CreateBatch(ctx context.Context, urls []storage.URL) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
+// читает пары (short, original) в словарь оригинальный адрес -> короткий
+func scanShorts(rows *sql.Rows) (map[string]string, error) {
+	defer rows.Close()
 
-	for _, url := range urls {
-		_, err = tx.ExecContext(ctx, `
-			insert into urls (uuid, short, original, user_uuid)
-			values ($1, $2, $3, $4)
-		`, url.UUID, url.Short, url.Original, url.UserUUID)
+	shorts := make(map[string]string)
 
-		if err != nil {
-			tx.Rollback()
-			return err
+	for rows.Next() {
+		var short, original string
+		if err := rows.Scan(&short, &original); err != nil {
+			return nil, err
 		}
+		shorts[original] = short
 	}
 
-	return tx.Commit()
+	return shorts, rows.Err()
 }
 
 // получает внутренню статистику: количество сохранненых ссылок в бд и количество пользователей
 func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
 	var stats storage.Stats
 
+	// ссылки без владельца — с пустым или null user_uuid — пользователей не добавляют
 	query := `
-		select 
-			(select count(*) from urls) as urls_count, 
-			(select count(*) from (select distinct user_uuid from urls) as users_count) as users_count;
+		select
+			(select count(*) from urls) as urls_count,
+			(select count(distinct nullif(user_uuid, '')) from urls) as users_count;
 	`
 
 	row := r.db.QueryRowContext(ctx, query)
@@ -138,31 +174,229 @@ func (r *Repo) GetStats(ctx context.Context) (storage.Stats, error) {
 	return stats, err
 }
 
-// удаляет ссылку из бд
+// помечает удаленными ссылки пользователя
 func (r *Repo) Delete(ctx context.Context, shortURLs []string, userID string) error {
-	tx, err := r.db.Begin()
+	_, err := r.db.ExecContext(ctx, `
+		update urls
+		set is_deleted = true, deleted_at = now()
+		where user_uuid = $1 and short = any($2) and is_deleted = false
+	`, userID, shortURLs)
+
+	return err
+}
+
+// помечает удаленными ссылки разных пользователей одним запросом:
+// short = any($1) находит ссылки по индексу, а пары из unnest оставляют только ссылки владельцев
+func (r *Repo) DeleteBatch(ctx context.Context, deletions []storage.Deletion) error {
+	if len(deletions) == 0 {
+		return nil
+	}
+
+	shorts := make([]string, len(deletions))
+	users := make([]string, len(deletions))
+
+	for i, deletion := range deletions {
+		shorts[i] = deletion.Short
+		users[i] = deletion.UserUUID
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		update urls
+		set is_deleted = true, deleted_at = now()
+		where short = any($1) and is_deleted = false
+			and (short, user_uuid) in (select * from unnest($1::varchar[], $2::varchar[]))
+	`, shorts, users)
+
+	return err
+}
+
+// помечает удаленными ссылки, срок жизни которых истек к моменту now
+func (r *Repo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		update urls
+		set is_deleted = true, deleted_at = $1
+		where is_deleted = false and expires_at is not null and expires_at <= $1
+	`, now)
+
 	if err != nil {
-		return err
+		return 0, err
 	}
-	for _, short := range shortURLs {
-		_, err = tx.ExecContext(ctx, `
-			update urls
-			set is_deleted = true
-			where user_uuid = $1 and short = $2
-		`, userID, short)
 
+	affected, err := result.RowsAffected()
+
+	return int(affected), err
+}
+
+// снимает пометку об удалении со ссылок пользователя, удаленных не раньше deletedAfter
+func (r *Repo) Restore(ctx context.Context, shortURLs []string, userUUID string, deletedAfter time.Time) ([]string, error) {
+	var after *time.Time
+	if !deletedAfter.IsZero() {
+		after = &deletedAfter
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		update urls
+		set is_deleted = false, deleted_at = null
+		where user_uuid = $1 and short = any($2) and is_deleted = true
+			and ($3::timestamptz is null or deleted_at >= $3)
+		returning short
+	`, userUUID, shortURLs, after)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var restored []string
+
+	for rows.Next() {
+		var short string
+		err = rows.Scan(&short)
 		if err != nil {
-			return tx.Rollback()
+			return nil, err
 		}
+		restored = append(restored, short)
+	}
+
+	return restored, rows.Err()
+}
+
+// окончательно удаляет ссылки, удаленные раньше deletedBefore, вместе с их переходами
+// и журналом изменений — в одной транзакции.
+// время удаления у всех удаленных ссылок проставлено миграцией 0009 и запросами на удаление
+func (r *Repo) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	if deletedBefore.IsZero() {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		delete from urls
+		where is_deleted = true and deleted_at < $1
+		returning short
+	`, deletedBefore)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var shorts []string
+	for rows.Next() {
+		var short string
+		if err = rows.Scan(&short); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		shorts = append(shorts, short)
+	}
+
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(shorts) == 0 {
+		return nil, nil
+	}
+
+	// статистика и журнал изменений не должны достаться ссылке, которая займет тот же адрес
+	_, err = tx.ExecContext(ctx, `delete from clicks where short = any($1)`, shorts)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from url_changes where short = any($1)`, shorts)
+	if err != nil {
+		return nil, err
 	}
 
 	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return shorts, nil
+}
+
+// отключает неудаленные ссылки, оригинальный адрес которых подходит под match.
+// правила проверяются в приложении, поэтому адреса читаются из базы страницами по blockPageSize,
+// а подходящие ссылки каждой страницы отключаются одним запросом
+func (r *Repo) BlockMatching(ctx context.Context, match func(original string) bool) ([]string, error) {
+	var blocked []string
+	var lastID int64
+
+	for {
+		shorts, nextID, count, err := r.matchPage(ctx, lastID, match)
+		if err != nil {
+			return blocked, err
+		}
+
+		if len(shorts) > 0 {
+			_, err = r.db.ExecContext(ctx, `
+				update urls
+				set is_blocked = true
+				where short = any($1)
+			`, shorts)
+
+			if err != nil {
+				return blocked, err
+			}
+
+			blocked = append(blocked, shorts...)
+		}
+
+		if count < blockPageSize {
+			return blocked, nil
+		}
+
+		lastID = nextID
+	}
+}
+
+// сколько ссылок читается за раз при отключении заблокированных
+const blockPageSize = 1000
+
+// читает страницу неотключенных и неудаленных ссылок после afterID и возвращает подходящие под match,
+// id последней ссылки страницы и сколько ссылок в ней было
+func (r *Repo) matchPage(ctx context.Context, afterID int64, match func(original string) bool) ([]string, int64, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		select id, short, original
+		from urls
+		where is_blocked = false and is_deleted = false and id > $1
+		order by id
+		limit $2
+	`, afterID, blockPageSize)
 
 	if err != nil {
-		return tx.Rollback()
+		return nil, 0, 0, err
 	}
 
-	return nil
+	defer rows.Close()
+
+	var shorts []string
+	count := 0
+
+	for rows.Next() {
+		var short, original string
+		err = rows.Scan(&afterID, &short, &original)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		count++
+
+		if match(original) {
+			shorts = append(shorts, short)
+		}
+	}
+
+	return shorts, afterID, count, rows.Err()
 }
 
 // получает оригинальную ссылку по короткой
@@ -185,36 +419,111 @@ func (r *Repo) GetByOriginal(ctx context.Context, original string) (*storage.URL
 	return &url, nil
 }
 
-// получает информацию ссылке по короткой
+// получает информацию ссылке по короткой — вместе с владельцем, по нему сервис проверяет доступ к ссылке
 func (r *Repo) Get(ctx context.Context, short string) (*storage.URL, error) {
 	var url storage.URL
 
+	var userUUID sql.NullString
+
 	row := r.db.QueryRowContext(ctx, `
-		select uuid, short, original, is_deleted
+		select uuid, short, original, user_uuid, is_deleted, deleted_at, is_blocked, expires_at, created_at
 		from urls
 		where short = $1
 
 	`, short)
 
-	err := row.Scan(&url.UUID, &url.Short, &url.Original, &url.IsDeleted)
+	err := row.Scan(&url.UUID, &url.Short, &url.Original, &userUUID, &url.IsDeleted, &url.DeletedAt, &url.IsBlocked, &url.ExpiresAt, &url.CreatedAt)
+
+	// Как и остальные хранилища, для несуществующей ссылки возвращаем пустую
+	if errors.Is(err, sql.ErrNoRows) {
+		return &storage.URL{}, nil
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	url.UserUUID = userUUID.String
+
 	return &url, nil
 }
 
-// получает ссылки пользователя
-func (r *Repo) GetByUserUUID(ctx context.Context, userUUID string) (*[]storage.URL, error) {
-	var urls []storage.URL
+// меняет адрес или владельца ссылки и пишет запись об изменении в одной транзакции.
+// строка ссылки блокируется, чтобы параллельные изменения не перепутали журнал
+func (r *Repo) Update(ctx context.Context, update storage.URLUpdate) (*storage.URL, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	rows, err := r.db.QueryContext(ctx, `
-		select short, original 
+	var url storage.URL
+	var userUUID sql.NullString
+
+	err = tx.QueryRowContext(ctx, `
+		select uuid, short, original, user_uuid, is_deleted, is_blocked, expires_at
 		from urls
-		where user_uuid = $1
+		where short = $1
+		for update
+	`, update.Short).Scan(&url.UUID, &url.Short, &url.Original, &userUUID, &url.IsDeleted, &url.IsBlocked, &url.ExpiresAt)
 
-	`, userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	url.UserUUID = userUUID.String
+
+	if url.IsDeleted || url.UserUUID != update.UserUUID {
+		return nil, storage.ErrNotFound
+	}
+
+	change, changed := update.Change(url)
+	if !changed {
+		return &url, nil
+	}
+
+	change.Apply(&url)
+
+	_, err = tx.ExecContext(ctx, `
+		update urls
+		set original = $2, user_uuid = $3
+		where short = $1
+	`, url.Short, url.Original, url.UserUUID)
+
+	if err != nil {
+		return nil, mapUniqueViolation(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		insert into url_changes (short, changed_by, old_original, new_original, old_user_uuid, new_user_uuid, changed_at)
+		values ($1, $2, nullif($3, ''), nullif($4, ''), nullif($5, ''), nullif($6, ''), $7)
+	`, change.Short, change.ChangedBy, change.OldOriginal, change.NewOriginal, change.OldUserUUID, change.NewUserUUID, change.ChangedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
+
+// получает журнал изменений ссылки
+func (r *Repo) GetChanges(ctx context.Context, short string) ([]storage.URLChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		select short, changed_by, coalesce(old_original, ''), coalesce(new_original, ''),
+			coalesce(old_user_uuid, ''), coalesce(new_user_uuid, ''), changed_at
+		from url_changes
+		where short = $1
+		order by id
+	`, short)
 
 	if err != nil {
 		return nil, err
@@ -222,32 +531,92 @@ func (r *Repo) GetByUserUUID(ctx context.Context, userUUID string) (*[]storage.U
 
 	defer rows.Close()
 
+	var changes []storage.URLChange
+
 	for rows.Next() {
-		var url storage.URL
-		err = rows.Scan(&url.Short, &url.Original)
+		var change storage.URLChange
+		err = rows.Scan(&change.Short, &change.ChangedBy, &change.OldOriginal, &change.NewOriginal,
+			&change.OldUserUUID, &change.NewUserUUID, &change.ChangedAt)
 		if err != nil {
 			return nil, err
 		}
 
-		urls = append(urls, url)
+		changes = append(changes, change)
 	}
 
-	err = rows.Err()
+	return changes, rows.Err()
+}
+
+// хост оригинального адреса: ipv6 в квадратных скобках или имя до порта, пути или запроса.
+// адреса нормализованы при сокращении, поэтому схема и хост уже в нижнем регистре
+const hostExpr = `coalesce(
+	substring(original from '^[a-z]+://(?:[^/?#@]*@)?\[([^]]+)\]'),
+	substring(original from '^[a-z]+://(?:[^/?#@]*@)?([^/:?#]+)')
+)`
+
+// получает страницу неудаленных ссылок пользователя — фильтры, порядок и ключ страницы
+// применяются в запросе, а индекс (user_uuid, created_at, short) отдает строки уже по порядку
+func (r *Repo) ListByUser(ctx context.Context, userUUID string, opts storage.ListOptions) ([]storage.URL, error) {
+	order, after := "asc", ">"
+	if opts.Desc {
+		order, after = "desc", "<"
+	}
+
+	var cursorAt *time.Time
+	var cursorShort string
+	if opts.After != nil {
+		cursorAt = &opts.After.CreatedAt
+		cursorShort = opts.After.Short
+	}
+
+	var limit *int
+	if opts.Limit > 0 {
+		limit = &opts.Limit
+	}
+
+	query := fmt.Sprintf(`
+		select short, original, user_uuid, expires_at, created_at
+		from urls
+		where user_uuid = $1 and is_deleted = false
+			and ($2 = '' or strpos(lower(original), lower($2)) > 0)
+			and ($3 = '' or %s = $3)
+			and ($4::timestamptz is null or (created_at, short) %s ($4, $5))
+		order by created_at %s, short %s
+		limit $6
+	`, hostExpr, after, order, order)
+
+	rows, err := r.db.QueryContext(ctx, query, userUUID, opts.Contains, opts.Host, cursorAt, cursorShort, limit)
+
 	if err != nil {
 		return nil, err
 	}
 
-	return &urls, nil
+	defer rows.Close()
+
+	var urls []storage.URL
+
+	for rows.Next() {
+		var url storage.URL
+		err = rows.Scan(&url.Short, &url.Original, &url.UserUUID, &url.ExpiresAt, &url.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, url)
+	}
+
+	return urls, rows.Err()
+}
+
+// проверяет соединение с базой
+func (r *Repo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
 }
 
 // создает новый экземпляр postgres-репозитория
-func New(ctx context.Context, db *sql.DB) (*Repo, error) {
-	repo := Repo{
+// схема базы создается миграциями из модуля migrations
+func New(db *sql.DB) *Repo {
+	return &Repo{
 		db: db,
 	}
-	err := repo.Init(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return &repo, nil
 }
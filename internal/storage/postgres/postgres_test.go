@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/augustjourney/urlshrt/internal/config"
+	"github.com/augustjourney/urlshrt/internal/migrations"
+	"github.com/augustjourney/urlshrt/internal/service"
+	"github.com/augustjourney/urlshrt/internal/storage"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// сколько ссылок в пачке при замере
+const benchBatchSize = 10_000
+
+// подключается к тестовой базе из TEST_DATABASE_DSN, без нее тест пропускается
+func openTestDB(tb testing.TB) *sql.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		tb.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	require.NoError(tb, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(tb, err)
+
+	return db
+}
+
+// пользователь, ссылки которого удаляются после теста
+func newTestUser(t *testing.T, db *sql.DB) string {
+	user := uuid.NewString()
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), `delete from urls where user_uuid = $1`, user)
+	})
+	return user
+}
+
+func TestShortenExisting(t *testing.T) {
+	db := openTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+	user := newTestUser(t, db)
+
+	urlService := service.New(repo, &config.Config{BaseURL: "http://localhost:8080"})
+	original := fmt.Sprintf("http://example.com/%s", user)
+
+	created, err := urlService.Shorten(ctx, original, user, service.ShortenOptions{})
+	require.NoError(t, err)
+	assert.False(t, created.AlreadyExists)
+
+	// код из хэша совпадает, поэтому вставка упирается в уникальность короткого адреса раньше, чем оригинального
+	existing, err := urlService.Shorten(ctx, original, user, service.ShortenOptions{})
+	require.NoError(t, err)
+	assert.True(t, existing.AlreadyExists)
+	assert.Equal(t, created.ResultURL, existing.ResultURL)
+}
+
+func TestGetURLStatsOwner(t *testing.T) {
+	db := openTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+	owner := newTestUser(t, db)
+
+	urlService := service.New(repo, &config.Config{BaseURL: "http://localhost:8080"})
+
+	short := "own" + owner[:8]
+	err := repo.Create(ctx, storage.URL{
+		UUID:     uuid.NewString(),
+		Short:    short,
+		Original: fmt.Sprintf("http://example.com/%s", owner),
+		UserUUID: owner,
+	})
+	require.NoError(t, err)
+
+	// владелец читается вместе со ссылкой — без него проверка доступа не пропускает никого
+	url, err := repo.Get(ctx, short)
+	require.NoError(t, err)
+	assert.Equal(t, owner, url.UserUUID)
+
+	_, err = urlService.GetURLStats(ctx, short, owner)
+	require.NoError(t, err)
+
+	_, err = urlService.GetURLStats(ctx, short, uuid.NewString())
+	require.ErrorIs(t, err, service.ErrNotFound)
+}
+
+func TestBlockMatching(t *testing.T) {
+	db := openTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+	user := newTestUser(t, db)
+
+	// ссылок больше страницы — подходящие находятся на каждой
+	urls := make([]storage.URL, blockPageSize+10)
+	for i := range urls {
+		urls[i] = storage.URL{
+			UUID:     uuid.NewString(),
+			Short:    fmt.Sprintf("blk%s%d", user[:8], i),
+			Original: fmt.Sprintf("http://example.com/%s/%d", user, i),
+			UserUUID: user,
+		}
+	}
+	_, err := repo.CreateBatch(ctx, urls)
+	require.NoError(t, err)
+
+	first, last, deleted := urls[0], urls[len(urls)-1], urls[1]
+	require.NoError(t, repo.Delete(ctx, []string{deleted.Short}, user))
+
+	match := func(original string) bool {
+		return original == first.Original || original == last.Original || original == deleted.Original
+	}
+
+	blocked, err := repo.BlockMatching(ctx, match)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first.Short, last.Short}, blocked)
+
+	url, err := repo.Get(ctx, deleted.Short)
+	require.NoError(t, err)
+	assert.False(t, url.IsBlocked)
+
+	// отключенные ссылки повторно не отключаются
+	blocked, err = repo.BlockMatching(ctx, match)
+	require.NoError(t, err)
+	assert.Empty(t, blocked)
+}
+
+func TestPurge(t *testing.T) {
+	db := openTestDB(t)
+	repo := New(db)
+	ctx := context.Background()
+	user := newTestUser(t, db)
+
+	url := storage.URL{
+		UUID:     uuid.NewString(),
+		Short:    "prg" + user[:8],
+		Original: "http://example.com/purge/" + user,
+		UserUUID: user,
+	}
+	require.NoError(t, repo.Create(ctx, url))
+
+	_, err := repo.Update(ctx, storage.URLUpdate{Short: url.Short, UserUUID: user, Original: url.Original + "/moved", ChangedAt: time.Now()})
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, `insert into clicks (short, created_at) values ($1, now())`, url.Short)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, []string{url.Short}, user))
+
+	// срок хранения еще не прошел
+	_, err = repo.Purge(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	got, err := repo.Get(ctx, url.Short)
+	require.NoError(t, err)
+	assert.True(t, got.IsDeleted)
+
+	purged, err := repo.Purge(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Contains(t, purged, url.Short)
+
+	got, err = repo.Get(ctx, url.Short)
+	require.NoError(t, err)
+	assert.Empty(t, got.Original)
+
+	// переходы и журнал изменений не достаются ссылке, которая займет тот же адрес
+	var clicks int
+	require.NoError(t, db.QueryRowContext(ctx, `select count(*) from clicks where short = $1`, url.Short).Scan(&clicks))
+	assert.Zero(t, clicks)
+
+	changes, err := repo.GetChanges(ctx, url.Short)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func newBenchBatch(prefix string) []storage.URL {
+	urls := make([]storage.URL, benchBatchSize)
+	for i := range urls {
+		urls[i] = storage.URL{
+			UUID:     uuid.NewString(),
+			Short:    fmt.Sprintf("%s%d", prefix, i),
+			Original: fmt.Sprintf("http://bench.example.com/%s/%d", prefix, i),
+			UserUUID: "bench",
+		}
+	}
+	return urls
+}
+
+// прежний способ: отдельный insert на каждую ссылку в транзакции
+func insertOneByOne(ctx context.Context, db *sql.DB, urls []storage.URL) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		_, err = tx.ExecContext(ctx, `
+			insert into urls (uuid, short, original, user_uuid, expires_at)
+			values ($1, $2, $3, $4, $5)
+		`, url.UUID, url.Short, url.Original, url.UserUUID, url.ExpiresAt)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func BenchmarkCreateBatch(b *testing.B) {
+	db := openTestDB(b)
+	repo := New(db)
+	ctx := context.Background()
+
+	cleanup := func() {
+		_, err := db.ExecContext(ctx, `delete from urls where user_uuid = 'bench'`)
+		require.NoError(b, err)
+	}
+	cleanup()
+
+	b.Run("insert per url", func(b *testing.B) {
+		b.StopTimer()
+		for i := 0; i < b.N; i++ {
+			urls := newBenchBatch(fmt.Sprintf("b%d-", i))
+			b.StartTimer()
+			require.NoError(b, insertOneByOne(ctx, db, urls))
+			b.StopTimer()
+			cleanup()
+		}
+	})
+
+	b.Run("bulk insert", func(b *testing.B) {
+		b.StopTimer()
+		for i := 0; i < b.N; i++ {
+			urls := newBenchBatch(fmt.Sprintf("b%d-", i))
+			b.StartTimer()
+			results, err := repo.CreateBatch(ctx, urls)
+			b.StopTimer()
+			require.NoError(b, err)
+			require.Len(b, results, len(urls))
+			cleanup()
+		}
+	})
+}
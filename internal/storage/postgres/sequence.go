@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// последовательность postgres — источник чисел для последовательных коротких кодов
+// общая для всех реплик сервиса, поэтому коды не повторяются
+type Sequence struct {
+	db *sql.DB
+}
+
+// получает следующее значение последовательности
+func (s *Sequence) Next(ctx context.Context) (int64, error) {
+	var value int64
+
+	row := s.db.QueryRowContext(ctx, `select nextval('short_code_seq')`)
+
+	err := row.Scan(&value)
+
+	return value, err
+}
+
+// создает новый экземпляр последовательности коротких кодов
+func NewSequence(db *sql.DB) *Sequence {
+	return &Sequence{
+		db: db,
+	}
+}
@@ -5,35 +5,172 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // хранит информацию о ссылке
 type URL struct {
-	UUID      string `json:"uuid,omitempty"`
-	Short     string `json:"short_url"`
-	Original  string `json:"original_url"`
-	UserUUID  string `json:"user_uuid,omitempty"`
+	UUID      string     `json:"uuid,omitempty"`
+	Short     string     `json:"short_url"`
+	Original  string     `json:"original_url"`
+	UserUUID  string     `json:"user_uuid,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// когда ссылка создана — хранилища ставят текущее время, если не задано
+	CreatedAt time.Time `json:"created_at"`
 	IsDeleted bool
+	// когда ссылка удалена, nil — не удалена или время удаления неизвестно
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ссылка отключена: ее адрес попал в список заблокированных
+	IsBlocked bool
 }
 
 // хранит информацию о статистике:
 // количество сохраненных ссылок
-// и количество пользователей
+// и количество пользователей — владельцев хотя бы одной из них.
+// ссылки без владельца пользователей не добавляют
 type Stats struct {
 	UrlsCount  int `json:"urls" db:"urls_count"`
 	UsersCount int `json:"users" db:"users_count"`
 }
 
+// ссылка, которую пользователь просит удалить
+type Deletion struct {
+	Short    string
+	UserUUID string
+}
+
+// изменение ссылки ее владельцем
+type URLUpdate struct {
+	Short string
+	// текущий владелец — изменить ссылку может только он
+	UserUUID string
+	// новый оригинальный адрес, пустой — адрес не меняется
+	Original string
+	// новый владелец, пустой — владелец не меняется
+	NewUserUUID string
+	ChangedAt   time.Time
+}
+
+// запись журнала изменений ссылки: что было и что стало.
+// поля, которые не менялись, пустые
+type URLChange struct {
+	Short       string    `json:"short_url"`
+	ChangedBy   string    `json:"changed_by"`
+	OldOriginal string    `json:"old_original_url,omitempty"`
+	NewOriginal string    `json:"new_original_url,omitempty"`
+	OldUserUUID string    `json:"old_user_uuid,omitempty"`
+	NewUserUUID string    `json:"new_user_uuid,omitempty"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+// запись об изменении ссылки url; false — если изменение ничего не меняет
+func (u URLUpdate) Change(url URL) (URLChange, bool) {
+	change := URLChange{
+		Short:     url.Short,
+		ChangedBy: u.UserUUID,
+		ChangedAt: u.ChangedAt,
+	}
+
+	if u.Original != "" && u.Original != url.Original {
+		change.OldOriginal = url.Original
+		change.NewOriginal = u.Original
+	}
+
+	if u.NewUserUUID != "" && u.NewUserUUID != url.UserUUID {
+		change.OldUserUUID = url.UserUUID
+		change.NewUserUUID = u.NewUserUUID
+	}
+
+	return change, change.NewOriginal != "" || change.NewUserUUID != ""
+}
+
+// применяет изменение к ссылке
+func (c URLChange) Apply(url *URL) {
+	if c.NewOriginal != "" {
+		url.Original = c.NewOriginal
+	}
+	if c.NewUserUUID != "" {
+		url.UserUUID = c.NewUserUUID
+	}
+}
+
+// проверяет, что удаленная ссылка удалена не раньше since.
+// нулевой since подходит под любое время, неизвестное время удаления — только под нулевой since
+func DeletedSince(url URL, since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+	return url.DeletedAt != nil && !url.DeletedAt.Before(since)
+}
+
+// Результаты сохранения ссылки из пачки
+const (
+	// ссылка сохранена
+	BatchCreated = "created"
+	// ссылка с тем же оригинальным адресом уже сохранена — Short указывает на нее
+	BatchExisted = "existed"
+	// короткий адрес занят другой ссылкой, ссылка не сохранена
+	BatchShortTaken = "short_taken"
+)
+
+// результат сохранения ссылки из пачки
+type BatchResult struct {
+	Status string
+	// короткий адрес сохраненной ссылки: новой или уже существовавшей
+	Short string
+}
+
+// ошибка, которую вернуло бы сохранение этой ссылки по одной
+func (r BatchResult) Err() error {
+	switch r.Status {
+	case BatchExisted:
+		return ErrAlreadyExists
+	case BatchShortTaken:
+		return ErrShortAlreadyExists
+	}
+	return nil
+}
+
 // описывает методы хранилища
 type IRepo interface {
 	Create(ctx context.Context, url URL) error
 	Get(ctx context.Context, short string) (*URL, error)
 	GetByOriginal(ctx context.Context, original string) (*URL, error)
-	CreateBatch(ctx context.Context, urls []URL) error
-	GetByUserUUID(ctx context.Context, userUUID string) (*[]URL, error)
+	// сохраняет ссылки, которые еще не сохранены, и возвращает результаты в порядке urls;
+	// конфликт одной ссылки не мешает сохранить остальные
+	CreateBatch(ctx context.Context, urls []URL) ([]BatchResult, error)
+	// получает страницу неудаленных ссылок пользователя
+	ListByUser(ctx context.Context, userUUID string, opts ListOptions) ([]URL, error)
 	Delete(ctx context.Context, short []string, userID string) error
+	// помечает удаленными ссылки разных пользователей за один раз,
+	// ссылки, которыми пользователь не владеет, пропускаются
+	DeleteBatch(ctx context.Context, deletions []Deletion) error
 	GetStats(ctx context.Context) (Stats, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+	// снимает пометку об удалении со ссылок пользователя, удаленных не раньше deletedAfter,
+	// и возвращает их короткие адреса; нулевой deletedAfter — удаленных когда угодно
+	Restore(ctx context.Context, shorts []string, userUUID string, deletedAfter time.Time) ([]string, error)
+	// окончательно удаляет ссылки, удаленные раньше deletedBefore, вместе с их журналом изменений,
+	// и возвращает их короткие адреса;
+	// ссылки, время удаления которых неизвестно, считаются удаленными при загрузке хранилища
+	Purge(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	// отключает еще не отключенные ссылки, оригинальный адрес которых подходит под match,
+	// и возвращает их короткие адреса
+	BlockMatching(ctx context.Context, match func(original string) bool) ([]string, error)
+	// меняет адрес или владельца ссылки и сохраняет запись об изменении в журнал.
+	// ErrNotFound — ссылки нет, она удалена или принадлежит другому пользователю,
+	// ErrAlreadyExists — новый адрес уже сокращен в другую ссылку
+	Update(ctx context.Context, update URLUpdate) (*URL, error)
+	// получает журнал изменений ссылки в порядке изменений
+	GetChanges(ctx context.Context, short string) ([]URLChange, error)
+	Ping(ctx context.Context) error
 }
 
+// ошибка если ссылки нет или изменить ее нельзя
+var ErrNotFound = errors.New("URL not found")
+
 // ошибка если ссылка уже существует
 var ErrAlreadyExists = errors.New("URL already exists")
+
+// ошибка если короткая ссылка уже занята другой ссылкой
+var ErrShortAlreadyExists = errors.New("short URL already exists")
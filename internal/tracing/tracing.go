@@ -0,0 +1,79 @@
+// модуль tracing настраивает трассировку запросов на OpenTelemetry.
+// контекст трассировки передается между сервисами в формате W3C traceparent:
+// в заголовках http и в метаданных grpc.
+// спаны отдаются экспортеру: в stdout или, в тестах, в память.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры спанов
+const (
+	// спаны создаются и передаются дальше, но никуда не пишутся
+	ExporterNone = "none"
+	// спаны пишутся в stdout в json
+	ExporterStdout = "stdout"
+)
+
+// имя сервиса в спанах и имя трассировщика
+const serviceName = "urlshrt"
+
+// Ошибка если указан неизвестный экспортер
+var ErrUnknownExporter = errors.New("unknown tracing exporter, expected none or stdout")
+
+// создает экспортер по имени; для none экспортера нет — возвращается nil
+func NewExporter(name string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch name {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, name)
+}
+
+// создает провайдер спанов, который отдает их exporter, и делает его глобальным вместе с форматом traceparent.
+// exporter == nil — спаны никуда не пишутся, но контекст трассировки все равно передается дальше.
+// перед завершением сервиса провайдер нужно остановить, иначе последние спаны потеряются
+func Setup(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider
+}
+
+// начинает спан — дочерний для спана из ctx, если он там есть
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(serviceName).Start(ctx, name, opts...)
+}
+
+// завершает спан и отмечает в нем ошибку, если она есть
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
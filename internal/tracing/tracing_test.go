@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewExporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantNil  bool
+		wantErr  error
+	}{
+		{name: "none", exporter: ExporterNone, wantNil: true},
+		{name: "empty is none", exporter: "", wantNil: true},
+		{name: "stdout", exporter: ExporterStdout},
+		{name: "unknown", exporter: "jaeger", wantNil: true, wantErr: ErrUnknownExporter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := NewExporter(tt.exporter, &bytes.Buffer{})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantNil, exporter == nil)
+		})
+	}
+}
+
+func TestStartEnd(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := Setup(exporter)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("storage is down"))
+	End(parent, nil)
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "storage is down", spans[0].Status.Description)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, spans[1].SpanContext.TraceID(), spans[0].SpanContext.TraceID())
+
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+}